@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Edit the tags and note of the current session",
+	Long: `Add or remove tags and change the note of the running session.
+
+Examples:
+  rune annotate --tag review
+  rune annotate --untag review --tag feature
+  rune annotate --note "Pairing on the billing bug"`,
+	RunE: runAnnotate,
+}
+
+var (
+	annotateTags   []string
+	annotateUntags []string
+	annotateNote   string
+)
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.Flags().StringSliceVarP(&annotateTags, "tag", "t", nil, "Add a tag (repeatable or comma-separated)")
+	annotateCmd.Flags().StringSliceVar(&annotateUntags, "untag", nil, "Remove a tag (repeatable or comma-separated)")
+	annotateCmd.Flags().StringVar(&annotateNote, "note", "", "Replace the session note (use \"\" to clear it)")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(annotateCmd, runAnnotate)
+}
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	var note *string
+	if cmd.Flags().Changed("note") {
+		note = &annotateNote
+	}
+	if len(annotateTags) == 0 && len(annotateUntags) == 0 && note == nil {
+		return fmt.Errorf("nothing to change: use --tag, --untag or --note")
+	}
+
+	// Initialize tracker
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	session, err := tracker.Annotate(annotateTags, annotateUntags, note)
+	if err != nil {
+		telemetry.TrackError(err, "annotate", map[string]interface{}{
+			"step": "tracker_annotate",
+		})
+		return fmt.Errorf("failed to annotate session: %w", err)
+	}
+
+	fmt.Println("✓ Session updated")
+	if len(session.Tags) > 0 {
+		fmt.Printf("🏷  Tags: %s\n", strings.Join(session.Tags, ", "))
+	}
+	if session.Note != "" {
+		fmt.Printf("📝 Note: %s\n", session.Note)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/colors"
+	"github.com/ferg-cod3s/rune/internal/daemon"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// defaultSnooze is how long 'rune break snooze' postpones a break reminder
+const defaultSnooze = 10 * time.Minute
+
+var breakCmd = &cobra.Command{
+	Use:   "break",
+	Short: "Show when your next break is due",
+	Long: `Show when the daemon will remind you to take a break.
+
+A break falls due after settings.break_interval of continuous work; pausing
+the session starts the count again. With settings.pomodoro enabled, each
+reminder suggests a short or long break and Rune tells you when it's over.
+
+  rune break snooze        postpone the reminder by 10 minutes
+  rune break snooze 25m    postpone it by another amount
+
+Break reminders are recorded with the session, and 'rune report' shows how
+many were followed by a break.`,
+	Args: cobra.NoArgs,
+	RunE: runBreak,
+}
+
+var breakSnoozeCmd = &cobra.Command{
+	Use:   "snooze [duration]",
+	Short: "Postpone the current break reminder",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBreakSnooze,
+}
+
+func init() {
+	rootCmd.AddCommand(breakCmd)
+	breakCmd.AddCommand(breakSnoozeCmd)
+
+	// Wrap commands with telemetry
+	telemetry.WrapCommand(breakCmd, runBreak)
+	telemetry.WrapCommand(breakSnoozeCmd, runBreakSnooze)
+}
+
+// dialDaemon connects to the daemon, which owns the break schedule
+func dialDaemon() (*daemon.Client, error) {
+	client, err := daemon.Dial()
+	if errors.Is(err, daemon.ErrNotRunning) {
+		return nil, fmt.Errorf("break reminders need the rune daemon; start it with 'rune daemon start'")
+	}
+	return client, err
+}
+
+func runBreak(cmd *cobra.Command, args []string) error {
+	client, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.BreakStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get break status: %w", err)
+	}
+
+	if !status.Enabled {
+		fmt.Println("⚠ Break reminders are turned off")
+		fmt.Println("💡 Enable notifications.break_reminders in your config")
+		return nil
+	}
+
+	switch {
+	case !status.Active:
+		fmt.Println("No active session")
+	case !status.Running:
+		fmt.Println(colors.StatusPaused("Session paused") + " - the break schedule restarts when you resume")
+	case status.Due.IsZero():
+		fmt.Printf("🧘 Break due after %s of work\n", colors.Duration(formatDuration(status.Next.Worked)))
+		fmt.Println("💡 Pause with 'rune pause', or run 'rune break snooze'")
+	default:
+		until := time.Until(status.Due).Round(time.Minute)
+		if until < 0 {
+			until = 0
+		}
+		when := fmt.Sprintf("in %s (%s)", formatDuration(until), status.Due.Format("15:04"))
+		if status.Snoozed {
+			when += " " + colors.Muted("snoozed")
+		}
+		fmt.Printf("Next break: %s\n", colors.Time(when))
+		fmt.Printf("Working:    %s without a break\n", colors.Duration(formatDuration(status.Next.Worked)))
+	}
+
+	if status.Pomodoro && status.Active {
+		kind := "short"
+		if status.Next.Long {
+			kind = "long"
+		}
+		fmt.Printf("Suggested:  %s %s break\n", colors.Duration(formatDuration(status.Next.Length)), kind)
+	}
+
+	if status.Reminders > 0 {
+		fmt.Printf("Taken:      %s\n", formatBreakCompliance(status.Reminders, status.Taken))
+	}
+
+	return nil
+}
+
+func runBreakSnooze(cmd *cobra.Command, args []string) error {
+	snooze := defaultSnooze
+	if len(args) == 1 {
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid snooze duration %q (e.g. 10m, 1h)", args[0])
+		}
+		snooze = d
+	}
+
+	client, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	until, err := client.SnoozeBreak(snooze)
+	if err != nil {
+		return fmt.Errorf("failed to snooze break reminder: %w", err)
+	}
+
+	fmt.Printf("✓ Break reminder snoozed until %s\n", colors.Time(until.Format("15:04")))
+	return nil
+}
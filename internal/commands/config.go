@@ -138,6 +138,8 @@ func runConfigValidate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("   Projects: %d\n", len(cfg.Projects))
 	fmt.Printf("   Work hours: %.1f\n", cfg.Settings.WorkHours)
 
+	printConfigWarnings(cfg)
+
 	return nil
 }
 
@@ -400,3 +402,16 @@ func showTelemetryExamples() error {
 
 	return nil
 }
+
+// printConfigWarnings prints problems that don't stop the config loading
+func printConfigWarnings(cfg *config.Config) {
+	warnings := cfg.Warnings()
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Println()
+	for _, warning := range warnings {
+		fmt.Printf("⚠ %s\n", warning)
+	}
+}
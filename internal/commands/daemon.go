@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/colors"
+	"github.com/ferg-cod3s/rune/internal/daemon"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the background daemon that watches the current session",
+	Long: `Run Rune's background daemon in the foreground.
+
+While a session runs, the daemon keeps the session database open, detects
+idle time and sends break and end-of-day reminders. Other rune commands talk
+to it over a Unix socket (~/.rune/rune.sock) and open the database
+themselves when it isn't running.
+
+'rune start' launches the daemon automatically unless settings.daemon is
+"manual". To run it from your service manager instead:
+
+  rune daemon install   # write a systemd (Linux) or launchd (macOS) user unit`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the daemon in the background",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStart,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a systemd or launchd user unit for the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonInstall,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStartCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonInstallCmd)
+
+	// Wrap commands with telemetry
+	telemetry.WrapCommand(daemonStartCmd, runDaemonStart)
+	telemetry.WrapCommand(daemonStopCmd, runDaemonStop)
+	telemetry.WrapCommand(daemonInstallCmd, runDaemonInstall)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return err
+	}
+
+	// A command may still have the database open; wait for it to finish
+	var tracker *tracking.Tracker
+	for attempt := 0; ; attempt++ {
+		tracker, err = tracking.NewTracker()
+		if err == nil || attempt == 10 {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	cfg := loadConfigQuietly()
+	if cfg != nil {
+		tracker.SetIdleThreshold(cfg.Settings.IdleThreshold)
+		tracker.SetIdleSources(idleSources(cfg))
+		tracker.SetCalendar(loadCalendar())
+	}
+
+	fmt.Printf("🔮 Rune daemon listening on %s (pid %d)\n", paths.Socket, os.Getpid())
+	return daemon.NewServer(tracker, cfg, paths).Run()
+}
+
+func runDaemonStart(cmd *cobra.Command, args []string) error {
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return err
+	}
+
+	if client, err := daemon.Dial(); err == nil {
+		defer client.Close()
+		status, err := client.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Daemon already running (pid %d)\n", status.PID)
+		return nil
+	}
+
+	if err := daemon.Spawn(paths); err != nil {
+		return err
+	}
+	pid, _ := daemon.ReadPID(paths)
+	fmt.Printf("✓ Daemon started (pid %d)\n", pid)
+	fmt.Printf("📄 Logs: %s\n", paths.Log)
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	client, err := daemon.Dial()
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println("Daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Shutdown(); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+	fmt.Println("✓ Daemon stopped")
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	client, err := daemon.Dial()
+	if err != nil {
+		fmt.Printf("Daemon:       %s\n", colors.StatusStopped("Not running"))
+		fmt.Println(colors.Muted("Commands open the session database directly"))
+		return nil
+	}
+	defer client.Close()
+
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Daemon:       %s\n", colors.StatusRunning("Running"))
+	fmt.Printf("PID:          %d\n", status.PID)
+	fmt.Printf("Uptime:       %s\n", colors.Duration(formatDuration(time.Since(status.Started))))
+	fmt.Printf("Socket:       %s\n", status.Socket)
+	return nil
+}
+
+func runDaemonInstall(cmd *cobra.Command, args []string) error {
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find rune executable: %w", err)
+	}
+
+	unit, err := daemon.UserUnit(executable, paths)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(unit.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(unit.Path), err)
+	}
+	if err := os.WriteFile(unit.Path, []byte(unit.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", unit.Path, err)
+	}
+
+	fmt.Printf("✓ Wrote %s\n", unit.Path)
+	fmt.Println("💡 Enable it with:")
+	fmt.Printf("   %s\n", unit.Enable)
+	fmt.Println("💡 Set 'daemon: manual' under settings so 'rune start' leaves it to your service manager")
+	return nil
+}
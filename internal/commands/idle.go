@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/colors"
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/notifications"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var idleCmd = &cobra.Command{
+	Use:   "idle",
+	Short: "Record what you did while Rune saw you as idle",
+	Long: `When your machine goes idle, Rune pauses the session as of the moment
+you stopped typing. Once you're back, tell Rune what that time was:
+
+  rune idle work            count it as work (reading, a call, a whiteboard)
+  rune idle break           keep it as a break
+  rune idle project <name>  record it as a session for another project
+
+Without a subcommand, Rune shows the idle stretch and asks. The session
+resumes from the moment you came back.`,
+	Args: cobra.NoArgs,
+	RunE: runIdle,
+}
+
+var idleWorkCmd = &cobra.Command{
+	Use:   "work",
+	Short: "Count the idle stretch as work on the session",
+	Args:  cobra.NoArgs,
+	RunE:  runIdleWork,
+}
+
+var idleBreakCmd = &cobra.Command{
+	Use:   "break",
+	Short: "Keep the idle stretch as a break",
+	Args:  cobra.NoArgs,
+	RunE:  runIdleBreak,
+}
+
+var idleProjectCmd = &cobra.Command{
+	Use:   "project <name>",
+	Short: "Record the idle stretch as time on another project",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runIdleProject,
+}
+
+func init() {
+	rootCmd.AddCommand(idleCmd)
+	idleCmd.AddCommand(idleWorkCmd)
+	idleCmd.AddCommand(idleBreakCmd)
+	idleCmd.AddCommand(idleProjectCmd)
+
+	// Wrap commands with telemetry
+	telemetry.WrapCommand(idleCmd, runIdle)
+	telemetry.WrapCommand(idleWorkCmd, runIdleWork)
+	telemetry.WrapCommand(idleBreakCmd, runIdleBreak)
+	telemetry.WrapCommand(idleProjectCmd, runIdleProject)
+}
+
+func runIdle(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	stretch, err := tracker.PendingIdle()
+	if err != nil {
+		return fmt.Errorf("failed to read idle time: %w", err)
+	}
+	if stretch == nil {
+		fmt.Println("✓ No idle time waiting to be reclaimed")
+		return nil
+	}
+
+	return promptIdleStretch(os.Stdout, tracker, stretch)
+}
+
+func runIdleWork(cmd *cobra.Command, args []string) error {
+	return resolveIdle(tracking.IdleWork, "")
+}
+
+func runIdleBreak(cmd *cobra.Command, args []string) error {
+	return resolveIdle(tracking.IdleBreak, "")
+}
+
+func runIdleProject(cmd *cobra.Command, args []string) error {
+	return resolveIdle(tracking.IdleOtherProject, args[0])
+}
+
+// resolveIdle records what the pending idle stretch was
+func resolveIdle(resolution tracking.IdleResolution, project string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	return applyIdleResolution(os.Stdout, tracker, resolution, project)
+}
+
+// applyIdleResolution records what the pending idle stretch was and writes
+// the outcome to out
+func applyIdleResolution(out io.Writer, tracker timeTracker, resolution tracking.IdleResolution, project string) error {
+	stretch, err := tracker.PendingIdle()
+	if err != nil {
+		return fmt.Errorf("failed to read idle time: %w", err)
+	}
+
+	session, err := tracker.ResolveIdle(resolution, project)
+	if err != nil {
+		return fmt.Errorf("failed to reclaim idle time: %w", err)
+	}
+
+	telemetry.Track("idle_reclaimed", map[string]interface{}{
+		"resolution": string(resolution),
+		"minutes":    int(stretch.Duration(time.Now()).Minutes()),
+	})
+
+	switch resolution {
+	case tracking.IdleWork:
+		fmt.Fprintf(out, "✓ Counted idle time as work on %s\n", colors.Project(session.Project))
+	case tracking.IdleBreak:
+		fmt.Fprintln(out, "✓ Kept idle time as a break")
+	case tracking.IdleOtherProject:
+		fmt.Fprintf(out, "✓ Recorded idle time for %s\n", colors.Project(project))
+	}
+	if session.State == tracking.StateRunning {
+		fmt.Fprintf(out, "▶ Resumed %s\n", colors.Project(session.Project))
+	}
+
+	return nil
+}
+
+// promptIdleStretch asks on out what an idle stretch was and records the
+// answer
+func promptIdleStretch(out io.Writer, tracker timeTracker, stretch *tracking.IdleStretch) error {
+	fmt.Fprintln(out, describeIdleStretch(stretch, time.Now()))
+	fmt.Fprint(out, "Was it [w]ork, a [b]reak, or [p]roject <name>? ")
+
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil && response == "" {
+		return nil
+	}
+
+	resolution, project, ok := parseIdleAnswer(response)
+	if !ok {
+		fmt.Fprintln(out, colors.Muted("Skipped; run 'rune idle' to answer later"))
+		return nil
+	}
+	return applyIdleResolution(out, tracker, resolution, project)
+}
+
+// describeIdleStretch explains an idle stretch in one line
+func describeIdleStretch(stretch *tracking.IdleStretch, now time.Time) string {
+	end := "now"
+	if stretch.End != nil {
+		end = stretch.End.Local().Format("15:04")
+	}
+	return fmt.Sprintf("💤 You were idle on %s from %s to %s (%s)",
+		colors.Project(stretch.Project),
+		colors.Time(stretch.Start.Local().Format("15:04")),
+		colors.Time(end),
+		colors.Duration(formatDuration(stretch.Duration(now))))
+}
+
+// parseIdleAnswer reads "w", "work", "b", "break" or "p <name>"/"project <name>"
+func parseIdleAnswer(answer string) (tracking.IdleResolution, string, bool) {
+	fields := strings.Fields(answer)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "w", "work":
+		return tracking.IdleWork, "", len(fields) == 1
+	case "b", "break":
+		return tracking.IdleBreak, "", len(fields) == 1
+	case "p", "project":
+		if len(fields) < 2 {
+			return "", "", false
+		}
+		return tracking.IdleOtherProject, strings.Join(fields[1:], " "), true
+	default:
+		return "", "", false
+	}
+}
+
+// checkPendingIdle asks about an unanswered idle stretch before a command
+// runs, or points at 'rune idle' when there is no terminal to ask on. It
+// writes to stderr, and only asks when the command's output goes to a
+// terminal as text, so the prompt never ends up in piped or saved output.
+func checkPendingIdle(cmd *cobra.Command) {
+	if cmd == idleCmd || cmd.Parent() == idleCmd || cmd == daemonCmd || cmd.Parent() == daemonCmd {
+		return
+	}
+	switch cmd.Name() {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+
+	tracker, err := openTracker()
+	if err != nil {
+		return
+	}
+	defer tracker.Close()
+
+	stretch, err := tracker.PendingIdle()
+	if err != nil || stretch == nil {
+		return
+	}
+
+	if !stdinIsTerminal() || !stdoutIsTerminal() || machineOutput(cmd) {
+		fmt.Fprintf(os.Stderr, "💤 %s of idle time on %s is waiting; run 'rune idle' to record it\n",
+			formatDuration(stretch.Duration(time.Now())), stretch.Project)
+		return
+	}
+
+	if err := promptIdleStretch(os.Stderr, tracker, stretch); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// machineOutput reports whether a command was asked for output other than
+// text, e.g. 'rune report --format json' or 'rune logs --json'
+func machineOutput(cmd *cobra.Command) bool {
+	if flag := cmd.Flags().Lookup("format"); flag != nil && flag.Value.String() != "text" {
+		return true
+	}
+	if flag := cmd.Flags().Lookup("json"); flag != nil && flag.Value.String() == "true" {
+		return true
+	}
+	return false
+}
+
+// idleReclaimNotifier returns an idle end handler that sends a notification
+// asking what the idle stretch was, or nil when notifications are off
+func idleReclaimNotifier(cfg *config.Config) func(*tracking.IdleStretch) {
+	if cfg == nil || !cfg.Settings.Notifications.Enabled || !cfg.Settings.Notifications.IdleDetection {
+		return nil
+	}
+
+	nm := notifications.NewNotificationManager(true)
+	return func(stretch *tracking.IdleStretch) {
+		_ = nm.SendIdleReclaim(stretch.Duration(time.Now()), stretch.Project)
+	}
+}
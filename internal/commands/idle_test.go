@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+func TestParseIdleAnswer(t *testing.T) {
+	tests := []struct {
+		answer     string
+		resolution tracking.IdleResolution
+		project    string
+		ok         bool
+	}{
+		{"w\n", tracking.IdleWork, "", true},
+		{"Work", tracking.IdleWork, "", true},
+		{"b", tracking.IdleBreak, "", true},
+		{"break\n", tracking.IdleBreak, "", true},
+		{"p support", tracking.IdleOtherProject, "support", true},
+		{"project client site\n", tracking.IdleOtherProject, "client site", true},
+		{"p", "", "", false},
+		{"", "", "", false},
+		{"later", "", "", false},
+	}
+
+	for _, test := range tests {
+		resolution, project, ok := parseIdleAnswer(test.answer)
+		if ok != test.ok || resolution != test.resolution || project != test.project {
+			t.Errorf("parseIdleAnswer(%q) = %q, %q, %v, expected %q, %q, %v",
+				test.answer, resolution, project, ok, test.resolution, test.project, test.ok)
+		}
+	}
+}
+
+func TestMachineOutput(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"--format", "text"}, false},
+		{[]string{"--format", "json"}, true},
+		{[]string{"--format=csv"}, true},
+		{[]string{"--json"}, true},
+	}
+
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().String("format", "text", "")
+		cmd.Flags().Bool("json", false, "")
+		if err := cmd.Flags().Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%v) error = %v", tt.args, err)
+		}
+		if got := machineOutput(cmd); got != tt.want {
+			t.Errorf("machineOutput(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	// Commands without either flag only write text
+	if machineOutput(&cobra.Command{Use: "plain"}) {
+		t.Error("machineOutput() = true for a command without output flags")
+	}
+}
@@ -109,9 +109,11 @@ settings:
   break_interval: 50m
   idle_threshold: 10m
 
-projects:
-  - name: "default"
-    detect: ["git:.*", "dir:~/"]
+# Projects are matched by these detect rules before falling back to the
+# package, repository or directory name, e.g.:
+#   - name: "web-app"
+#     detect: ["git:web-app", "dir:~/projects/web-app", "branch:web/.*"]
+projects: []
 
 rituals:
   start:
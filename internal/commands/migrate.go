@@ -364,7 +364,7 @@ func previewTimewarriorImport(intervals []TimewarriorInterval) error {
 }
 
 func importWatsonFrames(frames []WatsonFrame) error {
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to create tracker: %w", err)
 	}
@@ -377,14 +377,7 @@ func importWatsonFrames(frames []WatsonFrame) error {
 
 	for _, frame := range frames {
 		// Create a Rune session from Watson frame
-		session := &tracking.Session{
-			ID:        fmt.Sprintf("watson_%s", frame.ID),
-			Project:   mapProject(frame.Project),
-			StartTime: frame.Start,
-			EndTime:   &frame.Stop,
-			Duration:  frame.Stop.Sub(frame.Start),
-			State:     tracking.StateStopped,
-		}
+		session := watsonFrameToSession(frame)
 
 		// Save the session directly to the database
 		if err := saveImportedSession(tracker, session); err != nil {
@@ -409,7 +402,7 @@ func importWatsonFrames(frames []WatsonFrame) error {
 }
 
 func importTimewarriorIntervals(intervals []TimewarriorInterval) error {
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to create tracker: %w", err)
 	}
@@ -433,20 +426,8 @@ func importTimewarriorIntervals(intervals []TimewarriorInterval) error {
 			continue
 		}
 
-		project := "default"
-		if len(interval.Tags) > 0 {
-			project = mapProject(interval.Tags[0])
-		}
-
 		// Create a Rune session from Timewarrior interval
-		session := &tracking.Session{
-			ID:        fmt.Sprintf("timewarrior_%d", i),
-			Project:   project,
-			StartTime: start,
-			EndTime:   &end,
-			Duration:  end.Sub(start),
-			State:     tracking.StateStopped,
-		}
+		session := timewarriorIntervalToSession(i, interval, start, end)
 
 		// Save the session directly to the database
 		if err := saveImportedSession(tracker, session); err != nil {
@@ -470,7 +451,43 @@ func importTimewarriorIntervals(intervals []TimewarriorInterval) error {
 	return nil
 }
 
-func saveImportedSession(tracker *tracking.Tracker, session *tracking.Session) error {
+// watsonFrameToSession converts a Watson frame into a stopped Rune session,
+// keeping the frame's tags
+func watsonFrameToSession(frame WatsonFrame) *tracking.Session {
+	stop := frame.Stop
+	return &tracking.Session{
+		ID:        fmt.Sprintf("watson_%s", frame.ID),
+		Project:   mapProject(frame.Project),
+		StartTime: frame.Start,
+		EndTime:   &stop,
+		Duration:  stop.Sub(frame.Start),
+		State:     tracking.StateStopped,
+		Tags:      tracking.NormalizeTags(frame.Tags),
+	}
+}
+
+// timewarriorIntervalToSession converts a Timewarrior interval into a stopped
+// Rune session. The first tag becomes the project; the rest are kept as tags.
+func timewarriorIntervalToSession(index int, interval TimewarriorInterval, start, end time.Time) *tracking.Session {
+	project := "default"
+	var tags []string
+	if len(interval.Tags) > 0 {
+		project = mapProject(interval.Tags[0])
+		tags = tracking.NormalizeTags(interval.Tags[1:])
+	}
+
+	return &tracking.Session{
+		ID:        fmt.Sprintf("timewarrior_%d", index),
+		Project:   project,
+		StartTime: start,
+		EndTime:   &end,
+		Duration:  end.Sub(start),
+		State:     tracking.StateStopped,
+		Tags:      tags,
+	}
+}
+
+func saveImportedSession(tracker timeTracker, session *tracking.Session) error {
 	return tracker.SaveImportedSession(session)
 }
 
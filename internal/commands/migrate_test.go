@@ -257,13 +257,10 @@ func TestWatsonFrameToRuneSession(t *testing.T) {
 		Tags:    []string{"tag1", "tag2"},
 	}
 
-	session := &tracking.Session{
-		ID:        "watson_test-frame",
-		Project:   "test-project",
-		StartTime: frame.Start,
-		EndTime:   &frame.Stop,
-		Duration:  frame.Stop.Sub(frame.Start),
-		State:     tracking.StateStopped,
+	session := watsonFrameToSession(frame)
+
+	if session.ID != "watson_test-frame" {
+		t.Errorf("Expected ID watson_test-frame, got %s", session.ID)
 	}
 
 	// Verify conversion logic
@@ -279,6 +276,10 @@ func TestWatsonFrameToRuneSession(t *testing.T) {
 	if session.Project != frame.Project {
 		t.Errorf("Expected project %s, got %s", frame.Project, session.Project)
 	}
+
+	if len(session.Tags) != 2 || session.Tags[0] != "tag1" || session.Tags[1] != "tag2" {
+		t.Errorf("Expected tags [tag1 tag2], got %v", session.Tags)
+	}
 }
 
 func TestTimewarriorIntervalToRuneSession(t *testing.T) {
@@ -298,14 +299,7 @@ func TestTimewarriorIntervalToRuneSession(t *testing.T) {
 		t.Fatalf("Failed to parse end time: %v", err)
 	}
 
-	session := &tracking.Session{
-		ID:        "timewarrior_0",
-		Project:   "project", // First tag becomes project
-		StartTime: start,
-		EndTime:   &end,
-		Duration:  end.Sub(start),
-		State:     tracking.StateStopped,
-	}
+	session := timewarriorIntervalToSession(0, interval, start, end)
 
 	// Verify conversion logic
 	expectedDuration := 2*time.Hour + 30*time.Minute
@@ -313,9 +307,14 @@ func TestTimewarriorIntervalToRuneSession(t *testing.T) {
 		t.Errorf("Expected duration %v, got %v", expectedDuration, session.Duration)
 	}
 
+	// First tag becomes project, the rest stay as tags
 	if session.Project != "project" {
 		t.Errorf("Expected project 'project', got %s", session.Project)
 	}
+
+	if len(session.Tags) != 2 || session.Tags[0] != "tag1" || session.Tags[1] != "tag2" {
+		t.Errorf("Expected tags [tag1 tag2], got %v", session.Tags)
+	}
 }
 
 func TestPreviewWatsonImportStats(t *testing.T) {
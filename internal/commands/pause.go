@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +30,7 @@ func runPause(cmd *cobra.Command, args []string) error {
 	fmt.Println("⏸ Pausing work timer...")
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
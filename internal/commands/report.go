@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ferg-cod3s/rune/internal/colors"
 	"github.com/ferg-cod3s/rune/internal/tracking"
@@ -22,327 +25,504 @@ This command can show:
 - Daily work summaries
 - Weekly productivity reports
 - Project-based time allocation
-- Monthly trends and insights`,
+- Monthly trends and insights
+
+Use --from and --to for any other period. Both accept dates (2026-09-15),
+months (2026-09), years (2026) and expressions such as "today", "yesterday",
+"last monday", "last week", "this month" or "3 days ago". A period given to
+--to is included in the report.
+
+Use --group-by to break the report down by day, week, project or tag.
+Projects detected inside a monorepo are named "<repo>/<package>"; use
+--group-by repo or --project <repo> to report at the repository level.`,
+	Example: `  rune report --week
+  rune report --from "last monday" --to friday --group-by day
+  rune report --from 2026-09 --group-by project --format csv`,
 	RunE: runReport,
 }
 
 var (
-	today   bool
-	week    bool
-	month   bool
-	project string
-	format  string
-	output  string
+	today      bool
+	week       bool
+	month      bool
+	reportFrom string
+	reportTo   string
+	groupBy    string
+	project    string
+	reportTags []string
+	byTag      bool
+	format     string
+	output     string
 )
 
+// reportGroupings are the values accepted by --group-by
+var reportGroupings = []string{"day", "week", "project", "repo", "tag"}
+
 func init() {
 	rootCmd.AddCommand(reportCmd)
 
 	reportCmd.Flags().BoolVar(&today, "today", false, "Show today's report")
 	reportCmd.Flags().BoolVar(&week, "week", false, "Show this week's report")
 	reportCmd.Flags().BoolVar(&month, "month", false, "Show this month's report")
-	reportCmd.Flags().StringVar(&project, "project", "", "Filter by project name")
+	reportCmd.Flags().StringVar(&reportFrom, "from", "", "Start of the report period (e.g. 2026-09-01, \"last monday\")")
+	reportCmd.Flags().StringVar(&reportTo, "to", "", "End of the report period, inclusive (default: today)")
+	reportCmd.Flags().StringVar(&groupBy, "group-by", "", "Group the report by day, week, project, repo or tag")
+	reportCmd.Flags().StringVar(&project, "project", "", "Filter by project name (a repository includes its packages)")
+	reportCmd.Flags().StringSliceVar(&reportTags, "tag", nil, "Filter by tag (repeatable; matches sessions with any of the tags)")
+	reportCmd.Flags().BoolVar(&byTag, "by-tag", false, "Group the report by tag (same as --group-by tag)")
 	reportCmd.Flags().StringVar(&format, "format", "text", "Output format: text, csv, json")
 	reportCmd.Flags().StringVar(&output, "output", "", "Output file (default: stdout)")
 }
 
+// reportPeriod is the time range covered by a report
+type reportPeriod struct {
+	// From and To bound the report to [From, To). From is zero when the
+	// report has no lower bound.
+	From  time.Time
+	To    time.Time
+	Title string
+}
+
+// Days returns the number of calendar days in the period
+func (p reportPeriod) Days() int {
+	if p.From.IsZero() {
+		return 0
+	}
+	days := 0
+	for day := p.From; day.Before(p.To); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+// ReportGroup is one row of a grouped report
+type ReportGroup struct {
+	Key      string
+	Sessions int
+	Duration time.Duration
+	Breaks   int
+}
+
 func runReport(cmd *cobra.Command, args []string) error {
-	var sessions []*tracking.Session
-	var totalDuration time.Duration
-	var err error
+	now := time.Now()
+	calendar := loadCalendar()
+
+	period, err := resolveReportPeriod(now, calendar)
+	if err != nil {
+		return err
+	}
+
+	grouping, err := resolveGrouping()
+	if err != nil {
+		return err
+	}
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
 	defer tracker.Close()
+	tracker.SetCalendar(calendar)
 
-	// Get data based on period
-	if today {
-		sessions, totalDuration, err = getTodayData(tracker)
-	} else if week {
-		sessions, totalDuration, err = getWeekData(tracker)
-	} else if month {
-		sessions, totalDuration, err = getMonthData(tracker)
-	} else {
-		// Default to today's report
-		sessions, totalDuration, err = getTodayData(tracker)
-	}
-
+	sessions, err := tracker.QuerySessions(tracking.SessionQuery{
+		From:    period.From,
+		To:      period.To,
+		Project: project,
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to query sessions: %w", err)
 	}
 
-	// Filter by project if specified
-	if project != "" {
-		var filteredSessions []*tracking.Session
-		var filteredDuration time.Duration
-		for _, session := range sessions {
-			if session.Project == project {
-				filteredSessions = append(filteredSessions, session)
-				filteredDuration += session.Duration
-			}
+	// Filter by tags if specified, then split sessions that cross midnight so
+	// each day (and the period as a whole) only counts the time worked in it
+	var totalDuration time.Duration
+	var pieces []*tracking.Session
+	for _, session := range sessions {
+		if !matchesReportFilters(session) {
+			continue
+		}
+		for _, piece := range calendar.SplitByDay(session, period.From, period.To) {
+			pieces = append(pieces, piece)
+			totalDuration += piece.Duration
 		}
-		sessions = filteredSessions
-		totalDuration = filteredDuration
 	}
+	sessions = pieces
 
 	// Output based on format
 	switch format {
 	case "csv":
+		if grouping != "" {
+			return exportGroupsCSV(grouping, groupSessions(sessions, grouping, calendar))
+		}
 		return exportCSV(sessions, totalDuration)
 	case "json":
-		return exportJSON(sessions, totalDuration)
+		return exportJSON(period, grouping, calendar, sessions, totalDuration)
 	default:
-		// Show text report
-		if today {
-			return showTodayReport(tracker)
-		} else if week {
-			return showWeekReport(tracker)
-		} else if month {
-			return showMonthReport(tracker)
-		} else {
-			return showTodayReport(tracker)
+		return showReport(period, grouping, calendar, sessions, totalDuration)
+	}
+}
+
+// resolveReportPeriod works out the report period from the period flags
+func resolveReportPeriod(now time.Time, calendar tracking.Calendar) (reportPeriod, error) {
+	if reportFrom != "" || reportTo != "" {
+		if today || week || month {
+			return reportPeriod{}, fmt.Errorf("--from/--to cannot be combined with --today, --week or --month")
 		}
+		return parseReportPeriod(reportFrom, reportTo, now, calendar)
+	}
+
+	switch {
+	case week:
+		start, end := calendar.Week(now)
+		return reportPeriod{From: start, To: end, Title: "This Week's Report"}, nil
+	case month:
+		start, end := calendar.Month(now)
+		return reportPeriod{From: start, To: end, Title: "This Month's Report"}, nil
+	default:
+		// Default to today's report
+		start, end := calendar.Day(now)
+		return reportPeriod{From: start, To: end, Title: "Today's Report"}, nil
 	}
 }
 
-func showTodayReport(tracker *tracking.Tracker) error {
-	fmt.Println(colors.Header("📈 Today's Report"))
-	fmt.Println(colors.Secondary("================="))
-	fmt.Println()
+// parseReportPeriod builds a report period from --from and --to expressions.
+// A missing --from leaves the period open-ended; a missing --to means today.
+func parseReportPeriod(fromExpr, toExpr string, now time.Time, calendar tracking.Calendar) (reportPeriod, error) {
+	var period reportPeriod
 
-	// Get daily total
-	dailyTotal, err := tracker.GetDailyTotal()
-	if err != nil {
-		return fmt.Errorf("failed to get daily total: %w", err)
+	if fromExpr != "" {
+		start, _, err := parseDateRange(fromExpr, now, calendar)
+		if err != nil {
+			return period, fmt.Errorf("invalid --from: %w", err)
+		}
+		period.From = start
 	}
 
-	// Get project stats for today
-	projectStats, err := tracker.GetProjectStats()
+	if toExpr == "" {
+		toExpr = "today"
+	}
+	_, end, err := parseDateRange(toExpr, now, calendar)
 	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
+		return period, fmt.Errorf("invalid --to: %w", err)
 	}
+	period.To = end
 
-	// Get today's sessions
-	sessions, err := tracker.GetSessionHistory(50) // Get more to filter by today
-	if err != nil {
-		return fmt.Errorf("failed to get session history: %w", err)
+	if !period.From.IsZero() && !period.From.Before(period.To) {
+		return period, fmt.Errorf("report period is empty: --from %s is not before --to %s",
+			period.From.Format("2006-01-02 15:04"), period.To.Format("2006-01-02 15:04"))
 	}
 
-	// Filter sessions for today
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-	var todaySessions []*tracking.Session
-	for _, session := range sessions {
-		if session.StartTime.After(today) && session.StartTime.Before(tomorrow) {
-			if project == "" || session.Project == project {
-				todaySessions = append(todaySessions, session)
-			}
-		}
+	last := period.To.Add(-time.Nanosecond)
+	switch {
+	case period.From.IsZero():
+		period.Title = fmt.Sprintf("Report up to %s", last.Format("Jan 2, 2006"))
+	case period.Days() == 1:
+		period.Title = fmt.Sprintf("Report for %s", period.From.Format("Mon Jan 2, 2006"))
+	default:
+		period.Title = fmt.Sprintf("Report for %s – %s", period.From.Format("Jan 2, 2006"), last.Format("Jan 2, 2006"))
 	}
 
-	fmt.Printf("Total Time:    %s\n", colors.Duration(formatDuration(dailyTotal)))
-	fmt.Printf("Sessions:      %s\n", colors.Accent(fmt.Sprintf("%d", len(todaySessions))))
+	return period, nil
+}
 
-	if len(projectStats) > 0 {
-		fmt.Printf("\n%s\n", colors.Subheader("Project Breakdown:"))
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", colors.Project(proj), colors.Duration(formatDuration(duration)))
-			}
-		}
+// resolveGrouping validates --group-by, folding in the older --by-tag flag
+func resolveGrouping() (string, error) {
+	grouping := strings.ToLower(strings.TrimSpace(groupBy))
+	if grouping == "" && byTag {
+		grouping = "tag"
+	}
+	if grouping == "" {
+		return "", nil
 	}
 
-	if len(todaySessions) > 0 {
-		fmt.Printf("\n%s\n", colors.Subheader("Today's Sessions:"))
-		for _, session := range todaySessions {
-			fmt.Printf("  %s  %-15s  %s  %s\n",
-				colors.Time(session.StartTime.Format("15:04")),
-				colors.Project(session.Project),
-				colors.Duration(formatDuration(session.Duration)),
-				colors.RelativeTime(formatRelativeTime(session.StartTime)))
+	for _, valid := range reportGroupings {
+		if grouping == valid {
+			return grouping, nil
 		}
 	}
-
-	return nil
+	return "", fmt.Errorf("invalid --group-by %q (use %s)", groupBy, strings.Join(reportGroupings, ", "))
 }
 
-func showWeekReport(tracker *tracking.Tracker) error {
-	fmt.Println(colors.Header("📈 This Week's Report"))
-	fmt.Println(colors.Secondary("====================="))
-	fmt.Println()
-
-	// Get weekly total
-	weeklyTotal, err := tracker.GetWeeklyTotal()
-	if err != nil {
-		return fmt.Errorf("failed to get weekly total: %w", err)
+// matchesReportFilters reports whether a session passes the --project and --tag filters
+func matchesReportFilters(session *tracking.Session) bool {
+	if project != "" && !tracking.ProjectMatches(session.Project, project) {
+		return false
 	}
-
-	// Calculate daily average (divide by 7 days)
-	dailyAverage := weeklyTotal / 7
-
-	// Get project stats
-	projectStats, err := tracker.GetProjectStats()
-	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
+	if len(reportTags) == 0 {
+		return true
 	}
-
-	fmt.Printf("Total Time:    %s\n", colors.Duration(formatDuration(weeklyTotal)))
-	fmt.Printf("Daily Average: %s\n", colors.Duration(formatDuration(dailyAverage)))
-
-	if len(projectStats) > 0 {
-		fmt.Printf("\n%s\n", colors.Subheader("Project Breakdown:"))
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", colors.Project(proj), colors.Duration(formatDuration(duration)))
-			}
+	for _, tag := range reportTags {
+		if session.HasTag(tag) {
+			return true
 		}
 	}
-
-	return nil
+	return false
 }
 
-func showMonthReport(tracker *tracking.Tracker) error {
-	fmt.Println(colors.Header("📈 This Month's Report"))
-	fmt.Println(colors.Secondary("======================"))
-	fmt.Println()
-
-	// Get monthly total (approximate - get all sessions and filter)
-	sessions, err := tracker.GetSessionHistory(1000) // Get many sessions
-	if err != nil {
-		return fmt.Errorf("failed to get session history: %w", err)
+// groupSessions totals sessions by day, week, project, repo or tag. Day and week
+// groups are ordered chronologically, project and tag groups by time spent.
+// Sessions with several tags count toward each of them; untagged sessions are
+// grouped as "(untagged)". Pieces of a session split at midnight count as one
+// session within a group.
+func groupSessions(sessions []*tracking.Session, grouping string, calendar tracking.Calendar) []ReportGroup {
+	groups := make(map[string]*ReportGroup)
+	seen := make(map[string]bool)
+	add := func(key string, session *tracking.Session) {
+		group, ok := groups[key]
+		if !ok {
+			group = &ReportGroup{Key: key}
+			groups[key] = group
+		}
+		if !seen[key+"\x00"+session.ID] {
+			seen[key+"\x00"+session.ID] = true
+			group.Sessions++
+		}
+		group.Duration += session.Duration
+		group.Breaks += session.Breaks()
 	}
 
-	// Filter for this month
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
-
-	var monthlyTotal time.Duration
-	var monthlySessions []*tracking.Session
 	for _, session := range sessions {
-		if session.StartTime.After(monthStart) && session.StartTime.Before(monthEnd) {
-			if project == "" || session.Project == project {
-				monthlyTotal += session.Duration
-				monthlySessions = append(monthlySessions, session)
+		switch grouping {
+		case "day":
+			add(session.StartTime.Format("2006-01-02"), session)
+		case "week":
+			add(calendar.StartOfWeek(session.StartTime).Format("2006-01-02"), session)
+		case "repo":
+			repo, _, _ := strings.Cut(session.Project, "/")
+			add(repo, session)
+		case "tag":
+			if len(session.Tags) == 0 {
+				add("(untagged)", session)
+			}
+			for _, tag := range session.Tags {
+				add(tag, session)
 			}
+		default:
+			add(session.Project, session)
 		}
 	}
 
-	// Calculate daily average
-	daysInMonth := monthEnd.Sub(monthStart).Hours() / 24
-	dailyAverage := time.Duration(float64(monthlyTotal) / daysInMonth)
+	result := make([]ReportGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
 
-	// Get project stats
-	projectStats, err := tracker.GetProjectStats()
-	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
+	sort.Slice(result, func(i, j int) bool {
+		if grouping == "day" || grouping == "week" {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// groupingLabel returns the column heading for a grouping
+func groupingLabel(grouping string) string {
+	switch grouping {
+	case "day":
+		return "Day"
+	case "week":
+		return "Week of"
+	case "repo":
+		return "Repository"
+	case "tag":
+		return "Tag"
+	default:
+		return "Project"
 	}
+}
 
-	fmt.Printf("Total Time:    %s\n", colors.Duration(formatDuration(monthlyTotal)))
-	fmt.Printf("Daily Average: %s\n", colors.Duration(formatDuration(dailyAverage)))
-	fmt.Printf("Sessions:      %s\n", colors.Accent(fmt.Sprintf("%d", len(monthlySessions))))
+func showReport(period reportPeriod, grouping string, calendar tracking.Calendar, sessions []*tracking.Session, totalDuration time.Duration) error {
+	title := "📈 " + period.Title
+	fmt.Println(colors.Header(title))
+	fmt.Println(colors.Secondary(strings.Repeat("=", utf8.RuneCountInString(title))))
+	fmt.Println()
 
-	if len(projectStats) > 0 {
-		fmt.Printf("\n%s\n", colors.Subheader("Project Breakdown:"))
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", colors.Project(proj), colors.Duration(formatDuration(duration)))
+	days := period.Days()
+
+	fmt.Printf("Total Time:    %s\n", colors.Duration(formatDuration(totalDuration)))
+	if days > 1 {
+		dailyAverage := totalDuration / time.Duration(days)
+		fmt.Printf("Daily Average: %s\n", colors.Duration(formatDuration(dailyAverage)))
+	}
+	fmt.Printf("Sessions:      %s\n", colors.Accent(fmt.Sprintf("%d", countSessions(sessions))))
+	fmt.Printf("Breaks:        %s\n", formatSessionBreaks(sessions))
+	if reminders, taken := breakCompliance(sessions); reminders > 0 {
+		fmt.Printf("Compliance:    %s\n", formatBreakCompliance(reminders, taken))
+	}
+
+	// Break down by project unless another grouping was asked for
+	breakdown := grouping
+	if breakdown == "" {
+		breakdown = "project"
+	}
+	if groups := groupSessions(sessions, breakdown, calendar); len(groups) > 0 {
+		fmt.Printf("\n%s\n", colors.Subheader(fmt.Sprintf("%s Breakdown:", strings.TrimSuffix(groupingLabel(breakdown), " of"))))
+		printGroupTable(breakdown, groups, totalDuration)
+	}
+
+	// List individual sessions for single-day reports
+	if days == 1 && len(sessions) > 0 {
+		fmt.Printf("\n%s\n", colors.Subheader("Sessions:"))
+		for _, session := range sessions {
+			details := ""
+			if len(session.Tags) > 0 {
+				details += "  " + strings.Join(session.Tags, ",")
+			}
+			if session.Breaks() > 0 {
+				details += "  breaks: " + formatBreaks(session.Breaks(), session.BreakDuration(time.Now()))
 			}
+			if session.Note != "" {
+				details += "  " + session.Note
+			}
+			fmt.Printf("  %s  %-15s  %s  %s%s\n",
+				colors.Time(session.StartTime.Format("15:04")),
+				colors.Project(session.Project),
+				colors.Duration(formatDuration(session.Duration)),
+				colors.RelativeTime(formatRelativeTime(session.StartTime)),
+				colors.Muted(details))
 		}
 	}
 
 	return nil
 }
 
-// getTodayData returns today's sessions and total duration
-func getTodayData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(50)
-	if err != nil {
-		return nil, 0, err
+// printGroupTable prints grouped totals as an aligned table
+func printGroupTable(grouping string, groups []ReportGroup, totalDuration time.Duration) {
+	label := groupingLabel(grouping)
+	width := utf8.RuneCountInString(label)
+	for _, group := range groups {
+		if n := utf8.RuneCountInString(group.Key); n > width {
+			width = n
+		}
 	}
 
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-	var todaySessions []*tracking.Session
-	var totalDuration time.Duration
-
-	for _, session := range sessions {
-		if session.StartTime.After(today) && session.StartTime.Before(tomorrow) {
-			todaySessions = append(todaySessions, session)
-			totalDuration += session.Duration
+	fmt.Printf("  %s  %8s  %9s  %5s\n",
+		colors.Muted(padRight(label, width)), colors.Muted("Sessions"), colors.Muted("Time"), colors.Muted("Share"))
+	for _, group := range groups {
+		share := 0.0
+		if totalDuration > 0 {
+			share = float64(group.Duration) / float64(totalDuration) * 100
 		}
+		key := padRight(group.Key, width)
+		if grouping == "project" {
+			key = colors.Project(key)
+		} else {
+			key = colors.Accent(key)
+		}
+		fmt.Printf("  %s  %8d  %s  %4.0f%%\n",
+			key, group.Sessions, colors.Duration(fmt.Sprintf("%9s", formatDuration(group.Duration))), share)
 	}
+}
 
-	return todaySessions, totalDuration, nil
+// padRight pads s with spaces to width runes
+func padRight(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
 }
 
-// getWeekData returns this week's sessions and total duration
-func getWeekData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(500)
-	if err != nil {
-		return nil, 0, err
+// countSessions counts distinct sessions, so a session split at midnight
+// counts once
+func countSessions(sessions []*tracking.Session) int {
+	seen := make(map[string]bool)
+	for _, session := range sessions {
+		seen[session.ID] = true
 	}
+	return len(seen)
+}
 
+// formatSessionBreaks summarizes the breaks taken across sessions
+func formatSessionBreaks(sessions []*tracking.Session) string {
 	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekStart = weekStart.Truncate(24 * time.Hour)
-	weekEnd := weekStart.Add(7 * 24 * time.Hour)
-	var weekSessions []*tracking.Session
-	var totalDuration time.Duration
+	var count int
+	var total time.Duration
+	for _, session := range sessions {
+		count += session.Breaks()
+		total += session.BreakDuration(now)
+	}
+	return formatBreaks(count, total)
+}
 
+// breakCompliance counts the break reminders sent across sessions and how
+// many were followed by a break
+func breakCompliance(sessions []*tracking.Session) (reminders, taken int) {
 	for _, session := range sessions {
-		if session.StartTime.After(weekStart) && session.StartTime.Before(weekEnd) {
-			weekSessions = append(weekSessions, session)
-			totalDuration += session.Duration
-		}
+		r, t := session.BreakCompliance()
+		reminders += r
+		taken += t
 	}
+	return reminders, taken
+}
 
-	return weekSessions, totalDuration, nil
+// formatBreakCompliance formats break compliance, e.g. "3 of 4 taken (75%)"
+func formatBreakCompliance(reminders, taken int) string {
+	return fmt.Sprintf("%d of %d taken (%.0f%%)", taken, reminders, float64(taken)/float64(reminders)*100)
 }
 
-// getMonthData returns this month's sessions and total duration
-func getMonthData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(1000)
+// createReportOutput opens the --output file, or returns stdout
+func createReportOutput() (*os.File, error) {
+	if output == "" {
+		return os.Stdout, nil
+	}
+	file, err := os.Create(output)
 	if err != nil {
-		return nil, 0, err
+		return nil, fmt.Errorf("failed to create output file: %w", err)
 	}
+	return file, nil
+}
 
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
-	var monthSessions []*tracking.Session
-	var totalDuration time.Duration
+// exportGroupsCSV exports grouped totals to CSV format
+func exportGroupsCSV(grouping string, groups []ReportGroup) error {
+	file, err := createReportOutput()
+	if err != nil {
+		return err
+	}
+	if file != os.Stdout {
+		defer file.Close()
+	}
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
 
-	for _, session := range sessions {
-		if session.StartTime.After(monthStart) && session.StartTime.Before(monthEnd) {
-			monthSessions = append(monthSessions, session)
-			totalDuration += session.Duration
+	if err := writer.Write([]string{groupingLabel(grouping), "Sessions", "Duration (minutes)", "Breaks"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, group := range groups {
+		record := []string{
+			group.Key,
+			strconv.Itoa(group.Sessions),
+			strconv.FormatFloat(group.Duration.Minutes(), 'f', 2, 64),
+			strconv.Itoa(group.Breaks),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
 		}
 	}
 
-	return monthSessions, totalDuration, nil
+	return nil
 }
 
 // exportCSV exports sessions to CSV format
 func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error {
-	var writer *csv.Writer
-	if output != "" {
-		file, err := os.Create(output)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
+	file, err := createReportOutput()
+	if err != nil {
+		return err
+	}
+	if file != os.Stdout {
 		defer file.Close()
-		writer = csv.NewWriter(file)
-	} else {
-		writer = csv.NewWriter(os.Stdout)
 	}
+	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
 	// Write header
-	if err := writer.Write([]string{"Date", "Start Time", "End Time", "Project", "Duration (minutes)", "State"}); err != nil {
+	if err := writer.Write([]string{"Date", "Start Time", "End Time", "Project", "Duration (minutes)", "Breaks", "Break Time (minutes)", "State", "Tags", "Note"}); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
@@ -354,6 +534,7 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 		}
 
 		durationMinutes := strconv.FormatFloat(session.Duration.Minutes(), 'f', 2, 64)
+		breakMinutes := strconv.FormatFloat(session.BreakDuration(time.Now()).Minutes(), 'f', 2, 64)
 
 		record := []string{
 			session.StartTime.Format("2006-01-02"),
@@ -361,7 +542,11 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 			endTime,
 			session.Project,
 			durationMinutes,
+			strconv.Itoa(session.Breaks()),
+			breakMinutes,
 			session.State.String(),
+			strings.Join(session.Tags, ";"),
+			session.Note,
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -371,7 +556,7 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 
 	// Write summary row
 	totalMinutes := strconv.FormatFloat(totalDuration.Minutes(), 'f', 2, 64)
-	summaryRecord := []string{"TOTAL", "", "", "", totalMinutes, ""}
+	summaryRecord := []string{"TOTAL", "", "", "", totalMinutes, "", "", "", "", ""}
 	if err := writer.Write(summaryRecord); err != nil {
 		return fmt.Errorf("failed to write CSV summary: %w", err)
 	}
@@ -382,35 +567,86 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 // ReportData represents the structure for JSON export
 type ReportData struct {
 	GeneratedAt   time.Time              `json:"generated_at"`
+	From          *time.Time             `json:"from,omitempty"`
+	To            time.Time              `json:"to"`
 	TotalDuration string                 `json:"total_duration"`
+	GroupBy       string                 `json:"group_by,omitempty"`
+	Groups        []ReportGroupData      `json:"groups,omitempty"`
 	Sessions      []*tracking.Session    `json:"sessions"`
 	Summary       map[string]interface{} `json:"summary"`
 }
 
+// ReportGroupData represents one grouped row in the JSON export
+type ReportGroupData struct {
+	Key             string  `json:"key"`
+	Sessions        int     `json:"sessions"`
+	Duration        string  `json:"duration"`
+	DurationMinutes float64 `json:"duration_minutes"`
+	Breaks          int     `json:"breaks"`
+}
+
 // exportJSON exports sessions to JSON format
-func exportJSON(sessions []*tracking.Session, totalDuration time.Duration) error {
+func exportJSON(period reportPeriod, grouping string, calendar tracking.Calendar, sessions []*tracking.Session, totalDuration time.Duration) error {
 	// Calculate project breakdown
+	now := time.Now()
 	projectStats := make(map[string]time.Duration)
+	var totalBreaks int
+	var totalBreakTime, longestStretch time.Duration
 	for _, session := range sessions {
 		projectStats[session.Project] += session.Duration
+		totalBreaks += session.Breaks()
+		totalBreakTime += session.BreakDuration(now)
+		if stretch := session.LongestStretch(now); stretch > longestStretch {
+			longestStretch = stretch
+		}
 	}
+	reminders, taken := breakCompliance(sessions)
 
-	// Convert project stats to string format for JSON
+	// Convert project and tag stats to string format for JSON
 	projectStatsStr := make(map[string]string)
 	for project, duration := range projectStats {
 		projectStatsStr[project] = formatDuration(duration)
 	}
+	tagStatsStr := make(map[string]string)
+	for _, group := range groupSessions(sessions, "tag", calendar) {
+		tagStatsStr[group.Key] = formatDuration(group.Duration)
+	}
 
 	data := ReportData{
-		GeneratedAt:   time.Now(),
+		GeneratedAt:   now,
+		To:            period.To,
 		TotalDuration: formatDuration(totalDuration),
+		GroupBy:       grouping,
 		Sessions:      sessions,
 		Summary: map[string]interface{}{
-			"total_sessions":    len(sessions),
+			"total_sessions":   countSessions(sessions),
+			"total_breaks":     totalBreaks,
+			"total_break_time": formatDuration(totalBreakTime),
+			"break_compliance": map[string]interface{}{
+				"reminders":       reminders,
+				"taken":           taken,
+				"longest_stretch": formatDuration(longestStretch),
+			},
 			"project_breakdown": projectStatsStr,
+			"tag_breakdown":     tagStatsStr,
 		},
 	}
 
+	if !period.From.IsZero() {
+		data.From = &period.From
+	}
+	if grouping != "" {
+		for _, group := range groupSessions(sessions, grouping, calendar) {
+			data.Groups = append(data.Groups, ReportGroupData{
+				Key:             group.Key,
+				Sessions:        group.Sessions,
+				Duration:        formatDuration(group.Duration),
+				DurationMinutes: group.Duration.Minutes(),
+				Breaks:          group.Breaks,
+			})
+		}
+	}
+
 	var jsonData []byte
 	var err error
 	jsonData, err = json.MarshalIndent(data, "", "  ")
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+func TestParseReportPeriod(t *testing.T) {
+	now := time.Date(2026, 9, 16, 14, 30, 0, 0, time.Local)
+
+	period, err := parseReportPeriod("last monday", "friday", now, tracking.DefaultCalendar())
+	if err == nil {
+		t.Errorf("parseReportPeriod should reject a --to before --from, got %+v", period)
+	}
+
+	period, err = parseReportPeriod("2026-09", "", now, tracking.DefaultCalendar())
+	if err != nil {
+		t.Fatalf("parseReportPeriod returned error: %v", err)
+	}
+	if expected := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local); !period.From.Equal(expected) {
+		t.Errorf("From = %v, expected %v", period.From, expected)
+	}
+	if expected := time.Date(2026, 9, 17, 0, 0, 0, 0, time.Local); !period.To.Equal(expected) {
+		t.Errorf("To = %v, expected %v", period.To, expected)
+	}
+	if period.Days() != 16 {
+		t.Errorf("Days() = %d, expected 16", period.Days())
+	}
+
+	period, err = parseReportPeriod("", "2026-08", now, tracking.DefaultCalendar())
+	if err != nil {
+		t.Fatalf("parseReportPeriod returned error: %v", err)
+	}
+	if !period.From.IsZero() || period.Days() != 0 {
+		t.Errorf("parseReportPeriod without --from should be open-ended, got %+v", period)
+	}
+}
+
+func TestGroupSessions(t *testing.T) {
+	session := func(project string, start time.Time, hours int, tags ...string) *tracking.Session {
+		return &tracking.Session{
+			ID:        start.Format(time.RFC3339),
+			Project:   project,
+			StartTime: start,
+			Duration:  time.Duration(hours) * time.Hour,
+			Tags:      tags,
+		}
+	}
+	monday := time.Date(2026, 9, 14, 9, 0, 0, 0, time.Local)
+	sessions := []*tracking.Session{
+		session("api", monday, 1, "review"),
+		session("web", monday.Add(3*time.Hour), 3),
+		session("api", monday.AddDate(0, 0, 7), 4, "review", "ops"),
+	}
+
+	tests := []struct {
+		grouping  string
+		keys      []string
+		durations []time.Duration
+	}{
+		{"project", []string{"api", "web"}, []time.Duration{5 * time.Hour, 3 * time.Hour}},
+		{"day", []string{"2026-09-14", "2026-09-21"}, []time.Duration{4 * time.Hour, 4 * time.Hour}},
+		{"week", []string{"2026-09-13", "2026-09-20"}, []time.Duration{4 * time.Hour, 4 * time.Hour}},
+		{"tag", []string{"review", "ops", "(untagged)"}, []time.Duration{5 * time.Hour, 4 * time.Hour, 3 * time.Hour}},
+	}
+
+	for _, test := range tests {
+		groups := groupSessions(sessions, test.grouping, tracking.DefaultCalendar())
+		if len(groups) != len(test.keys) {
+			t.Errorf("groupSessions(%s) returned %d groups, expected %d", test.grouping, len(groups), len(test.keys))
+			continue
+		}
+		for i, group := range groups {
+			if group.Key != test.keys[i] || group.Duration != test.durations[i] {
+				t.Errorf("groupSessions(%s)[%d] = %s %v, expected %s %v",
+					test.grouping, i, group.Key, group.Duration, test.keys[i], test.durations[i])
+			}
+		}
+	}
+}
+
+func TestGroupSessions_SplitAtMidnight(t *testing.T) {
+	calendar := tracking.DefaultCalendar()
+	start := time.Date(2026, 9, 14, 22, 0, 0, 0, time.Local)
+	end := start.Add(4 * time.Hour)
+	session := &tracking.Session{
+		ID:        "overnight",
+		Project:   "api",
+		StartTime: start,
+		EndTime:   &end,
+		State:     tracking.StateStopped,
+		Intervals: []tracking.Interval{{Start: start, End: &end}},
+	}
+
+	pieces := calendar.SplitByDay(session, time.Time{}, time.Time{})
+	if len(pieces) != 2 {
+		t.Fatalf("SplitByDay returned %d pieces, expected 2", len(pieces))
+	}
+
+	days := groupSessions(pieces, "day", calendar)
+	if len(days) != 2 || days[0].Duration != 2*time.Hour || days[1].Duration != 2*time.Hour {
+		t.Errorf("day groups = %+v, expected 2h on each day", days)
+	}
+
+	projects := groupSessions(pieces, "project", calendar)
+	if len(projects) != 1 || projects[0].Sessions != 1 || projects[0].Duration != 4*time.Hour {
+		t.Errorf("project groups = %+v, expected one 4h session", projects)
+	}
+	if countSessions(pieces) != 1 {
+		t.Errorf("countSessions = %d, expected 1", countSessions(pieces))
+	}
+}
+
+func TestGroupSessions_Repo(t *testing.T) {
+	start := time.Date(2026, 9, 14, 9, 0, 0, 0, time.Local)
+	var sessions []*tracking.Session
+	for i, project := range []string{"platform/api", "platform/web", "tools"} {
+		sessions = append(sessions, &tracking.Session{
+			ID:        project,
+			Project:   project,
+			StartTime: start.Add(time.Duration(i) * time.Hour),
+			Duration:  time.Hour,
+		})
+	}
+
+	groups := groupSessions(sessions, "repo", tracking.DefaultCalendar())
+	if len(groups) != 2 || groups[0].Key != "platform" || groups[0].Sessions != 2 || groups[1].Key != "tools" {
+		t.Errorf("repo groups = %+v, expected platform (2 sessions) and tools", groups)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +30,12 @@ func runResume(cmd *cobra.Command, args []string) error {
 	fmt.Println("▶️ Resuming work timer...")
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	// A daemon that stopped mid-session is started again
+	if err := ensureDaemon(loadConfigQuietly()); err != nil {
+		fmt.Printf("⚠ Could not start the rune daemon: %v\n", err)
+	}
+
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
@@ -2,10 +2,14 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ferg-cod3s/rune/internal/colors"
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/rituals"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -49,11 +53,54 @@ var ritualRunCmd = &cobra.Command{
 	RunE:  runRitualRun,
 }
 
+var ritualPsCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List background ritual commands",
+	Long: `List the background commands started by rituals, with their process ID,
+project, start time and log file.
+
+Stop rituals terminate the background commands their project's start
+rituals left running (SIGTERM, then SIGKILL after rituals.stop_grace),
+except those with 'keep_running: true'.`,
+	Args: cobra.NoArgs,
+	RunE: runRitualPs,
+}
+
+var ritualHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent ritual runs",
+	Long: `List recent start and stop ritual runs with their result.
+
+Every run's command output is kept in ~/.rune/runs; see it with
+'rune ritual logs <run-id>'. rituals.history.keep and max_age limit how
+many runs are kept (50 runs and 30 days by default).`,
+	Args: cobra.NoArgs,
+	RunE: runRitualHistory,
+}
+
+var ritualLogsCmd = &cobra.Command{
+	Use:   "logs <run-id|last> [command]",
+	Short: "Show the output of a ritual run",
+	Long: `Show what a ritual run did: each command's status, exit code and duration.
+
+Name a command (or give its number) to print its stdout and stderr; for a
+background command, its log file.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRitualLogs,
+}
+
+var ritualHistoryLimit int
+
 func init() {
 	rootCmd.AddCommand(ritualCmd)
 	ritualCmd.AddCommand(ritualListCmd)
 	ritualCmd.AddCommand(ritualTestCmd)
 	ritualCmd.AddCommand(ritualRunCmd)
+	ritualCmd.AddCommand(ritualPsCmd)
+	ritualCmd.AddCommand(ritualHistoryCmd)
+	ritualCmd.AddCommand(ritualLogsCmd)
+
+	ritualHistoryCmd.Flags().IntVarP(&ritualHistoryLimit, "limit", "n", 20, "Number of runs to show (0 for all)")
 }
 
 func runRitualList(cmd *cobra.Command, args []string) error {
@@ -71,7 +118,7 @@ func runRitualList(cmd *cobra.Command, args []string) error {
 	if len(cfg.Rituals.Start.Global) > 0 {
 		fmt.Println("  Global:")
 		for _, cmd := range cfg.Rituals.Start.Global {
-			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.CommandLine())
 		}
 	}
 
@@ -80,7 +127,7 @@ func runRitualList(cmd *cobra.Command, args []string) error {
 		for project, commands := range cfg.Rituals.Start.PerProject {
 			fmt.Printf("    %s:\n", project)
 			for _, cmd := range commands {
-				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.Command)
+				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.CommandLine())
 			}
 		}
 	}
@@ -92,7 +139,7 @@ func runRitualList(cmd *cobra.Command, args []string) error {
 	if len(cfg.Rituals.Stop.Global) > 0 {
 		fmt.Println("  Global:")
 		for _, cmd := range cfg.Rituals.Stop.Global {
-			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.CommandLine())
 		}
 	}
 
@@ -101,7 +148,7 @@ func runRitualList(cmd *cobra.Command, args []string) error {
 		for project, commands := range cfg.Rituals.Stop.PerProject {
 			fmt.Printf("    %s:\n", project)
 			for _, cmd := range commands {
-				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.Command)
+				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.CommandLine())
 			}
 		}
 	}
@@ -122,12 +169,16 @@ func runRitualTest(cmd *cobra.Command, args []string) error {
 		project = args[1]
 	} else {
 		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
+		project = detectProject(cfg).Project
 	}
 
 	engine := rituals.NewEngine(cfg)
-	return engine.TestRitual(ritualType, project)
+	if err := engine.TestRitual(ritualType, project); err != nil {
+		return err
+	}
+
+	printConfigWarnings(cfg)
+	return nil
 }
 
 func runRitualRun(cmd *cobra.Command, args []string) error {
@@ -143,8 +194,7 @@ func runRitualRun(cmd *cobra.Command, args []string) error {
 		project = args[1]
 	} else {
 		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
+		project = detectProject(cfg).Project
 	}
 
 	engine := rituals.NewEngine(cfg)
@@ -158,3 +208,223 @@ func runRitualRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unknown ritual type: %s (use 'start' or 'stop')", ritualType)
 	}
 }
+
+func runRitualPs(cmd *cobra.Command, args []string) error {
+	store, err := rituals.NewBackgroundStore()
+	if err != nil {
+		return err
+	}
+
+	processes, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(processes) == 0 {
+		fmt.Println(colors.Muted("No background ritual commands"))
+		return nil
+	}
+
+	now := time.Now()
+	for _, p := range processes {
+		status := colors.StatusRunning("running")
+		if !p.Running() {
+			status = colors.StatusStopped("exited")
+		}
+		project := p.Project
+		if project == "" {
+			project = "-"
+		}
+		if p.KeepRunning {
+			status += colors.Muted(" (keep running)")
+		}
+
+		fmt.Printf("%-7d  %s  %-15s  %-20s  %s  %s\n",
+			p.PID,
+			colors.Time(p.StartedAt.Format("15:04")),
+			colors.Project(project),
+			p.Name,
+			colors.Duration(formatDuration(now.Sub(p.StartedAt))),
+			status)
+		fmt.Printf("         %s\n", colors.Muted(p.LogFile))
+	}
+
+	return nil
+}
+
+func runRitualHistory(cmd *cobra.Command, args []string) error {
+	store, err := rituals.NewRunStore()
+	if err != nil {
+		return err
+	}
+
+	runs, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		fmt.Println(colors.Muted("No ritual runs recorded"))
+		return nil
+	}
+
+	for i, run := range runs {
+		if ritualHistoryLimit > 0 && i >= ritualHistoryLimit {
+			break
+		}
+		project := run.Project
+		if project == "" {
+			project = "-"
+		}
+
+		fmt.Printf("%s  %s  %-5s  %-15s  %-8s  %s  %s\n",
+			colors.Muted(run.ID),
+			colors.Time(run.StartedAt.Format("2006-01-02 15:04")),
+			run.Type,
+			colors.Project(project),
+			colors.Duration(formatStepDuration(run.Duration())),
+			formatRunStatus(run.Status),
+			colors.Muted(fmt.Sprintf("%d commands", len(run.Commands))))
+	}
+
+	return nil
+}
+
+func runRitualLogs(cmd *cobra.Command, args []string) error {
+	store, err := rituals.NewRunStore()
+	if err != nil {
+		return err
+	}
+
+	run, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 2 {
+		record, err := findRunCommand(run, args[1])
+		if err != nil {
+			return err
+		}
+		return printCommandOutput(store, run, record)
+	}
+
+	fmt.Printf("Run:      %s\n", run.ID)
+	fmt.Printf("Ritual:   %s (%s)\n", run.Type, colors.Project(run.Project))
+	fmt.Printf("Started:  %s\n", colors.Time(run.StartedAt.Format("2006-01-02 15:04:05")))
+	fmt.Printf("Duration: %s\n", colors.Duration(formatStepDuration(run.Duration())))
+	fmt.Printf("Status:   %s\n", formatRunStatus(run.Status))
+	if run.Error != "" {
+		fmt.Printf("Error:    %s\n", colors.Error(run.Error))
+	}
+	fmt.Println()
+
+	for i, record := range run.Commands {
+		detail := colors.Duration(formatStepDuration(record.Duration))
+		switch record.Status {
+		case rituals.StatusSkipped:
+			detail = colors.Muted("skipped: " + record.Reason)
+		case rituals.StatusFailed:
+			detail += "  " + colors.Error(fmt.Sprintf("exit %d", record.ExitCode))
+		case rituals.StatusStarted:
+			detail = colors.Muted("background")
+		}
+		fmt.Printf("  %2d. %s %s  %s\n", i+1, commandStatusIcon(record.Status), record.Name, detail)
+	}
+
+	if len(run.Commands) > 0 {
+		fmt.Println()
+		fmt.Printf("💡 Show a command's output with 'rune ritual logs %s <number>'\n", run.ID)
+	}
+	return nil
+}
+
+// findRunCommand finds a command of a run by number or name
+func findRunCommand(run *rituals.Run, name string) (rituals.CommandRecord, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 1 || n > len(run.Commands) {
+			return rituals.CommandRecord{}, fmt.Errorf("run %s has commands 1 to %d", run.ID, len(run.Commands))
+		}
+		return run.Commands[n-1], nil
+	}
+
+	for _, record := range run.Commands {
+		if strings.EqualFold(record.Name, name) {
+			return record, nil
+		}
+	}
+	return rituals.CommandRecord{}, fmt.Errorf("no command named '%s' in run %s", name, run.ID)
+}
+
+// printCommandOutput prints what a command of a run wrote
+func printCommandOutput(store *rituals.RunStore, run *rituals.Run, record rituals.CommandRecord) error {
+	fmt.Printf("%s %s: %s\n", commandStatusIcon(record.Status), record.Name, colors.Muted(record.Command))
+	if record.Reason != "" {
+		fmt.Printf("   %s\n", colors.Muted(record.Reason))
+	}
+
+	outputs := []struct{ label, file string }{
+		{"stdout", record.Stdout},
+		{"stderr", record.Stderr},
+		{"log", record.Log},
+	}
+	for _, output := range outputs {
+		if output.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(store.Path(run.ID, output.file))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", output.label, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		fmt.Println(colors.Subheader("── " + output.label + " ──"))
+		fmt.Print(string(data))
+		if !strings.HasSuffix(string(data), "\n") {
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// formatRunStatus colors the status of a run
+func formatRunStatus(status string) string {
+	switch status {
+	case rituals.StatusSucceeded:
+		return colors.Success("✓ " + status)
+	case rituals.StatusFailed:
+		return colors.Error("❌ " + status)
+	default:
+		return colors.Warning(status)
+	}
+}
+
+// commandStatusIcon is the icon ritual output uses for a command's status
+func commandStatusIcon(status string) string {
+	switch status {
+	case rituals.StatusSucceeded:
+		return "✓"
+	case rituals.StatusFailed:
+		return "❌"
+	case rituals.StatusSkipped:
+		return "⏭"
+	case rituals.StatusStarted:
+		return "⚡"
+	default:
+		return "…"
+	}
+}
+
+// formatStepDuration formats the duration of a ritual or one of its
+// commands, which are usually seconds rather than hours
+func formatStepDuration(d time.Duration) string {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
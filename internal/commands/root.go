@@ -27,6 +27,10 @@ with existing developer workflows.
 
 Cast your daily runes and master your workflow.`,
 	Version: version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Ask about idle time the monitor found before doing anything else
+		checkPendingIdle(cmd)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Handle --log flag
 		if viper.GetBool("log") {
@@ -0,0 +1,468 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/colors"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "List, edit and fix recorded sessions",
+	Long: `Inspect and correct the sessions stored in Rune's database.
+
+Use these commands to fix a session after the fact, for example when you
+forgot to run 'rune stop', or to record work that wasn't tracked live.
+
+Times are given in your local timezone as "YYYY-MM-DD HH:MM", "HH:MM"
+(today) or RFC 3339.`,
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recent sessions",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionList,
+}
+
+var sessionShowCmd = &cobra.Command{
+	Use:   "show <session-id>",
+	Short: "Show the details of a session",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionShow,
+}
+
+var sessionEditCmd = &cobra.Command{
+	Use:   "edit <session-id>",
+	Short: "Edit a session",
+	Long: `Edit the start, end, project, tags or note of a session.
+
+Without any flags the session is opened in $EDITOR as JSON.
+
+Examples:
+  rune session edit session_1700000000 --end "2024-01-15 18:00"
+  rune session edit session_1700000000 --project api --tag review
+  rune session edit session_1700000000`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionEdit,
+}
+
+var sessionDeleteCmd = &cobra.Command{
+	Use:   "delete <session-id>",
+	Short: "Delete a session",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSessionDelete,
+}
+
+var sessionAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Manually add a past session",
+	Long: `Record a session that wasn't tracked live.
+
+Example:
+  rune session add --project api --start "2024-01-15 09:00" --end "2024-01-15 12:30" --tag review`,
+	Args: cobra.NoArgs,
+	RunE: runSessionAdd,
+}
+
+var (
+	sessionLimit   int
+	sessionStart   string
+	sessionEnd     string
+	sessionProject string
+	sessionTags    []string
+	sessionUntags  []string
+	sessionNote    string
+	sessionYes     bool
+)
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionShowCmd)
+	sessionCmd.AddCommand(sessionEditCmd)
+	sessionCmd.AddCommand(sessionDeleteCmd)
+	sessionCmd.AddCommand(sessionAddCmd)
+
+	sessionListCmd.Flags().IntVarP(&sessionLimit, "limit", "n", 20, "Number of sessions to show (0 for all)")
+	sessionListCmd.Flags().StringVar(&sessionProject, "project", "", "Filter by project name")
+
+	for _, cmd := range []*cobra.Command{sessionEditCmd, sessionAddCmd} {
+		cmd.Flags().StringVar(&sessionStart, "start", "", "Session start time")
+		cmd.Flags().StringVar(&sessionEnd, "end", "", "Session end time")
+		cmd.Flags().StringVar(&sessionProject, "project", "", "Project name")
+		cmd.Flags().StringSliceVarP(&sessionTags, "tag", "t", nil, "Add a tag (repeatable or comma-separated)")
+		cmd.Flags().StringVar(&sessionNote, "note", "", "Session note")
+	}
+	sessionEditCmd.Flags().StringSliceVar(&sessionUntags, "untag", nil, "Remove a tag (repeatable or comma-separated)")
+
+	_ = sessionAddCmd.MarkFlagRequired("project")
+	_ = sessionAddCmd.MarkFlagRequired("start")
+	_ = sessionAddCmd.MarkFlagRequired("end")
+
+	sessionDeleteCmd.Flags().BoolVarP(&sessionYes, "yes", "y", false, "Delete without asking for confirmation")
+
+	// Wrap commands that modify sessions with telemetry
+	telemetry.WrapCommand(sessionEditCmd, runSessionEdit)
+	telemetry.WrapCommand(sessionDeleteCmd, runSessionDelete)
+	telemetry.WrapCommand(sessionAddCmd, runSessionAdd)
+}
+
+func runSessionList(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	sessions, err := tracker.GetSessionHistory(0)
+	if err != nil {
+		return fmt.Errorf("failed to get session history: %w", err)
+	}
+
+	var shown int
+	for _, session := range sessions {
+		if sessionProject != "" && session.Project != sessionProject {
+			continue
+		}
+		if sessionLimit > 0 && shown >= sessionLimit {
+			break
+		}
+		printSessionLine(session)
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Println(colors.Muted("No sessions recorded"))
+	}
+
+	return nil
+}
+
+func runSessionShow(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	session, err := tracker.GetSession(args[0])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	fmt.Printf("ID:           %s\n", session.ID)
+	fmt.Printf("Project:      %s\n", colors.Project(session.Project))
+	fmt.Printf("State:        %s\n", session.State)
+	fmt.Printf("Start:        %s\n", colors.Time(session.StartTime.Format("2006-01-02 15:04:05")))
+	if session.EndTime != nil {
+		fmt.Printf("End:          %s\n", colors.Time(session.EndTime.Format("2006-01-02 15:04:05")))
+	}
+	fmt.Printf("Worked:       %s\n", colors.Duration(formatDuration(session.WorkedDuration(now))))
+	fmt.Printf("Breaks:       %s\n", formatBreaks(session.Breaks(), session.BreakDuration(now)))
+	if len(session.Tags) > 0 {
+		fmt.Printf("Tags:         %s\n", colors.Accent(strings.Join(session.Tags, ", ")))
+	}
+	if session.Note != "" {
+		fmt.Printf("Note:         %s\n", session.Note)
+	}
+
+	if len(session.Intervals) > 0 {
+		fmt.Printf("\n%s\n", colors.Subheader("Intervals:"))
+		for _, interval := range session.Intervals {
+			end := "now"
+			if interval.End != nil {
+				end = interval.End.Format("15:04")
+			}
+			fmt.Printf("  %s - %s\n", colors.Time(interval.Start.Format("15:04")), colors.Time(end))
+		}
+	}
+
+	return nil
+}
+
+func runSessionEdit(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	session, err := tracker.GetSession(args[0])
+	if err != nil {
+		return err
+	}
+
+	editFlagSet := false
+	for _, name := range []string{"start", "end", "project", "tag", "untag", "note"} {
+		editFlagSet = editFlagSet || cmd.Flags().Changed(name)
+	}
+
+	if !editFlagSet {
+		if err := editSessionInEditor(session); err != nil {
+			return err
+		}
+	} else if err := applySessionFlags(cmd, session); err != nil {
+		return err
+	}
+
+	if err := tracker.UpdateSession(session); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	fmt.Printf("✓ Session %s updated\n", session.ID)
+	printSessionLine(session)
+	return warnOverlaps(tracker, session)
+}
+
+func runSessionDelete(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	session, err := tracker.GetSession(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !sessionYes {
+		printSessionLine(session)
+		if !confirm("Delete this session?") {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	if err := tracker.DeleteSession(session.ID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	fmt.Printf("✓ Session %s deleted\n", session.ID)
+	return nil
+}
+
+func runSessionAdd(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	start, err := parseDateTime(sessionStart, now)
+	if err != nil {
+		return err
+	}
+	end, err := parseDateTime(sessionEnd, now)
+	if err != nil {
+		return err
+	}
+
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	defer tracker.Close()
+
+	session, err := tracker.AddSession(sessionProject, start, end, sessionTags, strings.TrimSpace(sessionNote))
+	if err != nil {
+		return fmt.Errorf("failed to add session: %w", err)
+	}
+
+	fmt.Printf("✓ Session %s added\n", session.ID)
+	printSessionLine(session)
+	return warnOverlaps(tracker, session)
+}
+
+// applySessionFlags applies the edit flags that were set to a session
+func applySessionFlags(cmd *cobra.Command, session *tracking.Session) error {
+	flags := cmd.Flags()
+	now := time.Now()
+
+	start := session.StartTime
+	end := session.EndTime
+	if flags.Changed("start") {
+		t, err := parseDateTime(sessionStart, now)
+		if err != nil {
+			return err
+		}
+		start = t
+	}
+	if flags.Changed("end") {
+		if session.State != tracking.StateStopped {
+			return fmt.Errorf("cannot set the end of an active session; use 'rune stop' instead")
+		}
+		t, err := parseDateTime(sessionEnd, now)
+		if err != nil {
+			return err
+		}
+		end = &t
+	}
+	if flags.Changed("start") || flags.Changed("end") {
+		session.SetBounds(start, end)
+	}
+
+	if flags.Changed("project") {
+		session.Project = sessionProject
+	}
+
+	if flags.Changed("untag") {
+		removed := make(map[string]bool)
+		for _, tag := range tracking.NormalizeTags(sessionUntags) {
+			removed[tag] = true
+		}
+		var tags []string
+		for _, tag := range session.Tags {
+			if !removed[tag] {
+				tags = append(tags, tag)
+			}
+		}
+		session.Tags = tags
+	}
+	if flags.Changed("tag") {
+		session.Tags = append(session.Tags, sessionTags...)
+	}
+
+	if flags.Changed("note") {
+		session.Note = strings.TrimSpace(sessionNote)
+	}
+
+	return nil
+}
+
+// sessionEditForm is the editable view of a session shown in $EDITOR
+type sessionEditForm struct {
+	Project string     `json:"project"`
+	Start   time.Time  `json:"start"`
+	End     *time.Time `json:"end,omitempty"`
+	Tags    []string   `json:"tags"`
+	Note    string     `json:"note"`
+}
+
+// editSessionInEditor opens the session in $EDITOR and applies the changes
+func editSessionInEditor(session *tracking.Session) error {
+	form := sessionEditForm{
+		Project: session.Project,
+		Start:   session.StartTime,
+		End:     session.EndTime,
+		Tags:    session.Tags,
+		Note:    session.Note,
+	}
+	if form.Tags == nil {
+		form.Tags = []string{}
+	}
+
+	data, err := json.MarshalIndent(form, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "rune-session-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	file.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi" // fallback to vi
+	}
+	editorArgs := strings.Fields(editor)
+
+	editCmd := exec.Command(editorArgs[0], append(editorArgs[1:], file.Name())...)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read edited session: %w", err)
+	}
+
+	var updated sessionEditForm
+	decoder := json.NewDecoder(strings.NewReader(string(edited)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&updated); err != nil {
+		return fmt.Errorf("invalid session JSON: %w", err)
+	}
+
+	if updated.End != nil && session.State != tracking.StateStopped {
+		return fmt.Errorf("cannot set the end of an active session; use 'rune stop' instead")
+	}
+	if session.State == tracking.StateStopped && updated.End == nil {
+		return fmt.Errorf("a stopped session needs an end time")
+	}
+
+	session.Project = strings.TrimSpace(updated.Project)
+	session.Tags = updated.Tags
+	session.Note = strings.TrimSpace(updated.Note)
+	session.SetBounds(updated.Start, updated.End)
+
+	return nil
+}
+
+// warnOverlaps prints a warning for each session overlapping the given one
+func warnOverlaps(tracker timeTracker, session *tracking.Session) error {
+	overlaps, err := tracker.FindOverlaps(session)
+	if err != nil {
+		return fmt.Errorf("failed to check for overlapping sessions: %w", err)
+	}
+
+	for _, other := range overlaps {
+		fmt.Printf("%s Overlaps with session %s\n", colors.Warning("⚠"), other.ID)
+		fmt.Print("  ")
+		printSessionLine(other)
+	}
+
+	return nil
+}
+
+// printSessionLine prints a one-line summary of a session
+func printSessionLine(session *tracking.Session) {
+	end := "running"
+	if session.EndTime != nil {
+		end = session.EndTime.Format("15:04")
+	}
+
+	tags := ""
+	if len(session.Tags) > 0 {
+		tags = "  " + strings.Join(session.Tags, ",")
+	}
+
+	fmt.Printf("%s  %s  %s-%s  %-15s  %s%s\n",
+		colors.Muted(session.ID),
+		session.StartTime.Format("2006-01-02"),
+		colors.Time(session.StartTime.Format("15:04")),
+		colors.Time(end),
+		colors.Project(session.Project),
+		colors.Duration(formatDuration(session.WorkedDuration(time.Now()))),
+		colors.Accent(tags))
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
@@ -2,13 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/dnd"
 	"github.com/ferg-cod3s/rune/internal/notifications"
 	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -28,13 +28,26 @@ Interactive rituals create tmux sessions with multi-pane layouts or launch
 interactive terminals for development work. Use 'rune ritual test start' to
 preview what will be executed without running the commands.
 
-If no project is specified, it will be auto-detected from the current directory.`,
+If no project is specified, it will be auto-detected from the current directory.
+
+Tags and a note can be attached to the session to tell apart different kinds
+of work within one project:
+
+  rune start --tag review --note "PR #42"`,
 	RunE: runStart,
 }
 
+var (
+	startTags []string
+	startNote string
+)
+
 func init() {
 	rootCmd.AddCommand(startCmd)
 
+	startCmd.Flags().StringSliceVarP(&startTags, "tag", "t", nil, "Tag the session (repeatable or comma-separated)")
+	startCmd.Flags().StringVar(&startNote, "note", "", "Attach a note to the session")
+
 	// Wrap command with telemetry
 	telemetry.WrapCommand(startCmd, runStart)
 }
@@ -42,23 +55,15 @@ func init() {
 func runStart(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔮 Casting your start ritual...")
 
-	// Load configuration to get idle threshold
-	var tracker *tracking.Tracker
-	cfg, configErr := config.Load()
-	if configErr != nil {
-		// Use default tracker if config fails to load
-		var err error
-		tracker, err = tracking.NewTracker()
-		if err != nil {
-			return fmt.Errorf("failed to initialize tracker: %w", err)
-		}
-	} else {
-		// Use configured idle threshold
-		var err error
-		tracker, err = tracking.NewTrackerWithIdleThreshold(cfg.Settings.IdleThreshold)
-		if err != nil {
-			return fmt.Errorf("failed to initialize tracker: %w", err)
-		}
+	// Start the daemon so idle detection and reminders outlive this command
+	cfg, _ := config.Load()
+	if err := ensureDaemon(cfg); err != nil {
+		fmt.Printf("⚠ Could not start the rune daemon: %v\n", err)
+	}
+
+	tracker, err := openTracker()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
 	defer tracker.Close()
 
@@ -69,12 +74,11 @@ func runStart(cmd *cobra.Command, args []string) error {
 		project = args[0]
 	} else {
 		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
+		project = detectProject(cfg).Project
 	}
 
 	// Start time tracking
-	session, err := tracker.Start(project)
+	session, err := tracker.StartWithDetails(project, startTags, startNote)
 	if err != nil {
 		telemetry.TrackError(err, "start", map[string]interface{}{
 			"project": project,
@@ -87,6 +91,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	telemetry.Track("session_started", map[string]interface{}{
 		"project":       project,
 		"auto_detected": len(args) == 0,
+		"tag_count":     len(session.Tags),
 	})
 
 	// Load configuration and execute start rituals (reuse cfg if already loaded)
@@ -138,6 +143,9 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("✓ Start ritual complete")
 	fmt.Printf("⏰ Work timer started for project: %s\n", session.Project)
+	if len(session.Tags) > 0 {
+		fmt.Printf("🏷  Tags: %s\n", strings.Join(session.Tags, ", "))
+	}
 
 	return nil
 }
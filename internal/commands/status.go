@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ferg-cod3s/rune/internal/colors"
 	"github.com/ferg-cod3s/rune/internal/config"
@@ -10,6 +12,7 @@ import (
 	"github.com/ferg-cod3s/rune/internal/telemetry"
 	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var statusCmd = &cobra.Command{
@@ -22,7 +25,10 @@ This command shows:
 - Active project (if detected)
 - Session duration
 - Today's total work time
-- Focus mode status`,
+- Focus mode status
+
+With --verbose it also shows which project would be detected in the current
+directory and which detect rule matched.`,
 	RunE: runStatus,
 }
 
@@ -39,11 +45,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
 	defer tracker.Close()
+	tracker.SetCalendar(loadCalendar())
 
 	// Get current session
 	session, err := tracker.GetCurrentSession()
@@ -76,6 +83,21 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Timer:        %s\n", timerStatus)
 		fmt.Printf("Project:      %s\n", colors.Project(session.Project))
 		fmt.Printf("Session:      %s %s\n", colors.Duration(formatDuration(duration)), colors.RelativeTime("started "+formatRelativeTime(session.StartTime)))
+		fmt.Printf("Started:      %s\n", colors.Time(session.StartTime.Format("15:04")))
+		fmt.Printf("Breaks:       %s\n", formatBreaks(session.Breaks(), session.BreakDuration(time.Now())))
+		if len(session.Tags) > 0 {
+			fmt.Printf("Tags:         %s\n", colors.Accent(strings.Join(session.Tags, ", ")))
+		}
+		if session.Note != "" {
+			fmt.Printf("Note:         %s\n", session.Note)
+		}
+	}
+
+	// Explain which project would be detected in this directory
+	if viper.GetBool("verbose") {
+		cfg, _ := config.Load()
+		match := detectProject(cfg)
+		fmt.Printf("Detected:     %s %s\n", colors.Project(match.Project), colors.Muted("("+match.Reason+")"))
 	}
 
 	// Get daily total
@@ -84,6 +106,9 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get daily total: %w", err)
 	}
 	fmt.Printf("Today Total:  %s\n", colors.Duration(formatDuration(dailyTotal)))
+	if cfg := loadConfigQuietly(); cfg != nil && cfg.Settings.EndOfDay.HardStop != "" {
+		fmt.Printf("Hard Stop:    %s\n", colors.Time(cfg.Settings.EndOfDay.HardStop))
+	}
 
 	// Get idle status
 	isIdle, err := tracker.IsIdle()
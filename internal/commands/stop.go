@@ -8,7 +8,6 @@ import (
 	"github.com/ferg-cod3s/rune/internal/notifications"
 	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -37,7 +36,7 @@ func runStop(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔮 Casting your stop ritual...")
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracker: %w", err)
 	}
@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/colors"
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+var tmuxCmd = &cobra.Command{
+	Use:   "tmux",
+	Short: "Manage the tmux sessions rituals create",
+	Long: `List, attach to, save, restore, kill and prune tmux sessions.
+
+Sessions created by rituals are marked as Rune's, with the project they
+belong to, and their state is saved in ~/.rune/sessions so they can be
+restored after tmux exits.`,
+}
+
+var tmuxLsCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List tmux sessions",
+	Long: `List live tmux sessions, marking the ones Rune created and their
+project, and saved sessions that aren't running.`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxLs,
+}
+
+var tmuxAttachCmd = &cobra.Command{
+	Use:   "attach <session>",
+	Short: "Attach to a tmux session",
+	Long: `Attach to a tmux session. A saved session that isn't running is restored
+first. Inside tmux, the client switches to the session instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTmuxAttach,
+}
+
+var tmuxSaveCmd = &cobra.Command{
+	Use:   "save <session>",
+	Short: "Save a tmux session's windows, panes and layouts",
+	Long: `Save a snapshot of a live tmux session: each window's name and layout,
+and each pane's working directory and running command.
+
+Saving again replaces the snapshot but keeps the template the session was
+created from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTmuxSave,
+}
+
+var tmuxRestoreCmd = &cobra.Command{
+	Use:   "restore <session>",
+	Short: "Recreate a saved tmux session",
+	Long: `Recreate a saved tmux session.
+
+A session created from a template that is still in your configuration is
+recreated from the template. Otherwise its saved windows, panes, layouts and
+working directories are rebuilt, and the commands that were running are
+started again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTmuxRestore,
+}
+
+var tmuxKillCmd = &cobra.Command{
+	Use:   "kill <session>",
+	Short: "Kill a tmux session",
+	Long: `Kill a tmux session and forget its saved state. Use --keep to keep the
+saved state, so the session can be restored later.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTmuxKill,
+}
+
+var tmuxPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Kill orphaned Rune sessions",
+	Long: `Kill orphaned tmux sessions created by Rune and forget their saved state.
+
+A session is orphaned when it is marked as Rune's or named rune-*, nobody
+is attached to it and its saved state shows no activity for --older-than.
+Sessions without saved state are never killed. Creating, saving and
+attaching to a session through Rune counts as activity.
+
+The saved state of sessions that aren't running is forgotten only when it
+shows no activity for --older-than as well, so sessions kept with
+'rune tmux kill --keep' can still be restored.`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxPrune,
+}
+
+var (
+	tmuxKillKeep   bool
+	tmuxPruneAfter time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(tmuxCmd)
+	tmuxCmd.AddCommand(tmuxLsCmd)
+	tmuxCmd.AddCommand(tmuxAttachCmd)
+	tmuxCmd.AddCommand(tmuxSaveCmd)
+	tmuxCmd.AddCommand(tmuxRestoreCmd)
+	tmuxCmd.AddCommand(tmuxKillCmd)
+	tmuxCmd.AddCommand(tmuxPruneCmd)
+
+	tmuxKillCmd.Flags().BoolVar(&tmuxKillKeep, "keep", false, "Keep the saved state for 'rune tmux restore'")
+	tmuxPruneCmd.Flags().DurationVar(&tmuxPruneAfter, "older-than", 24*time.Hour, "Prune sessions with no activity for this long")
+}
+
+func runTmuxLs(cmd *cobra.Command, args []string) error {
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := client.Sessions()
+	if err != nil {
+		return err
+	}
+	saved, err := client.ListPersistedSessions()
+	if err != nil {
+		return err
+	}
+
+	running := make(map[string]bool)
+	for _, s := range sessions {
+		running[s.Name] = true
+	}
+
+	if len(sessions) == 0 && len(saved) == 0 {
+		fmt.Println(colors.Muted("No tmux sessions"))
+		return nil
+	}
+
+	for _, s := range sessions {
+		project := s.Project
+		if project == "" {
+			project = "-"
+		}
+		status := colors.StatusStopped("detached")
+		if s.Attached {
+			status = colors.StatusRunning("attached")
+		}
+		if s.Managed {
+			status += colors.Muted(" (rune)")
+		}
+		fmt.Printf("%-25s  %-15s  %-10s  %s\n", s.Name, colors.Project(project), formatCount(s.Windows, "window"), status)
+	}
+
+	for _, name := range saved {
+		if running[name] {
+			continue
+		}
+		state, err := client.LoadSessionState(name)
+		if err != nil {
+			continue
+		}
+		project := state.Project
+		if project == "" {
+			project = "-"
+		}
+		fmt.Printf("%-25s  %-15s  %-10s  %s\n", name, colors.Project(project), formatCount(len(state.Windows), "window"),
+			colors.Muted("saved, last active "+formatDuration(time.Since(state.LastActivity))+" ago"))
+	}
+
+	return nil
+}
+
+func runTmuxAttach(cmd *cobra.Command, args []string) error {
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	if !client.SessionExists(name) {
+		if _, err := client.LoadSessionState(name); err != nil {
+			return fmt.Errorf("session '%s' does not exist", name)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := client.RestoreSession(name, cfg.Rituals.Templates); err != nil {
+			return fmt.Errorf("failed to restore session: %w", err)
+		}
+		fmt.Printf("✓ Restored session '%s'\n", name)
+	}
+
+	if tmux.IsInTmuxSession() {
+		return client.SwitchClient(name)
+	}
+	return client.AttachSession(name)
+}
+
+func runTmuxSave(cmd *cobra.Command, args []string) error {
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	state, err := client.SnapshotSession(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	panes := 0
+	for _, window := range state.Windows {
+		panes += len(window.Panes)
+	}
+	fmt.Printf("✓ Saved session '%s' (%s, %s)\n", state.Name, formatCount(len(state.Windows), "window"), formatCount(panes, "pane"))
+	return nil
+}
+
+func runTmuxRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if err := client.RestoreSession(args[0], cfg.Rituals.Templates); err != nil {
+		return fmt.Errorf("failed to restore session: %w", err)
+	}
+
+	fmt.Printf("✓ Restored session '%s'\n", args[0])
+	fmt.Printf("💡 Attach with 'rune tmux attach %s'\n", args[0])
+	return nil
+}
+
+func runTmuxKill(cmd *cobra.Command, args []string) error {
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	if err := client.KillSession(name); err != nil {
+		return err
+	}
+	if !tmuxKillKeep {
+		if err := client.DeleteSessionState(name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✓ Killed session '%s'\n", name)
+	return nil
+}
+
+func runTmuxPrune(cmd *cobra.Command, args []string) error {
+	client, err := tmux.NewClient()
+	if err != nil {
+		return err
+	}
+
+	killed, err := client.PruneSessions(tmuxPruneAfter)
+	for _, name := range killed {
+		fmt.Printf("  🛑 %s\n", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune sessions: %w", err)
+	}
+
+	if len(killed) == 0 {
+		fmt.Println(colors.Muted("No orphaned sessions"))
+		return nil
+	}
+	fmt.Printf("✓ Pruned %d session(s)\n", len(killed))
+	return nil
+}
+
+// formatCount formats a count of windows or panes
+func formatCount(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/daemon"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// timeTracker is the tracker API used by commands. *tracking.Tracker opens
+// sessions.db directly; *daemon.Client forwards each call to `rune daemon`,
+// which keeps the database open while it runs.
+type timeTracker interface {
+	Close() error
+	SetCalendar(calendar tracking.Calendar)
+	Calendar() tracking.Calendar
+
+	StartWithDetails(project string, tags []string, note string) (*tracking.Session, error)
+	Stop() (*tracking.Session, error)
+	Pause() (*tracking.Session, error)
+	Resume() (*tracking.Session, error)
+	Annotate(add, remove []string, note *string) (*tracking.Session, error)
+
+	GetCurrentSession() (*tracking.Session, error)
+	GetSessionDuration() (time.Duration, error)
+	GetDailyTotal() (time.Duration, error)
+	GetWeeklyTotal() (time.Duration, error)
+	GetSessionHistory(limit int) ([]*tracking.Session, error)
+	GetProjectStats() (map[string]time.Duration, error)
+	QuerySessions(query tracking.SessionQuery) ([]*tracking.Session, error)
+
+	GetSession(id string) (*tracking.Session, error)
+	UpdateSession(session *tracking.Session) error
+	DeleteSession(id string) error
+	AddSession(project string, start, end time.Time, tags []string, note string) (*tracking.Session, error)
+	FindOverlaps(session *tracking.Session) ([]*tracking.Session, error)
+	SaveImportedSession(session *tracking.Session) error
+
+	IsIdle() (bool, error)
+	GetIdleTime() (time.Duration, error)
+	PendingIdle() (*tracking.IdleStretch, error)
+	ResolveIdle(resolution tracking.IdleResolution, project string) (*tracking.Session, error)
+}
+
+// openTracker connects to the running daemon, or opens the database
+// directly when no daemon is running
+func openTracker() (timeTracker, error) {
+	if client, err := daemon.Dial(); err == nil {
+		return client, nil
+	}
+
+	tracker, err := tracking.NewTracker()
+	if err != nil {
+		return nil, err
+	}
+	applyTrackerSettings(tracker, loadConfigQuietly())
+	return tracker, nil
+}
+
+// applyTrackerSettings applies the idle settings from the config to a
+// tracker that opened the database itself
+func applyTrackerSettings(tracker *tracking.Tracker, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	tracker.SetIdleThreshold(cfg.Settings.IdleThreshold)
+	tracker.SetIdleSources(idleSources(cfg))
+	tracker.SetIdleEndHandler(idleReclaimNotifier(cfg))
+}
+
+// loadConfigQuietly loads the config, returning nil if there is none
+func loadConfigQuietly() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// ensureDaemon starts the daemon in the background unless one is running or
+// settings.daemon is "manual"
+func ensureDaemon(cfg *config.Config) error {
+	if cfg != nil && cfg.Settings.DaemonMode() == config.DaemonManual {
+		return nil
+	}
+
+	if client, err := daemon.Dial(); err == nil {
+		client.Close()
+		return nil
+	}
+
+	paths, err := daemon.DefaultPaths()
+	if err != nil {
+		return err
+	}
+	return daemon.Spawn(paths)
+}
@@ -2,7 +2,14 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"golang.org/x/term"
 )
 
 // formatDuration formats a duration as "Xh Ym"
@@ -12,6 +19,236 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh %dm", hours, minutes)
 }
 
+// dateTimeLayouts are the absolute formats accepted by parseDateTime
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseDateTime parses a user-supplied time in the local timezone. Besides full
+// dates it accepts a bare time of day ("15:04"), which is taken to be on the
+// same day as now.
+func parseDateTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DD HH:MM, HH:MM or RFC 3339)", value)
+}
+
+// newProjectDetector creates a project detector using the detect rules in
+// cfg. Invalid patterns are skipped; config validation reports them.
+func newProjectDetector(cfg *config.Config) *tracking.ProjectDetector {
+	if cfg == nil {
+		return tracking.NewProjectDetector()
+	}
+
+	var rules []tracking.ProjectRule
+	for _, project := range cfg.Projects {
+		for _, pattern := range project.Detect {
+			rule, err := tracking.ParseProjectRule(project.Name, pattern)
+			if err != nil {
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return tracking.NewProjectDetectorWithRules(rules)
+}
+
+// detectProject detects the current project. Configured project names are
+// used as-is; names taken from project files are sanitized, keeping the "/"
+// between a repository and its package.
+func detectProject(cfg *config.Config) tracking.ProjectMatch {
+	detector := newProjectDetector(cfg)
+	match := detector.Detect()
+	if match.Rule != "" {
+		return match
+	}
+
+	if match.Repo != "" {
+		match.Repo = detector.SanitizeProjectName(match.Repo)
+	}
+	if match.Package != "" {
+		match.Package = detector.SanitizeProjectName(match.Package)
+		match.Project = match.Repo + "/" + match.Package
+	} else {
+		match.Project = detector.SanitizeProjectName(match.Project)
+	}
+	return match
+}
+
+// weekdayNames maps lowercase weekday names to time.Weekday
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// loadCalendar returns the calendar configured by settings.timezone and
+// settings.week_start. Without a usable config it falls back to the system
+// timezone and weeks starting on Sunday.
+func loadCalendar() tracking.Calendar {
+	calendar := tracking.DefaultCalendar()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return calendar
+	}
+	if loc, err := cfg.Settings.Location(); err == nil {
+		calendar.Location = loc
+	}
+	if weekday, err := cfg.Settings.FirstWeekday(); err == nil {
+		calendar.WeekStart = weekday
+	}
+
+	return calendar
+}
+
+// idleSources builds the idle sources listed in settings.idle_sources, or
+// returns nil to let the tracker pick them for the current session type
+func idleSources(cfg *config.Config) []tracking.IdleSource {
+	if cfg == nil {
+		return nil
+	}
+
+	var sources []tracking.IdleSource
+	for _, name := range cfg.Settings.IdleSources {
+		source, err := tracking.NewIdleSource(name)
+		if err != nil {
+			continue
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal. A mode
+// check isn't enough: /dev/null is a character device too.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// stdoutIsTerminal reports whether stdout is an interactive terminal
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// parseDateRange parses a date expression into the period it covers, as a
+// half-open range [start, end) in the calendar's timezone. It accepts:
+//
+//   - today, yesterday
+//   - weekday names: "monday" is the most recent Monday (possibly today),
+//     "last monday" the most recent one before today
+//   - this/last week, this/last month, this/last year
+//   - "N days ago", "N weeks ago", "N months ago"
+//   - 2006 (a year), 2006-01 (a month) and 2006-01-02 (a day)
+//   - any time accepted by parseDateTime, which covers a single instant
+func parseDateRange(value string, now time.Time, calendar tracking.Calendar) (time.Time, time.Time, error) {
+	expr := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	today := calendar.StartOfDay(now)
+	loc := today.Location()
+	now = now.In(loc)
+
+	switch expr {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "this week":
+		start := calendar.StartOfWeek(now)
+		return start, start.AddDate(0, 0, 7), nil
+	case "last week":
+		start := calendar.StartOfWeek(now).AddDate(0, 0, -7)
+		return start, start.AddDate(0, 0, 7), nil
+	case "this month":
+		start := calendar.StartOfMonth(now)
+		return start, start.AddDate(0, 1, 0), nil
+	case "last month":
+		start := calendar.StartOfMonth(now).AddDate(0, -1, 0)
+		return start, start.AddDate(0, 1, 0), nil
+	case "this year":
+		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0), nil
+	case "last year":
+		start := time.Date(now.Year()-1, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+
+	// Weekdays: "monday", "last monday"
+	name := strings.TrimPrefix(expr, "last ")
+	if weekday, ok := weekdayNames[name]; ok {
+		daysBack := (int(today.Weekday()) - int(weekday) + 7) % 7
+		if daysBack == 0 && name != expr {
+			daysBack = 7
+		}
+		start := today.AddDate(0, 0, -daysBack)
+		return start, start.AddDate(0, 0, 1), nil
+	}
+
+	// Relative: "3 days ago", "1 week ago", "2 months ago"
+	if fields := strings.Fields(expr); len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		if err == nil && n >= 0 {
+			switch strings.TrimSuffix(fields[1], "s") {
+			case "day":
+				start := today.AddDate(0, 0, -n)
+				return start, start.AddDate(0, 0, 1), nil
+			case "week":
+				start := calendar.StartOfWeek(now).AddDate(0, 0, -7*n)
+				return start, start.AddDate(0, 0, 7), nil
+			case "month":
+				start := calendar.StartOfMonth(now).AddDate(0, -n, 0)
+				return start, start.AddDate(0, 1, 0), nil
+			}
+		}
+	}
+
+	// Calendar periods: a year, a month or a day
+	if t, err := time.ParseInLocation("2006", expr, loc); err == nil {
+		return t, t.AddDate(1, 0, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", expr, loc); err == nil {
+		return t, t.AddDate(0, 1, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", expr, loc); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := parseDateTime(value, now); err == nil {
+		return t, t, nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q (try \"today\", \"last monday\", \"last week\", \"3 days ago\", \"2026-09\" or \"2026-09-15\")", value)
+}
+
+// formatBreaks formats a break count and total break time (e.g., "2 (0h 15m)")
+func formatBreaks(count int, total time.Duration) string {
+	if count == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d (%s)", count, formatDuration(total))
+}
+
 // formatRelativeTime formats a time relative to now (e.g., "2h 30m ago", "just now", "yesterday")
 func formatRelativeTime(t time.Time) string {
 	now := time.Now()
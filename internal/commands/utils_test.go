@@ -3,6 +3,8 @@ package commands
 import (
 	"testing"
 	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -127,3 +129,87 @@ func TestFormatRelativeTime(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDateTime(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"2024-01-10 09:15", time.Date(2024, 1, 10, 9, 15, 0, 0, time.Local)},
+		{"2024-01-10T09:15", time.Date(2024, 1, 10, 9, 15, 0, 0, time.Local)},
+		{"2024-01-10", time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)},
+		{"09:15", time.Date(2024, 1, 15, 9, 15, 0, 0, time.Local)},
+		{"2024-01-10T09:15:00Z", time.Date(2024, 1, 10, 9, 15, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		result, err := parseDateTime(test.input, now)
+		if err != nil {
+			t.Errorf("parseDateTime(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if !result.Equal(test.expected) {
+			t.Errorf("parseDateTime(%q) = %v, expected %v", test.input, result, test.expected)
+		}
+	}
+
+	if _, err := parseDateTime("yesterday-ish", now); err == nil {
+		t.Error("parseDateTime should reject unknown formats")
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 9, 16, 14, 30, 0, 0, time.Local)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		input string
+		start time.Time
+		end   time.Time
+	}{
+		{"today", day(9, 16), day(9, 17)},
+		{"yesterday", day(9, 15), day(9, 16)},
+		{"monday", day(9, 14), day(9, 15)},
+		{"Last Monday", day(9, 14), day(9, 15)},
+		{"wednesday", day(9, 16), day(9, 17)},
+		{"last wednesday", day(9, 9), day(9, 10)},
+		{"this week", day(9, 13), day(9, 20)},
+		{"last week", day(9, 6), day(9, 13)},
+		{"last month", day(8, 1), day(9, 1)},
+		{"3 days ago", day(9, 13), day(9, 14)},
+		{"2 weeks ago", day(8, 30), day(9, 6)},
+		{"2026-09", day(9, 1), day(10, 1)},
+		{"2026-09-01", day(9, 1), day(9, 2)},
+		{"2026", time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-09-01 09:15", time.Date(2026, 9, 1, 9, 15, 0, 0, time.Local), time.Date(2026, 9, 1, 9, 15, 0, 0, time.Local)},
+	}
+
+	for _, test := range tests {
+		start, end, err := parseDateRange(test.input, now, tracking.DefaultCalendar())
+		if err != nil {
+			t.Errorf("parseDateRange(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("parseDateRange(%q) = [%v, %v), expected [%v, %v)", test.input, start, end, test.start, test.end)
+		}
+	}
+
+	// Weeks can start on Monday
+	mondays := tracking.Calendar{Location: time.Local, WeekStart: time.Monday}
+	start, end, err := parseDateRange("last week", now, mondays)
+	if err != nil || !start.Equal(day(9, 7)) || !end.Equal(day(9, 14)) {
+		t.Errorf("parseDateRange(\"last week\") with Monday weeks = [%v, %v), %v", start, end, err)
+	}
+
+	for _, input := range []string{"", "someday", "next friday", "2026-13"} {
+		if _, _, err := parseDateRange(input, now, tracking.DefaultCalendar()); err == nil {
+			t.Errorf("parseDateRange(%q) should return an error", input)
+		}
+	}
+}
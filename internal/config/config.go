@@ -3,9 +3,18 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -25,9 +34,117 @@ type Settings struct {
 	WorkHours     float64              `yaml:"work_hours" mapstructure:"work_hours"`
 	BreakInterval time.Duration        `yaml:"break_interval" mapstructure:"break_interval"`
 	IdleThreshold time.Duration        `yaml:"idle_threshold" mapstructure:"idle_threshold"`
+	Timezone      string               `yaml:"timezone,omitempty" mapstructure:"timezone"`         // IANA name, e.g. "Europe/Berlin"; default: system timezone
+	WeekStart     string               `yaml:"week_start,omitempty" mapstructure:"week_start"`     // monday or sunday; default: sunday
+	IdleSources   []string             `yaml:"idle_sources,omitempty" mapstructure:"idle_sources"` // Linux idle sources to try in order; default: picked from the session type
+	Daemon        string               `yaml:"daemon,omitempty" mapstructure:"daemon"`             // auto (rune start launches the daemon) or manual; default: auto
+	Pomodoro      PomodoroSettings     `yaml:"pomodoro,omitempty" mapstructure:"pomodoro"`
+	EndOfDay      EndOfDaySettings     `yaml:"end_of_day,omitempty" mapstructure:"end_of_day"`
 	Notifications NotificationSettings `yaml:"notifications" mapstructure:"notifications"`
 }
 
+// PomodoroSettings switches break reminders to a Pomodoro-style schedule:
+// work stretches of break_interval, short breaks, and a long break after
+// every few work stretches
+type PomodoroSettings struct {
+	Enabled        bool          `yaml:"enabled" mapstructure:"enabled"`
+	ShortBreak     time.Duration `yaml:"short_break,omitempty" mapstructure:"short_break"`           // default: 5m
+	LongBreak      time.Duration `yaml:"long_break,omitempty" mapstructure:"long_break"`             // default: 15m
+	LongBreakEvery int           `yaml:"long_break_every,omitempty" mapstructure:"long_break_every"` // default: 4
+}
+
+// Pomodoro defaults used when a length is left unset
+const (
+	DefaultShortBreak     = 5 * time.Minute
+	DefaultLongBreak      = 15 * time.Minute
+	DefaultLongBreakEvery = 4
+)
+
+// Breaks returns the Pomodoro settings with defaults filled in
+func (p PomodoroSettings) Breaks() (short, long time.Duration, every int) {
+	short, long, every = p.ShortBreak, p.LongBreak, p.LongBreakEvery
+	if short == 0 {
+		short = DefaultShortBreak
+	}
+	if long == 0 {
+		long = DefaultLongBreak
+	}
+	if every == 0 {
+		every = DefaultLongBreakEvery
+	}
+	return short, long, every
+}
+
+// Location returns the timezone used for day, week and month boundaries
+func (s Settings) Location() (*time.Location, error) {
+	if s.Timezone == "" || strings.EqualFold(s.Timezone, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone %q is not a known IANA timezone: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// FirstWeekday returns the day weeks start on
+func (s Settings) FirstWeekday() (time.Weekday, error) {
+	switch strings.ToLower(s.WeekStart) {
+	case "", "sunday":
+		return time.Sunday, nil
+	case "monday":
+		return time.Monday, nil
+	default:
+		return time.Sunday, fmt.Errorf("week_start must be monday or sunday, got: %q", s.WeekStart)
+	}
+}
+
+// Daemon modes for settings.daemon
+const (
+	DaemonAuto   = "auto"
+	DaemonManual = "manual"
+)
+
+// DaemonMode returns how the background daemon is started
+func (s Settings) DaemonMode() string {
+	if s.Daemon == "" {
+		return DaemonAuto
+	}
+	return strings.ToLower(s.Daemon)
+}
+
+// EndOfDaySettings controls the end-of-day reminders sent as the daily
+// total approaches work_hours, and an optional hard stop
+type EndOfDaySettings struct {
+	Thresholds []int  `yaml:"thresholds,omitempty" mapstructure:"thresholds"` // percent of work_hours to remind at; default: 90, 100
+	HardStop   string `yaml:"hard_stop,omitempty" mapstructure:"hard_stop"`   // local time (HH:MM) to stop the session, run stop rituals and end focus mode
+}
+
+// DefaultEndOfDayThresholds are the reminder thresholds used when none are set
+var DefaultEndOfDayThresholds = []int{90, 100}
+
+// ReminderThresholds returns the thresholds, in percent of work_hours, at
+// which an end-of-day reminder is sent
+func (e EndOfDaySettings) ReminderThresholds() []int {
+	if len(e.Thresholds) == 0 {
+		return DefaultEndOfDayThresholds
+	}
+	return e.Thresholds
+}
+
+// HardStopOn returns the hard stop time on the day of t, in t's location.
+// It returns false when no hard stop is configured.
+func (e EndOfDaySettings) HardStopOn(t time.Time) (time.Time, bool) {
+	if e.HardStop == "" {
+		return time.Time{}, false
+	}
+	clock, err := time.Parse("15:04", e.HardStop)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), 0, 0, t.Location()), true
+}
+
 // NotificationSettings contains notification preferences
 type NotificationSettings struct {
 	Enabled           bool `yaml:"enabled" mapstructure:"enabled"`
@@ -44,11 +161,61 @@ type Project struct {
 	Detect []string `yaml:"detect" mapstructure:"detect"`
 }
 
+// isLegacyDefault reports whether a project is the catch-all entry 'rune
+// init' used to write. Its rules were never evaluated, and now that they
+// are they would match every repository and directory.
+func (p Project) isLegacyDefault() bool {
+	return p.Name == "default" && len(p.Detect) == 2 && p.Detect[0] == "git:.*" && p.Detect[1] == "dir:~/"
+}
+
+// dropLegacyProjects removes the catch-all project older configs have, so
+// projects are still detected from the package, repository or directory
+func (c *Config) dropLegacyProjects() {
+	projects := c.Projects[:0]
+	for _, project := range c.Projects {
+		if !project.isLegacyDefault() {
+			projects = append(projects, project)
+		}
+	}
+	c.Projects = projects
+}
+
 // Rituals contains start and stop ritual configurations
 type Rituals struct {
-	Start     RitualSet               `yaml:"start" mapstructure:"start"`
-	Stop      RitualSet               `yaml:"stop" mapstructure:"stop"`
-	Templates map[string]TmuxTemplate `yaml:"templates,omitempty" mapstructure:"templates"`
+	Shell       string                  `yaml:"shell,omitempty" mapstructure:"shell"`             // shell commands run through, e.g. "bash -c"; "none" splits on spaces; default: sh -c (cmd /C on Windows)
+	PassEnv     []string                `yaml:"pass_env,omitempty" mapstructure:"pass_env"`       // variables to pass through although they look sensitive, e.g. SSH_AUTH_SOCK
+	Concurrency int                     `yaml:"concurrency,omitempty" mapstructure:"concurrency"` // most commands running at once in a list with ids; default: 4
+	StopGrace   time.Duration           `yaml:"stop_grace,omitempty" mapstructure:"stop_grace"`   // how long background commands get to exit before being killed; default: 5s
+	History     HistorySettings         `yaml:"history,omitempty" mapstructure:"history"`         // retention of ritual run logs
+	Attach      string                  `yaml:"attach,omitempty" mapstructure:"attach"`           // how tmux rituals attach to their session: attach, switch-client, detached or attach-last; default: attach
+	Start       RitualSet               `yaml:"start" mapstructure:"start"`
+	Stop        RitualSet               `yaml:"stop" mapstructure:"stop"`
+	Templates   map[string]TmuxTemplate `yaml:"templates,omitempty" mapstructure:"templates"`
+}
+
+// HistorySettings limits how many ritual run logs are kept in ~/.rune/runs
+type HistorySettings struct {
+	Keep   int           `yaml:"keep,omitempty" mapstructure:"keep"`       // most runs kept; default: 50
+	MaxAge time.Duration `yaml:"max_age,omitempty" mapstructure:"max_age"` // runs older than this are removed; default: 720h (30 days)
+}
+
+// Default ritual run log retention
+const (
+	DefaultHistoryKeep   = 50
+	DefaultHistoryMaxAge = 30 * 24 * time.Hour
+)
+
+// Retention returns how many runs to keep and for how long, with defaults
+// for unset values
+func (h HistorySettings) Retention() (keep int, maxAge time.Duration) {
+	keep, maxAge = h.Keep, h.MaxAge
+	if keep <= 0 {
+		keep = DefaultHistoryKeep
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultHistoryMaxAge
+	}
+	return keep, maxAge
 }
 
 // RitualSet contains global and per-project rituals
@@ -59,13 +226,274 @@ type RitualSet struct {
 
 // Command represents a ritual command
 type Command struct {
-	Name         string `yaml:"name" mapstructure:"name"`
-	Command      string `yaml:"command" mapstructure:"command"`
-	Optional     bool   `yaml:"optional" mapstructure:"optional"`
-	Background   bool   `yaml:"background" mapstructure:"background"`
-	Interactive  bool   `yaml:"interactive" mapstructure:"interactive"`
-	TmuxSession  string `yaml:"tmux_session,omitempty" mapstructure:"tmux_session"`
-	TmuxTemplate string `yaml:"tmux_template,omitempty" mapstructure:"tmux_template"`
+	Name         string            `yaml:"name" mapstructure:"name"`
+	ID           string            `yaml:"id,omitempty" mapstructure:"id"`                 // what depends_on refers to; runs the list as a graph
+	DependsOn    []string          `yaml:"depends_on,omitempty" mapstructure:"depends_on"` // ids in the same list that must succeed first
+	Command      string            `yaml:"command" mapstructure:"command"`
+	Undo         string            `yaml:"undo,omitempty" mapstructure:"undo"`         // runs if a later required command fails, to reverse this one
+	Args         []string          `yaml:"args,omitempty" mapstructure:"args"`         // exact argv, run without a shell; instead of command
+	Shell        string            `yaml:"shell,omitempty" mapstructure:"shell"`       // overrides rituals.shell for this command
+	Timeout      time.Duration     `yaml:"timeout,omitempty" mapstructure:"timeout"`   // default: 30s; foreground commands only
+	Dir          string            `yaml:"dir,omitempty" mapstructure:"dir"`           // working directory; supports ~ and {{.Project}}
+	Env          map[string]string `yaml:"env,omitempty" mapstructure:"env"`           // variables added to the filtered environment
+	PassEnv      []string          `yaml:"pass_env,omitempty" mapstructure:"pass_env"` // added to rituals.pass_env for this command
+	When         *When             `yaml:"when,omitempty" mapstructure:"when"`         // conditions for running the command
+	Optional     bool              `yaml:"optional" mapstructure:"optional"`
+	Background   bool              `yaml:"background" mapstructure:"background"`
+	KeepRunning  bool              `yaml:"keep_running,omitempty" mapstructure:"keep_running"` // background command survives stop rituals
+	Interactive  bool              `yaml:"interactive" mapstructure:"interactive"`
+	TmuxSession  string            `yaml:"tmux_session,omitempty" mapstructure:"tmux_session"`
+	TmuxTemplate string            `yaml:"tmux_template,omitempty" mapstructure:"tmux_template"`
+	TmuxWindow   string            `yaml:"tmux_window,omitempty" mapstructure:"tmux_window"` // window of tmux_session to run in; created if missing
+	TmuxPane     int               `yaml:"tmux_pane,omitempty" mapstructure:"tmux_pane"`     // pane of that window, counting from 0
+	WaitFor      string            `yaml:"wait_for,omitempty" mapstructure:"wait_for"`       // regexp the pane's output must match before the ritual goes on
+	Attach       string            `yaml:"attach,omitempty" mapstructure:"attach"`           // overrides rituals.attach for this command
+}
+
+// When holds the conditions under which a ritual command runs. Every
+// condition that is set must hold; a list holds when any entry matches.
+type When struct {
+	Days   []string `yaml:"days,omitempty" mapstructure:"days"`     // mon..sun, weekdays or weekends
+	Time   string   `yaml:"time,omitempty" mapstructure:"time"`     // local time window, e.g. 09:00-12:00; may wrap midnight
+	Host   []string `yaml:"host,omitempty" mapstructure:"host"`     // hostname globs
+	OS     []string `yaml:"os,omitempty" mapstructure:"os"`         // darwin, linux or windows
+	Exists []string `yaml:"exists,omitempty" mapstructure:"exists"` // files or directories, relative to the command's dir
+	Env    []string `yaml:"env,omitempty" mapstructure:"env"`       // NAME (set and non-empty) or NAME=value
+	Branch []string `yaml:"branch,omitempty" mapstructure:"branch"` // git branch globs in the command's dir
+	Check  string   `yaml:"check,omitempty" mapstructure:"check"`   // guard command; runs the command only if it exits 0
+}
+
+// weekdayNames maps the names accepted in when.days to the days they cover
+var weekdayNames = map[string][]time.Weekday{
+	"sun": {time.Sunday}, "sunday": {time.Sunday},
+	"mon": {time.Monday}, "monday": {time.Monday},
+	"tue": {time.Tuesday}, "tuesday": {time.Tuesday},
+	"wed": {time.Wednesday}, "wednesday": {time.Wednesday},
+	"thu": {time.Thursday}, "thursday": {time.Thursday},
+	"fri": {time.Friday}, "friday": {time.Friday},
+	"sat": {time.Saturday}, "saturday": {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// Weekdays returns the days when.days covers
+func (w When) Weekdays() ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range w.Days {
+		covered, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q (use mon..sun, weekdays or weekends)", name)
+		}
+		days = append(days, covered...)
+	}
+	return days, nil
+}
+
+// TimeWindow returns the start and end of when.time as offsets from
+// midnight. The end is before the start for windows that wrap midnight.
+func (w When) TimeWindow() (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(w.Time, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("time window must look like 09:00-17:00, got: %q", w.Time)
+	}
+	if start, err = parseClock(from); err == nil {
+		end, err = parseClock(to)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("time window must look like 09:00-17:00, got: %q", w.Time)
+	}
+	return start, end, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// validate checks the syntax of the conditions
+func (w When) validate() error {
+	if _, err := w.Weekdays(); err != nil {
+		return err
+	}
+	if w.Time != "" {
+		if _, _, err := w.TimeWindow(); err != nil {
+			return err
+		}
+	}
+	for _, name := range w.OS {
+		if name != "darwin" && name != "linux" && name != "windows" {
+			return fmt.Errorf("unknown os %q (use darwin, linux or windows)", name)
+		}
+	}
+	for _, pattern := range append(append([]string{}, w.Host...), w.Branch...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	for _, env := range w.Env {
+		if name, _, _ := strings.Cut(env, "="); name == "" {
+			return fmt.Errorf("invalid env condition %q (use NAME or NAME=value)", env)
+		}
+	}
+	return nil
+}
+
+// DefaultCommandTimeout is how long a foreground ritual command may run
+// when it sets no timeout
+const DefaultCommandTimeout = 30 * time.Second
+
+// DefaultStopGrace is how long stop rituals give background commands to
+// exit after SIGTERM when rituals.stop_grace is unset
+const DefaultStopGrace = 5 * time.Second
+
+// GracePeriod returns how long background commands get to exit
+func (r Rituals) GracePeriod() time.Duration {
+	if r.StopGrace > 0 {
+		return r.StopGrace
+	}
+	return DefaultStopGrace
+}
+
+// DefaultConcurrency is how many commands of a ritual graph run at once
+// when rituals.concurrency is unset
+const DefaultConcurrency = 4
+
+// MaxConcurrency returns how many commands of a ritual graph run at once
+func (r Rituals) MaxConcurrency() int {
+	if r.Concurrency > 0 {
+		return r.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// IsGraph reports whether a command list runs as a dependency graph, which
+// it does once any command has an id. Other lists run in order.
+func IsGraph(commands []Command) bool {
+	for _, cmd := range commands {
+		if cmd.ID != "" || len(cmd.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGraph checks that ids are unique and that depends_on refers to
+// ids in the same list without forming a cycle
+func validateGraph(commands []Command) error {
+	ids := make(map[string]int, len(commands))
+	for i, cmd := range commands {
+		if cmd.ID == "" {
+			continue
+		}
+		if _, exists := ids[cmd.ID]; exists {
+			return fmt.Errorf("duplicate command id '%s'", cmd.ID)
+		}
+		ids[cmd.ID] = i
+	}
+
+	for _, cmd := range commands {
+		for _, dep := range cmd.DependsOn {
+			if _, exists := ids[dep]; !exists {
+				return fmt.Errorf("command '%s' depends on unknown id '%s'", cmd.Name, dep)
+			}
+			if dep == cmd.ID {
+				return fmt.Errorf("command '%s' depends on itself", cmd.Name)
+			}
+		}
+	}
+
+	// Depth-first search; a command reached again while still on the
+	// stack closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(commands))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("dependency cycle through command '%s'", commands[i].Name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range commands[i].DependsOn {
+			if err := visit(ids[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range commands {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NoShell is the shell setting that runs a command string without a shell,
+// split on whitespace
+const NoShell = "none"
+
+// CommandLine returns the command as it would be typed: the command string,
+// or the args with any that contain spaces quoted
+func (c Command) CommandLine() string {
+	if len(c.Args) == 0 {
+		return c.Command
+	}
+	parts := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"") {
+			arg = strconv.Quote(arg)
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
+
+// ShellFor returns the shell a command runs through: its own setting, else
+// rituals.shell. An empty result means the platform default.
+func (r Rituals) ShellFor(cmd Command) string {
+	if cmd.Shell != "" {
+		return cmd.Shell
+	}
+	return r.Shell
+}
+
+// Attach policies: what a tmux ritual does with the session it prepared
+const (
+	AttachNow      = "attach"        // attach right away, or switch to it when already inside tmux
+	AttachSwitch   = "switch-client" // switch to it when inside tmux, otherwise leave it detached
+	AttachDetached = "detached"      // leave it detached
+	AttachLast     = "attach-last"   // attach once all rituals have finished
+)
+
+// attachPolicies are the values rituals.attach and attach accept
+var attachPolicies = []string{AttachNow, AttachSwitch, AttachDetached, AttachLast}
+
+// AttachFor returns the attach policy of a command: its own setting, else
+// rituals.attach, else attach
+func (r Rituals) AttachFor(cmd Command) string {
+	if cmd.Attach != "" {
+		return cmd.Attach
+	}
+	if r.Attach != "" {
+		return r.Attach
+	}
+	return AttachNow
+}
+
+// validAttach reports whether an attach setting is empty or a known policy
+func validAttach(policy string) bool {
+	return policy == "" || slices.Contains(attachPolicies, policy)
 }
 
 // TmuxTemplate represents a tmux session template configuration
@@ -76,9 +504,96 @@ type TmuxTemplate struct {
 
 // TmuxWindow represents a tmux window configuration
 type TmuxWindow struct {
-	Name   string   `yaml:"name" mapstructure:"name"`
-	Layout string   `yaml:"layout,omitempty" mapstructure:"layout"`
-	Panes  []string `yaml:"panes" mapstructure:"panes"`
+	Name   string     `yaml:"name" mapstructure:"name"`
+	Layout string     `yaml:"layout,omitempty" mapstructure:"layout"` // preset name or tmux layout string
+	Panes  []TmuxPane `yaml:"panes" mapstructure:"panes"`
+}
+
+// TmuxPane is a pane of a template window. A pane given as a plain string
+// is just its command. Every pane after the first is made by splitting an
+// earlier one: by default the first pane, alternately below and to the
+// right.
+type TmuxPane struct {
+	Command   string `yaml:"command,omitempty" mapstructure:"command"`
+	Split     int    `yaml:"split,omitempty" mapstructure:"split"`         // position of the earlier pane to split
+	Direction string `yaml:"direction,omitempty" mapstructure:"direction"` // right, left, below or above
+	Size      int    `yaml:"size,omitempty" mapstructure:"size"`           // percentage of the split pane
+	Dir       string `yaml:"dir,omitempty" mapstructure:"dir"`             // working directory
+	Focus     bool   `yaml:"focus,omitempty" mapstructure:"focus"`
+}
+
+// Pane split directions
+var paneDirections = map[string]bool{"right": true, "left": true, "below": true, "above": true}
+
+// TmuxLayouts are the preset tmux layouts a window can use
+var TmuxLayouts = []string{"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"}
+
+// tmuxLayoutString matches a layout as tmux prints it in window_layout,
+// such as "b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,2}"
+var tmuxLayoutString = regexp.MustCompile(`^[0-9a-f]{4},\d+x\d+,\d+,\d+[,{\[]`)
+
+// tmuxLayoutPane matches a pane in a layout string: WxH,X,Y,ID
+var tmuxLayoutPane = regexp.MustCompile(`\d+x\d+,\d+,\d+,\d+`)
+
+// validateTemplate checks a tmux template. Errors name the setting at
+// fault, such as rituals.templates.dev.windows[0].panes[1].split.
+func validateTemplate(name string, template TmuxTemplate) error {
+	path := fmt.Sprintf("rituals.templates.%s", name)
+	if template.SessionName == "" {
+		return fmt.Errorf("%s.session_name cannot be empty", path)
+	}
+
+	for i, window := range template.Windows {
+		windowPath := fmt.Sprintf("%s.windows[%d]", path, i)
+		if window.Name == "" {
+			return fmt.Errorf("%s.name cannot be empty", windowPath)
+		}
+
+		if window.Layout != "" {
+			switch {
+			case tmuxLayoutString.MatchString(window.Layout):
+				panes := max(len(window.Panes), 1)
+				if n := len(tmuxLayoutPane.FindAllString(window.Layout, -1)); n != panes {
+					return fmt.Errorf("%s.layout has %d panes but the window has %d", windowPath, n, panes)
+				}
+			case !slices.Contains(TmuxLayouts, window.Layout):
+				return fmt.Errorf("%s.layout: unknown layout '%s' (use %s or a tmux layout string)",
+					windowPath, window.Layout, strings.Join(TmuxLayouts, ", "))
+			}
+		}
+
+		focused := false
+		for j, pane := range window.Panes {
+			panePath := fmt.Sprintf("%s.panes[%d]", windowPath, j)
+			if j == 0 && (pane.Split != 0 || pane.Direction != "" || pane.Size != 0) {
+				return fmt.Errorf("%s: the first pane isn't split, so it can't set split, direction or size", panePath)
+			}
+			if pane.Split < 0 || (j > 0 && pane.Split >= j) {
+				return fmt.Errorf("%s.split must be the position of an earlier pane (0-%d), got: %d", panePath, max(j-1, 0), pane.Split)
+			}
+			if pane.Direction != "" && !paneDirections[pane.Direction] {
+				return fmt.Errorf("%s.direction must be right, left, below or above, got: %s", panePath, pane.Direction)
+			}
+			if pane.Size < 0 || pane.Size > 99 {
+				return fmt.Errorf("%s.size must be a percentage between 1 and 99, got: %d", panePath, pane.Size)
+			}
+			if pane.Focus {
+				if focused {
+					return fmt.Errorf("%s.focus: only one pane of a window can have focus", panePath)
+				}
+				focused = true
+			}
+		}
+	}
+	return nil
+}
+
+// tmuxPaneHook lets a template pane be given as just its command
+func tmuxPaneHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(TmuxPane{}) {
+		return map[string]interface{}{"command": data}, nil
+	}
+	return data, nil
 }
 
 // Integrations contains external service integrations
@@ -125,9 +640,15 @@ type Logging struct {
 func Load() (*Config, error) {
 	var cfg Config
 
-	if err := viper.Unmarshal(&cfg); err != nil {
+	hooks := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		tmuxPaneHook,
+	))
+	if err := viper.Unmarshal(&cfg, hooks); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.dropLegacyProjects()
 
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -154,6 +675,44 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("idle_threshold must be positive, got: %v", c.Settings.IdleThreshold)
 	}
 
+	if _, err := c.Settings.Location(); err != nil {
+		return err
+	}
+
+	if _, err := c.Settings.FirstWeekday(); err != nil {
+		return err
+	}
+
+	if mode := c.Settings.DaemonMode(); mode != DaemonAuto && mode != DaemonManual {
+		return fmt.Errorf("daemon must be auto or manual, got: %q", c.Settings.Daemon)
+	}
+
+	if c.Settings.Pomodoro.ShortBreak < 0 || c.Settings.Pomodoro.LongBreak < 0 {
+		return fmt.Errorf("pomodoro break lengths cannot be negative")
+	}
+
+	if c.Settings.Pomodoro.LongBreakEvery < 0 {
+		return fmt.Errorf("pomodoro long_break_every cannot be negative, got: %d", c.Settings.Pomodoro.LongBreakEvery)
+	}
+
+	for _, threshold := range c.Settings.EndOfDay.Thresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("end_of_day thresholds must be positive percentages, got: %d", threshold)
+		}
+	}
+
+	if c.Settings.EndOfDay.HardStop != "" {
+		if _, err := time.Parse("15:04", c.Settings.EndOfDay.HardStop); err != nil {
+			return fmt.Errorf("end_of_day hard_stop must be a time like 18:30, got: %q", c.Settings.EndOfDay.HardStop)
+		}
+	}
+
+	for _, source := range c.Settings.IdleSources {
+		if _, err := tracking.NewIdleSource(source); err != nil {
+			return err
+		}
+	}
+
 	// Validate projects
 	for i, project := range c.Projects {
 		if project.Name == "" {
@@ -162,17 +721,17 @@ func (c *Config) Validate() error {
 		if len(project.Detect) == 0 {
 			return fmt.Errorf("project[%d]: detect patterns cannot be empty", i)
 		}
+		for _, pattern := range project.Detect {
+			if _, err := tracking.ParseProjectRule(project.Name, pattern); err != nil {
+				return fmt.Errorf("project '%s': %w", project.Name, err)
+			}
+		}
 	}
 
 	// Validate templates
 	for templateName, template := range c.Rituals.Templates {
-		if template.SessionName == "" {
-			return fmt.Errorf("template '%s': session_name cannot be empty", templateName)
-		}
-		for i, window := range template.Windows {
-			if window.Name == "" {
-				return fmt.Errorf("template '%s': window[%d] name cannot be empty", templateName, i)
-			}
+		if err := validateTemplate(templateName, template); err != nil {
+			return err
 		}
 	}
 
@@ -185,8 +744,41 @@ func (c *Config) Validate() error {
 		allCommands = append(allCommands, commands)
 	}
 
+	if c.Rituals.Concurrency < 0 {
+		return fmt.Errorf("rituals concurrency cannot be negative, got: %d", c.Rituals.Concurrency)
+	}
+	if c.Rituals.History.Keep < 0 || c.Rituals.History.MaxAge < 0 {
+		return fmt.Errorf("rituals history keep and max_age cannot be negative")
+	}
+	if c.Rituals.StopGrace < 0 {
+		return fmt.Errorf("rituals stop_grace cannot be negative, got: %v", c.Rituals.StopGrace)
+	}
+	if !validAttach(c.Rituals.Attach) {
+		return fmt.Errorf("rituals attach must be one of %s, got: %q", strings.Join(attachPolicies, ", "), c.Rituals.Attach)
+	}
+
 	for _, commands := range allCommands {
+		if err := validateGraph(commands); err != nil {
+			return err
+		}
 		for _, cmd := range commands {
+			if cmd.Command != "" && len(cmd.Args) > 0 {
+				return fmt.Errorf("command '%s' sets both command and args; use one", cmd.Name)
+			}
+			if cmd.Timeout < 0 {
+				return fmt.Errorf("command '%s': timeout cannot be negative, got: %v", cmd.Name, cmd.Timeout)
+			}
+			for name := range cmd.Env {
+				if name == "" || strings.ContainsAny(name, "= ") {
+					return fmt.Errorf("command '%s': invalid environment variable name %q", cmd.Name, name)
+				}
+			}
+			if cmd.When != nil {
+				if err := cmd.When.validate(); err != nil {
+					return fmt.Errorf("command '%s': when: %w", cmd.Name, err)
+				}
+			}
+
 			// Validate template references
 			if cmd.TmuxTemplate != "" {
 				if _, exists := c.Rituals.Templates[cmd.TmuxTemplate]; !exists {
@@ -194,14 +786,87 @@ func (c *Config) Validate() error {
 				}
 			}
 
+			if cmd.TmuxSession == "" && (cmd.TmuxWindow != "" || cmd.TmuxPane != 0 || cmd.WaitFor != "") {
+				return fmt.Errorf("command '%s': tmux_window, tmux_pane and wait_for need tmux_session", cmd.Name)
+			}
+			if !validAttach(cmd.Attach) {
+				return fmt.Errorf("command '%s': attach must be one of %s, got: %q", cmd.Name, strings.Join(attachPolicies, ", "), cmd.Attach)
+			}
+			if cmd.TmuxPane < 0 {
+				return fmt.Errorf("command '%s': tmux_pane cannot be negative, got: %d", cmd.Name, cmd.TmuxPane)
+			}
+			if cmd.WaitFor != "" {
+				if _, err := regexp.Compile(cmd.WaitFor); err != nil {
+					return fmt.Errorf("command '%s': invalid wait_for pattern: %w", cmd.Name, err)
+				}
+			}
+
 			// Interactive commands can use tmux_template, tmux_session, or fallback to PTY
-			// No validation needed - all combinations are valid
 		}
 	}
 
 	return nil
 }
 
+// Warnings returns problems that don't stop the configuration from loading
+// but probably don't do what was meant
+func (c *Config) Warnings() []string {
+	var warnings []string
+
+	for _, cmd := range c.Rituals.AllCommands() {
+		if len(cmd.Args) > 0 {
+			for _, arg := range cmd.Args {
+				if shellOperators[arg] {
+					warnings = append(warnings, fmt.Sprintf("command '%s': args run without a shell, so %q is passed to %s literally", cmd.Name, arg, cmd.Args[0]))
+					break
+				}
+			}
+			continue
+		}
+
+		if c.Rituals.ShellFor(cmd) == NoShell && HasShellSyntax(cmd.Command) {
+			warnings = append(warnings, fmt.Sprintf("command '%s' uses shell syntax but runs without a shell (shell: none); drop the shell setting or use args", cmd.Name))
+		}
+	}
+
+	return warnings
+}
+
+// AllCommands returns every start and stop ritual command, global ones
+// first and then per project in name order
+func (r Rituals) AllCommands() []Command {
+	var commands []Command
+	for _, set := range []RitualSet{r.Start, r.Stop} {
+		commands = append(commands, set.Global...)
+
+		projects := make([]string, 0, len(set.PerProject))
+		for project := range set.PerProject {
+			projects = append(projects, project)
+		}
+		sort.Strings(projects)
+		for _, project := range projects {
+			commands = append(commands, set.PerProject[project]...)
+		}
+	}
+	return commands
+}
+
+// shellOperators are arguments that only mean something to a shell
+var shellOperators = map[string]bool{
+	"&&": true, "||": true, "|": true, ";": true, "&": true,
+	">": true, ">>": true, "<": true, "2>": true, "2>&1": true,
+}
+
+// shellSyntax matches operators, redirections, substitutions, quotes,
+// globs and ~ that a shell would interpret
+var shellSyntax = regexp.MustCompile("[|&;<>`$'\"*?]|(^|\\s)~")
+
+// HasShellSyntax reports whether a command string relies on a shell to
+// mean what it says
+func HasShellSyntax(command string) bool {
+	return shellSyntax.MatchString(command)
+}
+
 // GetConfigPath returns the path to the configuration file
 func GetConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
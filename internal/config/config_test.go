@@ -3,9 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -97,6 +99,75 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "idle_threshold must be positive",
 		},
+		{
+			name: "valid timezone and week start",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					Timezone:      "Europe/Berlin",
+					WeekStart:     "Monday",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid timezone",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					Timezone:      "Mars/Olympus_Mons",
+				},
+			},
+			wantErr: true,
+			errMsg:  "not a known IANA timezone",
+		},
+		{
+			name: "invalid week start",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					WeekStart:     "wednesday",
+				},
+			},
+			wantErr: true,
+			errMsg:  "week_start must be monday or sunday",
+		},
+		{
+			name: "valid idle sources",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					IdleSources:   []string{"logind", "tty"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown idle source",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					IdleSources:   []string{"xidle"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "unknown idle source",
+		},
 		{
 			name: "project with empty name",
 			config: Config{
@@ -129,6 +200,22 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "detect patterns cannot be empty",
 		},
+		{
+			name: "project with invalid detect pattern",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Projects: []Project{
+					{Name: "test", Detect: []string{"git-remote:github.com/(acme"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid detect pattern",
+		},
 		{
 			name: "template with empty session name",
 			config: Config{
@@ -143,7 +230,7 @@ func TestConfig_Validate(t *testing.T) {
 						"test-template": {
 							SessionName: "", // Empty session name
 							Windows: []TmuxWindow{
-								{Name: "main", Panes: []string{"vim"}},
+								{Name: "main", Panes: []TmuxPane{{Command: "vim"}}},
 							},
 						},
 					},
@@ -166,14 +253,14 @@ func TestConfig_Validate(t *testing.T) {
 						"test-template": {
 							SessionName: "test-session",
 							Windows: []TmuxWindow{
-								{Name: "", Panes: []string{"vim"}}, // Empty window name
+								{Name: "", Panes: []TmuxPane{{Command: "vim"}}}, // Empty window name
 							},
 						},
 					},
 				},
 			},
 			wantErr: true,
-			errMsg:  "window[0] name cannot be empty",
+			errMsg:  "rituals.templates.test-template.windows[0].name cannot be empty",
 		},
 		{
 			name: "command references undefined template",
@@ -249,7 +336,7 @@ func TestConfig_Validate(t *testing.T) {
 						"test-template": {
 							SessionName: "test-session",
 							Windows: []TmuxWindow{
-								{Name: "main", Panes: []string{"vim ."}},
+								{Name: "main", Panes: []TmuxPane{{Command: "vim ."}}},
 							},
 						},
 					},
@@ -281,6 +368,220 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "negative pomodoro break",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 25 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					Pomodoro:      PomodoroSettings{Enabled: true, ShortBreak: -5 * time.Minute},
+				},
+			},
+			wantErr: true,
+			errMsg:  "pomodoro break lengths cannot be negative",
+		},
+		{
+			name: "invalid hard stop",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					EndOfDay:      EndOfDaySettings{HardStop: "6pm"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "end_of_day hard_stop must be a time like 18:30",
+		},
+		{
+			name: "invalid end of day threshold",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					EndOfDay:      EndOfDaySettings{Thresholds: []int{90, 0}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "end_of_day thresholds must be positive percentages",
+		},
+		{
+			name: "command and args both set",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Stop: RitualSet{
+						Global: []Command{{Name: "commit", Command: "git commit", Args: []string{"git", "commit"}}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "command 'commit' sets both command and args",
+		},
+		{
+			name: "negative command timeout",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "pull", Command: "git pull", Timeout: -time.Second}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "command 'pull': timeout cannot be negative",
+		},
+		{
+			name: "unknown day in when",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "standup", Command: "open standup", When: &When{Days: []string{"mon", "funday"}}}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  `command 'standup': when: unknown day "funday"`,
+		},
+		{
+			name: "invalid time window in when",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "standup", Command: "open standup", When: &When{Time: "9am-noon"}}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "time window must look like 09:00-17:00",
+		},
+		{
+			name: "unknown os in when",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "brew", Command: "brew update", When: &When{OS: []string{"macos"}}}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  `unknown os "macos"`,
+		},
+		{
+			name: "wait_for without tmux_session",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "server", Command: "npm run dev", WaitFor: "ready"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "command 'server': tmux_window, tmux_pane and wait_for need tmux_session",
+		},
+		{
+			name: "negative tmux_pane",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "server", Command: "npm run dev", TmuxSession: "dev", TmuxPane: -1}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "command 'server': tmux_pane cannot be negative",
+		},
+		{
+			name: "invalid wait_for pattern",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{
+						Global: []Command{{Name: "server", Command: "npm run dev", TmuxSession: "dev", WaitFor: "ready ("}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "command 'server': invalid wait_for pattern",
+		},
+		{
+			name: "unknown rituals attach policy",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Attach: "later"},
+			},
+			wantErr: true,
+			errMsg:  `rituals attach must be one of attach, switch-client, detached, attach-last, got: "later"`,
+		},
+		{
+			name: "unknown command attach policy",
+			config: Config{
+				Version: 1,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "dev", Interactive: true, TmuxSession: "dev", Attach: "background"}}}},
+			},
+			wantErr: true,
+			errMsg:  `command 'dev': attach must be one of`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -333,3 +634,302 @@ func TestExists(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, exists)
 }
+
+func TestPomodoroSettings_Breaks(t *testing.T) {
+	short, long, every := PomodoroSettings{Enabled: true}.Breaks()
+	assert.Equal(t, DefaultShortBreak, short)
+	assert.Equal(t, DefaultLongBreak, long)
+	assert.Equal(t, DefaultLongBreakEvery, every)
+
+	short, long, every = PomodoroSettings{ShortBreak: 3 * time.Minute, LongBreak: 20 * time.Minute, LongBreakEvery: 3}.Breaks()
+	assert.Equal(t, 3*time.Minute, short)
+	assert.Equal(t, 20*time.Minute, long)
+	assert.Equal(t, 3, every)
+}
+
+func TestEndOfDaySettings(t *testing.T) {
+	assert.Equal(t, []int{90, 100}, EndOfDaySettings{}.ReminderThresholds())
+	assert.Equal(t, []int{75}, EndOfDaySettings{Thresholds: []int{75}}.ReminderThresholds())
+
+	_, ok := EndOfDaySettings{}.HardStopOn(time.Now())
+	assert.False(t, ok)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 1, 9, 15, 0, 0, berlin)
+	stop, ok := EndOfDaySettings{HardStop: "18:30"}.HardStopOn(day)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 1, 18, 30, 0, 0, berlin), stop)
+}
+
+func TestConfig_Warnings(t *testing.T) {
+	cfg := &Config{
+		Rituals: Rituals{
+			Start: RitualSet{
+				Global: []Command{
+					{Name: "shell", Command: "git add -A && git commit -m 'WIP'"},
+					{Name: "split", Command: "git pull && make", Shell: NoShell},
+					{Name: "plain split", Command: "git pull --rebase", Shell: NoShell},
+				},
+			},
+			Stop: RitualSet{
+				PerProject: map[string][]Command{
+					"api": {
+						{Name: "argv", Args: []string{"git", "commit", "-m", "a && b"}},
+						{Name: "argv pipe", Args: []string{"ls", "|", "wc"}},
+					},
+				},
+			},
+		},
+	}
+
+	warnings := cfg.Warnings()
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "command 'split' uses shell syntax")
+	assert.Contains(t, warnings[1], "command 'argv pipe'")
+
+	// A global shell of none applies too
+	cfg.Rituals.Shell = NoShell
+	assert.Len(t, cfg.Warnings(), 3)
+}
+
+func TestHasShellSyntax(t *testing.T) {
+	for command, want := range map[string]bool{
+		"git pull --rebase":        false,
+		"make -j4 build":           false,
+		"git add -A && git commit": true,
+		"ls | wc -l":               true,
+		"echo $HOME":               true,
+		"git commit -m 'WIP'":      true,
+		"cat ~/.gitconfig":         true,
+		"go test ./... > out.txt":  true,
+		"git log --format=%h~1":    false,
+		"rm *.tmp":                 true,
+		"npm run dev &":            true,
+	} {
+		assert.Equal(t, want, HasShellSyntax(command), command)
+	}
+}
+
+func TestCommand_CommandLine(t *testing.T) {
+	assert.Equal(t, "git pull", Command{Command: "git pull"}.CommandLine())
+	assert.Equal(t, `git commit -m "WIP: End of day"`, Command{Args: []string{"git", "commit", "-m", "WIP: End of day"}}.CommandLine())
+}
+
+func TestRituals_AttachFor(t *testing.T) {
+	assert.Equal(t, AttachNow, Rituals{}.AttachFor(Command{}))
+	assert.Equal(t, AttachLast, Rituals{Attach: AttachLast}.AttachFor(Command{}))
+	assert.Equal(t, AttachDetached, Rituals{Attach: AttachLast}.AttachFor(Command{Attach: AttachDetached}))
+}
+
+func TestWhen_Weekdays(t *testing.T) {
+	days, err := When{Days: []string{"Weekends", "mon"}}.Weekdays()
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday, time.Monday}, days)
+
+	start, end, err := When{Time: "22:00-06:30"}.TimeWindow()
+	require.NoError(t, err)
+	assert.Equal(t, 22*time.Hour, start)
+	assert.Equal(t, 6*time.Hour+30*time.Minute, end)
+}
+
+func TestValidateGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []Command
+		errMsg   string
+	}{
+		{
+			name: "valid graph",
+			commands: []Command{
+				{Name: "db", ID: "db"},
+				{Name: "api", ID: "api", DependsOn: []string{"db"}},
+				{Name: "web", DependsOn: []string{"api", "db"}},
+			},
+		},
+		{
+			name:     "duplicate id",
+			commands: []Command{{Name: "a", ID: "db"}, {Name: "b", ID: "db"}},
+			errMsg:   "duplicate command id 'db'",
+		},
+		{
+			name:     "unknown dependency",
+			commands: []Command{{Name: "api", ID: "api", DependsOn: []string{"db"}}},
+			errMsg:   "command 'api' depends on unknown id 'db'",
+		},
+		{
+			name:     "depends on itself",
+			commands: []Command{{Name: "api", ID: "api", DependsOn: []string{"api"}}},
+			errMsg:   "command 'api' depends on itself",
+		},
+		{
+			name: "cycle",
+			commands: []Command{
+				{Name: "a", ID: "a", DependsOn: []string{"c"}},
+				{Name: "b", ID: "b", DependsOn: []string{"a"}},
+				{Name: "c", ID: "c", DependsOn: []string{"b"}},
+			},
+			errMsg: "dependency cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGraph(tt.commands)
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				assert.True(t, IsGraph(tt.commands))
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			}
+		})
+	}
+
+	assert.False(t, IsGraph([]Command{{Name: "pull"}, {Name: "build"}}))
+	assert.Equal(t, DefaultConcurrency, Rituals{}.MaxConcurrency())
+	assert.Equal(t, 2, Rituals{Concurrency: 2}.MaxConcurrency())
+}
+
+func TestValidateTemplate(t *testing.T) {
+	window := func(layout string, panes ...TmuxPane) TmuxTemplate {
+		return TmuxTemplate{
+			SessionName: "{{.Project}}-dev",
+			Windows:     []TmuxWindow{{Name: "code", Layout: layout, Panes: panes}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		template TmuxTemplate
+		errMsg   string
+	}{
+		{
+			name: "editor left, two terminals stacked on the right",
+			template: window("",
+				TmuxPane{Command: "vim", Focus: true},
+				TmuxPane{Split: 0, Direction: "right", Size: 30, Dir: "~/src"},
+				TmuxPane{Split: 1, Direction: "below"},
+			),
+		},
+		{name: "preset layout", template: window("main-horizontal", TmuxPane{}, TmuxPane{})},
+		{
+			name:     "layout string",
+			template: window("ad31,80x24,0,0{59x24,0,0,101,20x24,60,0,102}", TmuxPane{}, TmuxPane{}),
+		},
+		{
+			name:     "layout string with the wrong number of panes",
+			template: window("ad31,80x24,0,0{59x24,0,0,101,20x24,60,0,102}", TmuxPane{}),
+			errMsg:   "rituals.templates.dev.windows[0].layout has 2 panes but the window has 1",
+		},
+		{
+			name:     "unknown layout",
+			template: window("sideways"),
+			errMsg:   "rituals.templates.dev.windows[0].layout: unknown layout 'sideways'",
+		},
+		{
+			name:     "split of a later pane",
+			template: window("", TmuxPane{}, TmuxPane{Split: 1}),
+			errMsg:   "rituals.templates.dev.windows[0].panes[1].split must be the position of an earlier pane (0-0), got: 1",
+		},
+		{
+			name:     "first pane split",
+			template: window("", TmuxPane{Direction: "right"}),
+			errMsg:   "rituals.templates.dev.windows[0].panes[0]: the first pane isn't split",
+		},
+		{
+			name:     "unknown direction",
+			template: window("", TmuxPane{}, TmuxPane{Direction: "up"}),
+			errMsg:   "rituals.templates.dev.windows[0].panes[1].direction must be right, left, below or above",
+		},
+		{
+			name:     "size out of range",
+			template: window("", TmuxPane{}, TmuxPane{Size: 100}),
+			errMsg:   "rituals.templates.dev.windows[0].panes[1].size must be a percentage between 1 and 99",
+		},
+		{
+			name:     "two focused panes",
+			template: window("", TmuxPane{Focus: true}, TmuxPane{Focus: true}),
+			errMsg:   "rituals.templates.dev.windows[0].panes[1].focus: only one pane",
+		},
+		{
+			name:     "empty session name",
+			template: TmuxTemplate{},
+			errMsg:   "rituals.templates.dev.session_name cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate("dev", tt.template)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestLoad_LegacyDefaultProject(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	// The projects section 'rune init' used to write
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`
+version: 1
+settings:
+  work_hours: 8.0
+  break_interval: 50m
+  idle_threshold: 10m
+projects:
+  - name: "default"
+    detect: ["git:.*", "dir:~/"]
+  - name: "web-app"
+    detect: ["git:web-app"]
+`)))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []Project{{Name: "web-app", Detect: []string{"git:web-app"}}}, cfg.Projects)
+}
+
+func TestLoad_TmuxPanes(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`
+version: 1
+settings:
+  work_hours: 8
+  break_interval: 50m
+  idle_threshold: 10m
+rituals:
+  templates:
+    dev:
+      session_name: "{{.Project}}-dev"
+      windows:
+        - name: code
+          panes:
+            - vim
+            - command: npm test -- --watch
+              split: 0
+              direction: right
+              size: 30
+              dir: ~/src
+              focus: true
+`)))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	panes := cfg.Rituals.Templates["dev"].Windows[0].Panes
+	assert.Equal(t, []TmuxPane{
+		{Command: "vim"},
+		{Command: "npm test -- --watch", Direction: "right", Size: 30, Dir: "~/src", Focus: true},
+	}, panes)
+	assert.Equal(t, 50*time.Minute, cfg.Settings.BreakInterval)
+}
@@ -0,0 +1,262 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// ErrNotRunning is returned by Dial when no daemon is listening
+var ErrNotRunning = errors.New("rune daemon is not running")
+
+// dialTimeout bounds how long a command waits for the daemon to answer the
+// connection before falling back to opening the database itself
+const dialTimeout = 500 * time.Millisecond
+
+// Client talks to a running daemon. Its methods mirror tracking.Tracker, so
+// commands can use either one.
+type Client struct {
+	rpc      *rpc.Client
+	calendar tracking.Calendar
+}
+
+// Dial connects to the daemon at the default socket
+func Dial() (*Client, error) {
+	paths, err := DefaultPaths()
+	if err != nil {
+		return nil, err
+	}
+	return DialPath(paths.Socket)
+}
+
+// DialPath connects to the daemon listening on socket
+func DialPath(socket string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	return &Client{rpc: rpc.NewClient(conn), calendar: tracking.DefaultCalendar()}, nil
+}
+
+// Close closes the connection; the daemon keeps running
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+// call invokes a tracker method, unwrapping errors returned by the daemon
+func (c *Client) call(method string, args, reply interface{}) error {
+	err := c.rpc.Call("Tracker."+method, args, reply)
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		return errors.New(string(serverErr))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach rune daemon: %w", err)
+	}
+	return nil
+}
+
+// Status describes the running daemon
+func (c *Client) Status() (StatusReply, error) {
+	var reply StatusReply
+	err := c.call("Status", Ack{}, &reply)
+	return reply, err
+}
+
+// Shutdown asks the daemon to exit
+func (c *Client) Shutdown() error {
+	return c.call("Shutdown", Ack{}, &Ack{})
+}
+
+// SetCalendar sets the calendar used for daily and weekly totals
+func (c *Client) SetCalendar(calendar tracking.Calendar) {
+	c.calendar = calendar
+}
+
+// Calendar returns the calendar used for daily and weekly totals
+func (c *Client) Calendar() tracking.Calendar {
+	return c.calendar
+}
+
+func (c *Client) calendarArgs() CalendarArgs {
+	args := CalendarArgs{Location: "Local", WeekStart: c.calendar.WeekStart}
+	if c.calendar.Location != nil {
+		args.Location = c.calendar.Location.String()
+	}
+	return args
+}
+
+// StartWithDetails starts a session
+func (c *Client) StartWithDetails(project string, tags []string, note string) (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("Start", StartArgs{Project: project, Tags: tags, Note: note}, &reply)
+	return reply.Session, err
+}
+
+// Stop stops the current session
+func (c *Client) Stop() (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("Stop", Ack{}, &reply)
+	return reply.Session, err
+}
+
+// Pause pauses the current session
+func (c *Client) Pause() (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("Pause", Ack{}, &reply)
+	return reply.Session, err
+}
+
+// Resume resumes the current session
+func (c *Client) Resume() (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("Resume", Ack{}, &reply)
+	return reply.Session, err
+}
+
+// Annotate updates the current session's tags and note
+func (c *Client) Annotate(add, remove []string, note *string) (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("Annotate", AnnotateArgs{Add: add, Remove: remove, Note: note}, &reply)
+	return reply.Session, err
+}
+
+// GetCurrentSession returns the current session
+func (c *Client) GetCurrentSession() (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("CurrentSession", Ack{}, &reply)
+	return reply.Session, err
+}
+
+// GetSessionDuration returns the current session's worked time
+func (c *Client) GetSessionDuration() (time.Duration, error) {
+	var reply DurationReply
+	err := c.call("SessionDuration", Ack{}, &reply)
+	return reply.Duration, err
+}
+
+// GetDailyTotal returns today's total
+func (c *Client) GetDailyTotal() (time.Duration, error) {
+	var reply DurationReply
+	err := c.call("DailyTotal", c.calendarArgs(), &reply)
+	return reply.Duration, err
+}
+
+// GetWeeklyTotal returns this week's total
+func (c *Client) GetWeeklyTotal() (time.Duration, error) {
+	var reply DurationReply
+	err := c.call("WeeklyTotal", c.calendarArgs(), &reply)
+	return reply.Duration, err
+}
+
+// GetSessionHistory returns recent sessions
+func (c *Client) GetSessionHistory(limit int) ([]*tracking.Session, error) {
+	var reply SessionsReply
+	err := c.call("SessionHistory", limit, &reply)
+	return reply.Sessions, err
+}
+
+// GetProjectStats returns time per project
+func (c *Client) GetProjectStats() (map[string]time.Duration, error) {
+	var reply StatsReply
+	err := c.call("ProjectStats", Ack{}, &reply)
+	return reply.Stats, err
+}
+
+// QuerySessions returns the sessions matching a query
+func (c *Client) QuerySessions(query tracking.SessionQuery) ([]*tracking.Session, error) {
+	var reply SessionsReply
+	err := c.call("QuerySessions", query, &reply)
+	return reply.Sessions, err
+}
+
+// GetSession returns a session by ID
+func (c *Client) GetSession(id string) (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("GetSession", id, &reply)
+	return reply.Session, err
+}
+
+// UpdateSession saves changes to a session
+func (c *Client) UpdateSession(session *tracking.Session) error {
+	var reply SessionReply
+	if err := c.call("UpdateSession", *session, &reply); err != nil {
+		return err
+	}
+	// Pick up the normalized tags and recomputed duration
+	if reply.Session != nil {
+		*session = *reply.Session
+	}
+	return nil
+}
+
+// DeleteSession removes a session
+func (c *Client) DeleteSession(id string) error {
+	return c.call("DeleteSession", id, &Ack{})
+}
+
+// AddSession records a past session
+func (c *Client) AddSession(project string, start, end time.Time, tags []string, note string) (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("AddSession", AddSessionArgs{Project: project, Start: start, End: end, Tags: tags, Note: note}, &reply)
+	return reply.Session, err
+}
+
+// FindOverlaps returns the sessions overlapping a session
+func (c *Client) FindOverlaps(session *tracking.Session) ([]*tracking.Session, error) {
+	var reply SessionsReply
+	err := c.call("FindOverlaps", *session, &reply)
+	return reply.Sessions, err
+}
+
+// SaveImportedSession stores an imported session
+func (c *Client) SaveImportedSession(session *tracking.Session) error {
+	return c.call("SaveImportedSession", *session, &Ack{})
+}
+
+// IsIdle reports whether the user is idle
+func (c *Client) IsIdle() (bool, error) {
+	var reply IdleReply
+	err := c.call("Idle", Ack{}, &reply)
+	return reply.Idle, err
+}
+
+// GetIdleTime returns how long the user has been idle
+func (c *Client) GetIdleTime() (time.Duration, error) {
+	var reply IdleReply
+	err := c.call("Idle", Ack{}, &reply)
+	return reply.IdleTime, err
+}
+
+// PendingIdle returns the idle stretch waiting for an answer
+func (c *Client) PendingIdle() (*tracking.IdleStretch, error) {
+	var reply StretchReply
+	err := c.call("PendingIdle", Ack{}, &reply)
+	return reply.Stretch, err
+}
+
+// ResolveIdle records what the pending idle stretch was
+func (c *Client) ResolveIdle(resolution tracking.IdleResolution, project string) (*tracking.Session, error) {
+	var reply SessionReply
+	err := c.call("ResolveIdle", ResolveIdleArgs{Resolution: resolution, Project: project}, &reply)
+	return reply.Session, err
+}
+
+// BreakStatus describes the current session's next break
+func (c *Client) BreakStatus() (BreakReply, error) {
+	var reply BreakReply
+	err := c.call("BreakStatus", Ack{}, &reply)
+	return reply, err
+}
+
+// SnoozeBreak pushes the current break reminder back by d and returns when
+// it will fire
+func (c *Client) SnoozeBreak(d time.Duration) (time.Time, error) {
+	var reply SnoozeReply
+	err := c.call("SnoozeBreak", d, &reply)
+	return reply.Until, err
+}
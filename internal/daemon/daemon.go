@@ -0,0 +1,260 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/dnd"
+	"github.com/ferg-cod3s/rune/internal/logger"
+	"github.com/ferg-cod3s/rune/internal/notifications"
+	"github.com/ferg-cod3s/rune/internal/rituals"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// Paths are the files the daemon uses under ~/.rune
+type Paths struct {
+	Socket string
+	PID    string
+	Log    string
+}
+
+// DefaultPaths returns the daemon's socket, PID and log file paths
+func DefaultPaths() (Paths, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	runeDir := filepath.Join(home, ".rune")
+	return Paths{
+		Socket: filepath.Join(runeDir, "rune.sock"),
+		PID:    filepath.Join(runeDir, "daemon.pid"),
+		Log:    filepath.Join(runeDir, "daemon.log"),
+	}, nil
+}
+
+// Server owns the tracker and serves it to CLI commands over a Unix socket.
+// While it runs, it is the only process with sessions.db open: it watches for
+// idle time and sends reminders for as long as a session is active.
+type Server struct {
+	tracker   *tracking.Tracker
+	cfg       *config.Config
+	paths     Paths
+	service   *TrackerService
+	reminders *reminders
+	notifier  *notifications.NotificationManager
+	started   time.Time
+
+	listener net.Listener
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServer creates a daemon server around an open tracker. A nil config
+// uses the tracker's defaults and disables notifications.
+func NewServer(tracker *tracking.Tracker, cfg *config.Config, paths Paths) *Server {
+	server := &Server{
+		tracker: tracker,
+		cfg:     cfg,
+		paths:   paths,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+	}
+
+	enabled := cfg != nil && cfg.Settings.Notifications.Enabled
+	nm := notifications.NewNotificationManager(enabled)
+	if cfg != nil && enabled && cfg.Settings.Notifications.IdleDetection {
+		tracker.SetIdleEndHandler(func(stretch *tracking.IdleStretch) {
+			_ = nm.SendIdleReclaim(stretch.Duration(time.Now()), stretch.Project)
+		})
+	}
+
+	server.service = &TrackerService{server: server}
+	// The idle monitor pauses the session from its own goroutine
+	tracker.SetIdleLock(&server.service.mu)
+	server.reminders = newReminders(cfg, nm)
+	server.notifier = nm
+	return server
+}
+
+// Run serves until Shutdown is called or the process is interrupted
+func (s *Server) Run() error {
+	log := logger.TrackingLogger()
+
+	if client, err := DialPath(s.paths.Socket); err == nil {
+		status, _ := client.Status()
+		client.Close()
+		return fmt.Errorf("daemon already running (pid %d)", status.PID)
+	}
+
+	// A socket left behind by a daemon that didn't shut down cleanly
+	_ = os.Remove(s.paths.Socket)
+
+	listener, err := net.Listen("unix", s.paths.Socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.paths.Socket, err)
+	}
+	_ = os.Chmod(s.paths.Socket, 0600)
+	s.listener = listener
+
+	if err := os.WriteFile(s.paths.PID, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+	defer os.Remove(s.paths.PID)
+
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("Tracker", s.service); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to register tracker service: %w", err)
+	}
+
+	// Pick up a session started before the daemon
+	if session, err := s.tracker.GetCurrentSession(); err == nil && session != nil && session.State != tracking.StateStopped {
+		_ = s.tracker.StartIdleMonitoring()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go s.runReminders()
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Info("daemon received signal", "signal", sig.String())
+			s.Shutdown()
+		case <-s.stop:
+		}
+	}()
+
+	log.Info("daemon started", "pid", os.Getpid(), "socket", s.paths.Socket)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.stop:
+				log.Info("daemon stopped")
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Warn("daemon failed to accept connection", "error", err)
+			continue
+		}
+		go rpcServer.ServeConn(conn)
+	}
+}
+
+// Shutdown stops serving and removes the socket
+func (s *Server) Shutdown() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		_ = os.Remove(s.paths.Socket)
+	})
+}
+
+// runReminders checks once a minute whether a reminder is due
+func (s *Server) runReminders() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.checkReminders(now)
+		}
+	}
+}
+
+// checkReminders sends any reminder that is due, records break reminders
+// against the session and enforces the hard stop
+func (s *Server) checkReminders(now time.Time) {
+	if stopped, daily := s.checkRemindersLocked(now); stopped != nil {
+		s.finishHardStop(stopped, daily)
+	}
+}
+
+// checkRemindersLocked does the work of checkReminders that touches the
+// tracker. The service lock is held throughout, so the reminder state and
+// the session can't change underneath it. It returns the session stopped
+// by the hard stop, if any.
+func (s *Server) checkRemindersLocked(now time.Time) (*tracking.Session, time.Duration) {
+	s.service.mu.Lock()
+	defer s.service.mu.Unlock()
+
+	log := logger.TrackingLogger()
+
+	session, err := s.tracker.GetCurrentSession()
+	if err != nil {
+		return nil, 0
+	}
+	daily, err := s.tracker.GetDailyTotal()
+	if err != nil {
+		return nil, 0
+	}
+
+	if s.reminders.check(session, daily, now) {
+		if _, err := s.tracker.RecordBreakReminder(now); err != nil {
+			log.Warn("failed to record break reminder", "error", err)
+		}
+	}
+
+	if !s.reminders.hardStopDue(session, now) {
+		return nil, 0
+	}
+	stopped, err := s.tracker.Stop()
+	if err != nil {
+		log.Warn("failed to stop session at hard stop", "error", err)
+		return nil, 0
+	}
+	log.Info("session stopped at hard stop", "project", stopped.Project)
+	return stopped, daily + stopped.Duration
+}
+
+// finishHardStop runs the stop rituals and ends focus mode for a session
+// stopped at the hard stop, as 'rune stop' would. It runs without the
+// service lock, so commands aren't held up by slow rituals.
+func (s *Server) finishHardStop(session *tracking.Session, daily time.Duration) {
+	log := logger.TrackingLogger()
+
+	if s.cfg != nil {
+		if err := rituals.NewEngine(s.cfg).ExecuteStopRituals(session.Project); err != nil {
+			log.Warn("stop rituals failed at hard stop", "error", err)
+		}
+	}
+
+	if err := dnd.NewDNDManager(s.notifier).Disable(); err != nil {
+		log.Warn("failed to disable focus mode at hard stop", "error", err)
+	}
+
+	_ = s.notifier.SendHardStop(daily, session.Project)
+}
+
+// ReadPID returns the PID recorded by a running daemon
+func ReadPID(paths Paths) (int, error) {
+	data, err := os.ReadFile(paths.PID)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
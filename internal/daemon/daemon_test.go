@@ -0,0 +1,351 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestServer runs a daemon on a socket in a temporary home directory
+func startTestServer(t *testing.T) (*Server, Paths) {
+	t.Helper()
+
+	home := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	t.Cleanup(func() { os.Setenv("HOME", originalHome) })
+
+	tracker, err := tracking.NewTracker()
+	require.NoError(t, err)
+
+	paths := Paths{
+		Socket: filepath.Join(home, "rune.sock"),
+		PID:    filepath.Join(home, "daemon.pid"),
+		Log:    filepath.Join(home, "daemon.log"),
+	}
+	server := NewServer(tracker, nil, paths)
+
+	done := make(chan error, 1)
+	go func() { done <- server.Run() }()
+	t.Cleanup(func() {
+		server.Shutdown()
+		require.NoError(t, <-done)
+		tracker.Close()
+	})
+
+	require.Eventually(t, func() bool {
+		client, err := DialPath(paths.Socket)
+		if err != nil {
+			return false
+		}
+		client.Close()
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	return server, paths
+}
+
+func TestDialWithoutDaemon(t *testing.T) {
+	_, err := DialPath(filepath.Join(t.TempDir(), "rune.sock"))
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestClient_SessionLifecycle(t *testing.T) {
+	_, paths := startTestServer(t)
+
+	client, err := DialPath(paths.Socket)
+	require.NoError(t, err)
+	defer client.Close()
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), status.PID)
+
+	current, err := client.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	session, err := client.StartWithDetails("api", []string{"review"}, "PR #42")
+	require.NoError(t, err)
+	assert.Equal(t, "api", session.Project)
+	assert.Equal(t, []string{"review"}, session.Tags)
+
+	_, err = client.StartWithDetails("web", nil, "")
+	assert.ErrorContains(t, err, "session already active", "tracker errors reach the client")
+
+	session, err = client.Pause()
+	require.NoError(t, err)
+	assert.Equal(t, tracking.StatePaused, session.State)
+
+	_, err = client.Resume()
+	require.NoError(t, err)
+
+	stopped, err := client.Stop()
+	require.NoError(t, err)
+	assert.Equal(t, tracking.StateStopped, stopped.State)
+
+	// A second client sees the same database
+	other, err := DialPath(paths.Socket)
+	require.NoError(t, err)
+	defer other.Close()
+
+	sessions, err := other.QuerySessions(tracking.SessionQuery{Project: "api"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, stopped.ID, sessions[0].ID)
+
+	fetched, err := other.GetSession(stopped.ID)
+	require.NoError(t, err)
+	fetched.Tags = append(fetched.Tags, " +deep-work ")
+	require.NoError(t, other.UpdateSession(fetched))
+	assert.Equal(t, []string{"review", "deep-work"}, fetched.Tags, "normalized by the daemon")
+
+	require.NoError(t, other.DeleteSession(stopped.ID))
+	_, err = other.GetSession(stopped.ID)
+	assert.Error(t, err)
+}
+
+func TestClient_Shutdown(t *testing.T) {
+	_, paths := startTestServer(t)
+
+	client, err := DialPath(paths.Socket)
+	require.NoError(t, err)
+	require.NoError(t, client.Shutdown())
+	client.Close()
+
+	assert.Eventually(t, func() bool {
+		_, err := DialPath(paths.Socket)
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+type fakeNotifier struct {
+	breaks    []time.Duration
+	pomodoro  []time.Duration
+	breakOver []time.Duration
+	endOfDay  []time.Duration
+}
+
+func (n *fakeNotifier) SendBreakReminder(duration time.Duration) error {
+	n.breaks = append(n.breaks, duration)
+	return nil
+}
+
+func (n *fakeNotifier) SendPomodoroBreak(worked, length time.Duration, long bool) error {
+	n.pomodoro = append(n.pomodoro, length)
+	return nil
+}
+
+func (n *fakeNotifier) SendBreakOver(length time.Duration) error {
+	n.breakOver = append(n.breakOver, length)
+	return nil
+}
+
+func (n *fakeNotifier) SendEndOfDayReminder(total time.Duration, targetHours float64) error {
+	n.endOfDay = append(n.endOfDay, total)
+	return nil
+}
+
+// checkAndRecord runs a reminder check and records a sent break reminder
+// against the session, as the server does
+func checkAndRecord(r *reminders, session *tracking.Session, daily time.Duration, now time.Time) {
+	if r.check(session, daily, now) {
+		session.BreakReminders = append(session.BreakReminders, now)
+	}
+}
+
+func reminderConfig(pomodoro bool) *config.Config {
+	return &config.Config{Settings: config.Settings{
+		WorkHours:     8,
+		BreakInterval: 50 * time.Minute,
+		Pomodoro:      config.PomodoroSettings{Enabled: pomodoro},
+		Notifications: config.NotificationSettings{Enabled: true, BreakReminders: true, EndOfDayReminders: true},
+	}}
+}
+
+func runningSession(start time.Time) *tracking.Session {
+	return &tracking.Session{
+		Project:   "api",
+		StartTime: start,
+		State:     tracking.StateRunning,
+		Intervals: []tracking.Interval{{Start: start}},
+	}
+}
+
+func TestReminders(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r := newReminders(reminderConfig(false), notifier)
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	session := runningSession(start)
+
+	checkAndRecord(r, session, 0, start.Add(30*time.Minute))
+	assert.Empty(t, notifier.breaks)
+
+	checkAndRecord(r, session, 0, start.Add(55*time.Minute))
+	checkAndRecord(r, session, 0, start.Add(56*time.Minute))
+	assert.Equal(t, []time.Duration{55 * time.Minute}, notifier.breaks, "one reminder per stretch of work")
+
+	// Resuming after a break starts a new stretch
+	pausedAt := start.Add(60 * time.Minute)
+	resumed := start.Add(70 * time.Minute)
+	session.Intervals = []tracking.Interval{{Start: start, End: &pausedAt}, {Start: resumed}}
+	checkAndRecord(r, session, 0, resumed.Add(50*time.Minute))
+	assert.Len(t, notifier.breaks, 2)
+
+	reminders, taken := session.BreakCompliance()
+	assert.Equal(t, 2, reminders)
+	assert.Equal(t, 1, taken, "the first reminder was followed by a pause")
+
+	// Earlier sessions today count towards the work hours
+	assert.Empty(t, notifier.endOfDay)
+	checkAndRecord(r, session, 6*time.Hour+10*time.Minute, resumed.Add(51*time.Minute))
+	checkAndRecord(r, session, 6*time.Hour+10*time.Minute, resumed.Add(52*time.Minute))
+	assert.Len(t, notifier.endOfDay, 1)
+
+	// Nothing is sent with notifications off
+	quiet := &fakeNotifier{}
+	newReminders(&config.Config{}, quiet).check(session, 9*time.Hour, resumed.Add(3*time.Hour))
+	assert.Empty(t, quiet.breaks)
+	assert.Empty(t, quiet.endOfDay)
+}
+
+func TestReminders_Snooze(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r := newReminders(reminderConfig(false), notifier)
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	session := runningSession(start)
+
+	checkAndRecord(r, session, 0, start.Add(50*time.Minute))
+	require.Len(t, notifier.breaks, 1)
+
+	until, err := r.snooze(session, 10*time.Minute, start.Add(51*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(61*time.Minute), until)
+
+	status := r.status(session, start.Add(55*time.Minute))
+	assert.True(t, status.Snoozed)
+	assert.Equal(t, until, status.Due)
+
+	checkAndRecord(r, session, 0, start.Add(60*time.Minute))
+	assert.Len(t, notifier.breaks, 1, "still snoozed")
+	checkAndRecord(r, session, 0, start.Add(61*time.Minute))
+	checkAndRecord(r, session, 0, start.Add(62*time.Minute))
+	assert.Len(t, notifier.breaks, 2, "reminded again once when the snooze ran out")
+
+	// Pausing resets the schedule and drops the snooze
+	_, err = r.snooze(session, time.Hour, start.Add(62*time.Minute))
+	require.NoError(t, err)
+	pausedAt := start.Add(63 * time.Minute)
+	resumed := start.Add(70 * time.Minute)
+	session.Intervals = []tracking.Interval{{Start: start, End: &pausedAt}, {Start: resumed}}
+	status = r.status(session, resumed)
+	assert.False(t, status.Snoozed)
+	assert.Equal(t, resumed.Add(50*time.Minute), status.Due)
+
+	session.State = tracking.StatePaused
+	_, err = r.snooze(session, 10*time.Minute, resumed)
+	assert.Error(t, err, "nothing to snooze while paused")
+}
+
+func TestReminders_Pomodoro(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r := newReminders(reminderConfig(true), notifier)
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	session := runningSession(start)
+
+	// Four work stretches, each followed by the suggested break
+	stretch := start
+	for i := 0; i < 4; i++ {
+		due := stretch.Add(50 * time.Minute)
+		checkAndRecord(r, session, 0, due)
+
+		length := notifier.pomodoro[len(notifier.pomodoro)-1]
+		pausedAt := due.Add(time.Minute)
+		session.Intervals[len(session.Intervals)-1].End = &pausedAt
+		session.State = tracking.StatePaused
+		session.PausedAt = &pausedAt
+
+		checkAndRecord(r, session, 0, pausedAt.Add(length-time.Minute))
+		checkAndRecord(r, session, 0, pausedAt.Add(length))
+		checkAndRecord(r, session, 0, pausedAt.Add(length+time.Minute))
+
+		stretch = pausedAt.Add(length + 2*time.Minute)
+		session.Intervals = append(session.Intervals, tracking.Interval{Start: stretch})
+		session.State = tracking.StateRunning
+		session.PausedAt = nil
+	}
+
+	assert.Equal(t, []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 15 * time.Minute}, notifier.pomodoro)
+	assert.Equal(t, notifier.pomodoro, notifier.breakOver, "one break-over notification per break")
+	assert.Empty(t, notifier.breaks)
+
+	reminders, taken := session.BreakCompliance()
+	assert.Equal(t, 4, reminders)
+	assert.Equal(t, 4, taken)
+}
+
+func TestReminders_EndOfDayThresholds(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r := newReminders(reminderConfig(false), notifier)
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	session := runningSession(start)
+
+	// 8h of work a day: 90% is 7h12m
+	r.check(session, 6*time.Hour, start.Add(time.Hour))
+	assert.Empty(t, notifier.endOfDay)
+	r.check(session, 6*time.Hour, start.Add(75*time.Minute))
+	r.check(session, 6*time.Hour, start.Add(80*time.Minute))
+	assert.Equal(t, []time.Duration{7*time.Hour + 15*time.Minute}, notifier.endOfDay, "90% reminded once")
+	r.check(session, 6*time.Hour, start.Add(2*time.Hour))
+	assert.Len(t, notifier.endOfDay, 2, "100% reminded")
+
+	// Crossing both at once sends a single reminder
+	late := &fakeNotifier{}
+	newReminders(reminderConfig(false), late).check(session, 8*time.Hour, start.Add(time.Hour))
+	assert.Len(t, late.endOfDay, 1)
+
+	// Thresholds start over the next day
+	tomorrow := start.AddDate(0, 0, 1)
+	session = runningSession(tomorrow)
+	r.check(session, 7*time.Hour, tomorrow.Add(15*time.Minute))
+	assert.Len(t, notifier.endOfDay, 3)
+}
+
+func TestReminders_HardStop(t *testing.T) {
+	cfg := reminderConfig(false)
+	cfg.Settings.EndOfDay.HardStop = "18:30"
+	r := newReminders(cfg, &fakeNotifier{})
+	r.location = time.UTC
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	session := runningSession(start)
+	stopAt := time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+
+	assert.False(t, r.hardStopDue(session, stopAt.Add(-time.Minute)))
+	assert.True(t, r.hardStopDue(session, stopAt.Add(time.Minute)))
+	assert.False(t, r.hardStopDue(session, stopAt.Add(2*time.Minute)), "once per day")
+
+	// A session started after the hard stop is left alone
+	r = newReminders(cfg, &fakeNotifier{})
+	r.location = time.UTC
+	evening := runningSession(stopAt.Add(time.Hour))
+	assert.False(t, r.hardStopDue(evening, stopAt.Add(2*time.Hour)))
+
+	// Paused sessions are stopped too
+	session.State = tracking.StatePaused
+	assert.True(t, r.hardStopDue(session, stopAt.Add(time.Minute)))
+
+	// Without a hard stop nothing is stopped
+	r = newReminders(reminderConfig(false), &fakeNotifier{})
+	assert.False(t, r.hardStopDue(runningSession(start), stopAt.Add(time.Hour)))
+}
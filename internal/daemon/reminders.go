@@ -0,0 +1,238 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// notifier sends the reminders scheduled by the daemon
+type notifier interface {
+	SendBreakReminder(duration time.Duration) error
+	SendPomodoroBreak(worked, length time.Duration, long bool) error
+	SendBreakOver(length time.Duration) error
+	SendEndOfDayReminder(totalTime time.Duration, targetHours float64) error
+}
+
+// reminders decides when to send break and end-of-day reminders. A break
+// reminder is sent once per stretch of continuous work unless it is
+// snoozed; each end-of-day threshold is reminded once per day.
+type reminders struct {
+	notifier   notifier
+	schedule   tracking.BreakSchedule
+	workHours  float64
+	thresholds []int
+	breaks     bool
+	endOfDay   bool
+
+	// The hard stop is read in the configured timezone
+	endOfDaySettings config.EndOfDaySettings
+	location         *time.Location
+
+	// A snooze only applies to the stretch it was asked for: pausing
+	// resets the schedule
+	snoozedUntil   time.Time
+	snoozedStretch time.Time
+
+	breakOverSent time.Time
+
+	remindedDay       string
+	remindedThreshold int
+	hardStopDay       string
+}
+
+func newReminders(cfg *config.Config, n notifier) *reminders {
+	r := &reminders{notifier: n, location: time.Local}
+	if cfg == nil {
+		return r
+	}
+
+	r.schedule = breakSchedule(cfg)
+	r.workHours = cfg.Settings.WorkHours
+	r.thresholds = cfg.Settings.EndOfDay.ReminderThresholds()
+	r.endOfDaySettings = cfg.Settings.EndOfDay
+	if loc, err := cfg.Settings.Location(); err == nil {
+		r.location = loc
+	}
+	if !cfg.Settings.Notifications.Enabled {
+		return r
+	}
+
+	r.breaks = cfg.Settings.Notifications.BreakReminders && r.schedule.Interval > 0
+	r.endOfDay = cfg.Settings.Notifications.EndOfDayReminders && r.workHours > 0
+	return r
+}
+
+// breakSchedule builds the break schedule from the settings
+func breakSchedule(cfg *config.Config) tracking.BreakSchedule {
+	schedule := tracking.BreakSchedule{Interval: cfg.Settings.BreakInterval}
+	if cfg.Settings.Pomodoro.Enabled {
+		schedule.Pomodoro = true
+		schedule.ShortBreak, schedule.LongBreak, schedule.LongBreakEvery = cfg.Settings.Pomodoro.Breaks()
+	}
+	return schedule
+}
+
+// snooze pushes the current stretch's break reminder back by d
+func (r *reminders) snooze(session *tracking.Session, d time.Duration, now time.Time) (time.Time, error) {
+	if !r.breaks {
+		return time.Time{}, fmt.Errorf("break reminders are turned off")
+	}
+	stretch, ok := sessionStretch(session)
+	if !ok {
+		return time.Time{}, fmt.Errorf("no running session")
+	}
+
+	r.snoozedUntil = now.Add(d)
+	r.snoozedStretch = stretch
+	return r.snoozedUntil, nil
+}
+
+// snoozed returns the snooze that applies to the session's current stretch
+func (r *reminders) snoozed(session *tracking.Session) time.Time {
+	if stretch, ok := sessionStretch(session); ok && stretch.Equal(r.snoozedStretch) {
+		return r.snoozedUntil
+	}
+	return time.Time{}
+}
+
+// status describes the session's next break
+func (r *reminders) status(session *tracking.Session, now time.Time) BreakReply {
+	reply := BreakReply{Enabled: r.breaks, Pomodoro: r.schedule.Pomodoro}
+	if session == nil {
+		return reply
+	}
+
+	reply.Active = session.State != tracking.StateStopped
+	reply.Running = session.State == tracking.StateRunning
+	reply.Next = r.schedule.Upcoming(session, now)
+	reply.Reminders, reply.Taken = session.BreakCompliance()
+
+	snoozed := r.snoozed(session)
+	if due, ok := r.schedule.NextBreak(session, snoozed, now); ok {
+		reply.Due = due
+		reply.Snoozed = due.Equal(snoozed)
+	} else if suggested, ok := r.schedule.Suggested(session); ok && reply.Running {
+		// Already reminded in this stretch: describe the break that is due
+		suggested.Worked = reply.Next.Worked
+		reply.Next = suggested
+	}
+	return reply
+}
+
+// check sends any reminder that is due. daily is the time worked today in
+// completed sessions; the current session's time is added to it. It
+// returns true when a break reminder was sent, so it can be recorded
+// against the session.
+func (r *reminders) check(session *tracking.Session, daily time.Duration, now time.Time) bool {
+	if session == nil || session.State == tracking.StateStopped {
+		return false
+	}
+
+	sent := r.checkBreak(session, now)
+	if r.endOfDay && session.State == tracking.StateRunning {
+		r.checkEndOfDay(daily+session.WorkedDuration(now), now)
+	}
+	return sent
+}
+
+// checkEndOfDay sends an end-of-day reminder when today's total crosses a
+// threshold. When several were crossed since the last check, only the
+// highest one is reminded.
+func (r *reminders) checkEndOfDay(total time.Duration, now time.Time) {
+	day := now.In(r.location).Format("2006-01-02")
+	if r.remindedDay != day {
+		r.remindedDay = day
+		r.remindedThreshold = 0
+	}
+
+	percent := int(total * 100 / time.Duration(r.workHours*float64(time.Hour)))
+	crossed := 0
+	for _, threshold := range r.thresholds {
+		if threshold <= percent && threshold > r.remindedThreshold && threshold > crossed {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 {
+		return
+	}
+
+	if err := r.notifier.SendEndOfDayReminder(total, r.workHours); err == nil {
+		r.remindedThreshold = crossed
+	}
+}
+
+// hardStopDue reports whether the session should be stopped because the
+// configured hard stop has passed. Only sessions that were active at the
+// hard stop are stopped, once per day: starting again afterwards is a
+// deliberate choice.
+func (r *reminders) hardStopDue(session *tracking.Session, now time.Time) bool {
+	if session == nil || session.State == tracking.StateStopped {
+		return false
+	}
+
+	local := now.In(r.location)
+	stopAt, ok := r.endOfDaySettings.HardStopOn(local)
+	day := local.Format("2006-01-02")
+	if !ok || now.Before(stopAt) || !session.StartTime.Before(stopAt) || r.hardStopDay == day {
+		return false
+	}
+
+	r.hardStopDay = day
+	return true
+}
+
+// checkBreak sends a break reminder when one is due, and in Pomodoro mode
+// tells the user when a break has run its suggested length
+func (r *reminders) checkBreak(session *tracking.Session, now time.Time) bool {
+	if !r.breaks {
+		return false
+	}
+
+	if session.State == tracking.StatePaused {
+		r.checkBreakOver(session, now)
+		return false
+	}
+
+	due, ok := r.schedule.Due(session, r.snoozed(session), now)
+	if !ok {
+		return false
+	}
+
+	var err error
+	if r.schedule.Pomodoro {
+		err = r.notifier.SendPomodoroBreak(due.Worked, due.Length, due.Long)
+	} else {
+		err = r.notifier.SendBreakReminder(due.Worked)
+	}
+	return err == nil
+}
+
+// checkBreakOver sends one "break over" notification per pause that
+// follows a Pomodoro break reminder
+func (r *reminders) checkBreakOver(session *tracking.Session, now time.Time) {
+	if !r.schedule.Pomodoro || session.PausedAt == nil || session.PausedAt.Equal(r.breakOverSent) {
+		return
+	}
+
+	suggested, ok := r.schedule.Suggested(session)
+	if !ok || session.PausedAt.Before(session.BreakReminders[len(session.BreakReminders)-1]) {
+		return
+	}
+
+	if now.Sub(*session.PausedAt) >= suggested.Length {
+		if err := r.notifier.SendBreakOver(suggested.Length); err == nil {
+			r.breakOverSent = *session.PausedAt
+		}
+	}
+}
+
+// sessionStretch returns the start of the session's current stretch
+func sessionStretch(session *tracking.Session) (time.Time, bool) {
+	if session == nil {
+		return time.Time{}, false
+	}
+	return session.Stretch()
+}
@@ -0,0 +1,399 @@
+package daemon
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// TrackerService exposes the tracker over net/rpc. Calls are serialized, so
+// read-modify-write operations such as Pause behave as they do in a single
+// CLI process.
+type TrackerService struct {
+	server *Server
+	mu     sync.Mutex
+}
+
+// Ack is the reply of calls that return nothing. gob cannot encode structs
+// without exported fields, so it carries a flag.
+type Ack struct {
+	OK bool
+}
+
+// CalendarArgs identifies the calendar used for daily and weekly totals
+type CalendarArgs struct {
+	Location  string
+	WeekStart time.Weekday
+}
+
+// StartArgs are the arguments of Tracker.Start
+type StartArgs struct {
+	Project string
+	Tags    []string
+	Note    string
+}
+
+// AnnotateArgs are the arguments of Tracker.Annotate
+type AnnotateArgs struct {
+	Add    []string
+	Remove []string
+	Note   *string
+}
+
+// AddSessionArgs are the arguments of Tracker.AddSession
+type AddSessionArgs struct {
+	Project string
+	Start   time.Time
+	End     time.Time
+	Tags    []string
+	Note    string
+}
+
+// ResolveIdleArgs are the arguments of Tracker.ResolveIdle
+type ResolveIdleArgs struct {
+	Resolution tracking.IdleResolution
+	Project    string
+}
+
+// SessionReply carries a session, nil when there is none
+type SessionReply struct {
+	Session *tracking.Session
+}
+
+// SessionsReply carries a list of sessions
+type SessionsReply struct {
+	Sessions []*tracking.Session
+}
+
+// DurationReply carries a duration
+type DurationReply struct {
+	Duration time.Duration
+}
+
+// StatsReply carries time per project
+type StatsReply struct {
+	Stats map[string]time.Duration
+}
+
+// IdleReply carries the idle state
+type IdleReply struct {
+	Idle     bool
+	IdleTime time.Duration
+}
+
+// StretchReply carries the pending idle stretch, nil when there is none
+type StretchReply struct {
+	Stretch *tracking.IdleStretch
+}
+
+// BreakReply describes the current session's next break
+type BreakReply struct {
+	Enabled  bool
+	Pomodoro bool
+	Active   bool
+	Running  bool
+	// Due is when the next break reminder fires; zero once it has been sent
+	Due     time.Time
+	Snoozed bool
+	Next    tracking.BreakDue
+
+	Reminders int
+	Taken     int
+}
+
+// SnoozeReply carries the time a break reminder was snoozed until
+type SnoozeReply struct {
+	Until time.Time
+}
+
+// StatusReply describes the running daemon
+type StatusReply struct {
+	PID     int
+	Started time.Time
+	Socket  string
+}
+
+func (s *TrackerService) tracker() *tracking.Tracker {
+	return s.server.tracker
+}
+
+// Status reports the daemon's PID and start time
+func (s *TrackerService) Status(args Ack, reply *StatusReply) error {
+	reply.PID = os.Getpid()
+	reply.Started = s.server.started
+	reply.Socket = s.server.paths.Socket
+	return nil
+}
+
+// Shutdown stops the daemon after replying
+func (s *TrackerService) Shutdown(args Ack, reply *Ack) error {
+	reply.OK = true
+	go func() {
+		// Give the reply time to reach the client
+		time.Sleep(100 * time.Millisecond)
+		s.server.Shutdown()
+	}()
+	return nil
+}
+
+// Start starts a session
+func (s *TrackerService) Start(args StartArgs, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().StartWithDetails(args.Project, args.Tags, args.Note)
+	reply.Session = session
+	return err
+}
+
+// Stop stops the current session
+func (s *TrackerService) Stop(args Ack, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().Stop()
+	reply.Session = session
+	return err
+}
+
+// Pause pauses the current session
+func (s *TrackerService) Pause(args Ack, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().Pause()
+	reply.Session = session
+	return err
+}
+
+// Resume resumes the current session
+func (s *TrackerService) Resume(args Ack, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().Resume()
+	reply.Session = session
+	return err
+}
+
+// Annotate updates the current session's tags and note
+func (s *TrackerService) Annotate(args AnnotateArgs, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().Annotate(args.Add, args.Remove, args.Note)
+	reply.Session = session
+	return err
+}
+
+// CurrentSession returns the current session
+func (s *TrackerService) CurrentSession(args Ack, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().GetCurrentSession()
+	reply.Session = session
+	return err
+}
+
+// SessionDuration returns the current session's worked time
+func (s *TrackerService) SessionDuration(args Ack, reply *DurationReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	duration, err := s.tracker().GetSessionDuration()
+	reply.Duration = duration
+	return err
+}
+
+// DailyTotal returns today's total in the given calendar
+func (s *TrackerService) DailyTotal(args CalendarArgs, reply *DurationReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	restore := s.useCalendar(args)
+	defer restore()
+
+	total, err := s.tracker().GetDailyTotal()
+	reply.Duration = total
+	return err
+}
+
+// WeeklyTotal returns this week's total in the given calendar
+func (s *TrackerService) WeeklyTotal(args CalendarArgs, reply *DurationReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	restore := s.useCalendar(args)
+	defer restore()
+
+	total, err := s.tracker().GetWeeklyTotal()
+	reply.Duration = total
+	return err
+}
+
+// SessionHistory returns recent sessions
+func (s *TrackerService) SessionHistory(limit int, reply *SessionsReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.tracker().GetSessionHistory(limit)
+	reply.Sessions = sessions
+	return err
+}
+
+// ProjectStats returns time per project
+func (s *TrackerService) ProjectStats(args Ack, reply *StatsReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, err := s.tracker().GetProjectStats()
+	reply.Stats = stats
+	return err
+}
+
+// QuerySessions returns the sessions matching a query
+func (s *TrackerService) QuerySessions(query tracking.SessionQuery, reply *SessionsReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.tracker().QuerySessions(query)
+	reply.Sessions = sessions
+	return err
+}
+
+// GetSession returns a session by ID
+func (s *TrackerService) GetSession(id string, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().GetSession(id)
+	reply.Session = session
+	return err
+}
+
+// UpdateSession saves changes to a session
+func (s *TrackerService) UpdateSession(session tracking.Session, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.tracker().UpdateSession(&session)
+	reply.Session = &session
+	return err
+}
+
+// DeleteSession removes a session
+func (s *TrackerService) DeleteSession(id string, reply *Ack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply.OK = true
+	return s.tracker().DeleteSession(id)
+}
+
+// AddSession records a past session
+func (s *TrackerService) AddSession(args AddSessionArgs, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().AddSession(args.Project, args.Start, args.End, args.Tags, args.Note)
+	reply.Session = session
+	return err
+}
+
+// FindOverlaps returns the sessions overlapping a session
+func (s *TrackerService) FindOverlaps(session tracking.Session, reply *SessionsReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.tracker().FindOverlaps(&session)
+	reply.Sessions = sessions
+	return err
+}
+
+// SaveImportedSession stores an imported session
+func (s *TrackerService) SaveImportedSession(session tracking.Session, reply *Ack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply.OK = true
+	return s.tracker().SaveImportedSession(&session)
+}
+
+// Idle reports whether the user is idle, using the daemon's idle sources
+func (s *TrackerService) Idle(args Ack, reply *IdleReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idle, err := s.tracker().GetIdleTime()
+	if err != nil {
+		return err
+	}
+	isIdle, err := s.tracker().IsIdle()
+	reply.Idle = isIdle
+	reply.IdleTime = idle
+	return err
+}
+
+// PendingIdle returns the idle stretch waiting for an answer
+func (s *TrackerService) PendingIdle(args Ack, reply *StretchReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stretch, err := s.tracker().PendingIdle()
+	reply.Stretch = stretch
+	return err
+}
+
+// ResolveIdle records what the pending idle stretch was
+func (s *TrackerService) ResolveIdle(args ResolveIdleArgs, reply *SessionReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().ResolveIdle(args.Resolution, args.Project)
+	reply.Session = session
+	return err
+}
+
+// BreakStatus describes the current session's next break
+func (s *TrackerService) BreakStatus(args Ack, reply *BreakReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().GetCurrentSession()
+	if err != nil {
+		return err
+	}
+	*reply = s.server.reminders.status(session, time.Now())
+	return nil
+}
+
+// SnoozeBreak pushes the current stretch's break reminder back
+func (s *TrackerService) SnoozeBreak(d time.Duration, reply *SnoozeReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.tracker().GetCurrentSession()
+	if err != nil {
+		return err
+	}
+	until, err := s.server.reminders.snooze(session, d, time.Now())
+	reply.Until = until
+	return err
+}
+
+// useCalendar switches the tracker to the caller's calendar and returns a
+// function that switches it back
+func (s *TrackerService) useCalendar(args CalendarArgs) func() {
+	previous := s.tracker().Calendar()
+
+	calendar := tracking.Calendar{Location: time.Local, WeekStart: args.WeekStart}
+	if loc, err := time.LoadLocation(args.Location); err == nil && args.Location != "" {
+		calendar.Location = loc
+	}
+	s.tracker().SetCalendar(calendar)
+
+	return func() { s.tracker().SetCalendar(previous) }
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Spawn starts `rune daemon` in the background and waits until it accepts
+// connections. Its output goes to the daemon log file.
+func Spawn(paths Paths) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find rune executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(paths.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, "daemon")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	// The daemon is not our child to wait for
+	_ = cmd.Process.Release()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if client, err := DialPath(paths.Socket); err == nil {
+			client.Close()
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return fmt.Errorf("daemon did not start; see %s", paths.Log)
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// launchdLabel names the launchd job
+const launchdLabel = "dev.rune.daemon"
+
+// Unit is a user service definition that starts the daemon at login
+type Unit struct {
+	Path    string
+	Content string
+	// Enable is the command that loads and starts the unit
+	Enable string
+}
+
+// UserUnit returns the systemd (Linux) or launchd (macOS) user unit that runs
+// executable as the daemon
+func UserUnit(executable string, paths Paths) (Unit, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Unit{}, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		return Unit{
+			Path:    filepath.Join(home, ".config", "systemd", "user", "rune.service"),
+			Content: systemdUnit(executable),
+			Enable:  "systemctl --user daemon-reload && systemctl --user enable --now rune.service",
+		}, nil
+	case "darwin":
+		path := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+		return Unit{
+			Path:    path,
+			Content: launchdPlist(executable, paths),
+			Enable:  "launchctl load -w " + path,
+		}, nil
+	default:
+		return Unit{}, fmt.Errorf("user services are not supported on %s; run 'rune daemon start' instead", runtime.GOOS)
+	}
+}
+
+func systemdUnit(executable string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Rune time tracking daemon
+Documentation=https://github.com/ferg-cod3s/rune
+
+[Service]
+Type=simple
+ExecStart=%s daemon
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`, executable)
+}
+
+func launchdPlist(executable string, paths Paths) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>daemon</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, executable, paths.Log, paths.Log)
+}
@@ -102,6 +102,20 @@ func (nm *NotificationManager) SendEndOfDayReminder(totalTime time.Duration, tar
 	return nm.Send(notification)
 }
 
+// SendHardStop tells the user their session was stopped at the configured
+// end of the workday
+func (nm *NotificationManager) SendHardStop(totalTime time.Duration, project string) error {
+	notification := Notification{
+		Title:    "🛑 Workday Over",
+		Message:  fmt.Sprintf("It's your hard stop. Rune stopped %s after %v today and ran your stop rituals.", project, formatDuration(totalTime)),
+		Type:     EndOfDayReminder,
+		Priority: High,
+		Sound:    true,
+		Icon:     "workday",
+	}
+	return nm.Send(notification)
+}
+
 // SendSessionComplete sends a session completion notification
 func (nm *NotificationManager) SendSessionComplete(duration time.Duration, project string) error {
 	notification := Notification{
@@ -128,6 +142,49 @@ func (nm *NotificationManager) SendIdleDetected(idleDuration time.Duration) erro
 	return nm.Send(notification)
 }
 
+// SendIdleReclaim asks what an idle stretch was once the user is back
+func (nm *NotificationManager) SendIdleReclaim(idleDuration time.Duration, project string) error {
+	notification := Notification{
+		Title:    "👋 Welcome Back",
+		Message:  fmt.Sprintf("You were away from %s for %v. Run 'rune idle' to log it as work, a break or another project.", project, formatDuration(idleDuration)),
+		Type:     IdleDetected,
+		Priority: Normal,
+		Sound:    false,
+		Icon:     "idle",
+	}
+	return nm.Send(notification)
+}
+
+// SendPomodoroBreak sends a break reminder that suggests a break length
+func (nm *NotificationManager) SendPomodoroBreak(worked, length time.Duration, long bool) error {
+	title := "🍅 Short Break"
+	if long {
+		title = "🍅 Long Break"
+	}
+	notification := Notification{
+		Title:    title,
+		Message:  fmt.Sprintf("You've been working for %v. Take %v off, or run 'rune break snooze' to keep going.", formatDuration(worked), formatDuration(length)),
+		Type:     BreakReminder,
+		Priority: Normal,
+		Sound:    true,
+		Icon:     "break",
+	}
+	return nm.Send(notification)
+}
+
+// SendBreakOver tells the user a Pomodoro break has run its length
+func (nm *NotificationManager) SendBreakOver(length time.Duration) error {
+	notification := Notification{
+		Title:    "⏰ Break Over",
+		Message:  fmt.Sprintf("Your %v break is up. Run 'rune resume' when you're back.", formatDuration(length)),
+		Type:     BreakReminder,
+		Priority: Normal,
+		Sound:    true,
+		Icon:     "break",
+	}
+	return nm.Send(notification)
+}
+
 // macOS implementation using terminal-notifier (fallback to osascript)
 func (nm *NotificationManager) sendMacOS(notification Notification) error {
 	// Try terminal-notifier first (more reliable)
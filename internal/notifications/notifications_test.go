@@ -83,6 +83,12 @@ func TestNotificationTypes(t *testing.T) {
 		t.Logf("End of day reminder error (may be expected on CI): %v", err)
 	}
 
+	// Test hard stop
+	err = nm.SendHardStop(8*time.Hour, "test-project")
+	if err != nil {
+		t.Logf("Hard stop error (may be expected on CI): %v", err)
+	}
+
 	// Test session complete
 	err = nm.SendSessionComplete(2*time.Hour, "test-project")
 	if err != nil {
@@ -94,6 +100,23 @@ func TestNotificationTypes(t *testing.T) {
 	if err != nil {
 		t.Logf("Idle detected error (may be expected on CI): %v", err)
 	}
+
+	// Test idle reclaim
+	err = nm.SendIdleReclaim(25*time.Minute, "test-project")
+	if err != nil {
+		t.Logf("Idle reclaim error (may be expected on CI): %v", err)
+	}
+
+	// Test Pomodoro breaks
+	err = nm.SendPomodoroBreak(25*time.Minute, 5*time.Minute, false)
+	if err != nil {
+		t.Logf("Pomodoro break error (may be expected on CI): %v", err)
+	}
+
+	err = nm.SendBreakOver(15 * time.Minute)
+	if err != nil {
+		t.Logf("Break over error (may be expected on CI): %v", err)
+	}
 }
 
 func TestGetSoundName(t *testing.T) {
@@ -0,0 +1,231 @@
+package rituals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// BackgroundProcess is a background ritual command that was started
+// detached, in its own process group
+type BackgroundProcess struct {
+	PID         int       `json:"pid"`
+	Name        string    `json:"name"`
+	Command     string    `json:"command"`
+	Project     string    `json:"project,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	LogFile     string    `json:"log_file"`
+	KeepRunning bool      `json:"keep_running,omitempty"`
+}
+
+// Running reports whether the process is still alive
+func (p BackgroundProcess) Running() bool {
+	return processAlive(p.PID)
+}
+
+// BackgroundStore records background ritual processes and their logs under
+// ~/.rune/background, one JSON file per process
+type BackgroundStore struct {
+	dir string
+}
+
+// NewBackgroundStore creates a store in ~/.rune/background
+func NewBackgroundStore() (*BackgroundStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".rune", "background")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create background process directory: %w", err)
+	}
+
+	return &BackgroundStore{dir: dir}, nil
+}
+
+// unsafeLogChars are replaced in command names used in file names
+var unsafeLogChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// LogPath returns a new log file path for a command started at the given time
+func (s *BackgroundStore) LogPath(name string, started time.Time) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s-%s.log", started.Format("20060102-150405"), logSlug(name)))
+}
+
+// Save records a started process
+func (s *BackgroundStore) Save(p BackgroundProcess) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal background process: %w", err)
+	}
+	if err := os.WriteFile(s.path(p.PID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write background process file: %w", err)
+	}
+	return nil
+}
+
+// List returns the recorded processes, oldest first
+func (s *BackgroundStore) List() ([]BackgroundProcess, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background process directory: %w", err)
+	}
+
+	var processes []BackgroundProcess
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var p BackgroundProcess
+		if err := json.Unmarshal(data, &p); err != nil {
+			continue
+		}
+		processes = append(processes, p)
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].StartedAt.Before(processes[j].StartedAt)
+	})
+	return processes, nil
+}
+
+// Remove forgets a process; its log file is kept
+func (s *BackgroundStore) Remove(pid int) error {
+	if err := os.Remove(s.path(pid)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove background process file: %w", err)
+	}
+	return nil
+}
+
+func (s *BackgroundStore) path(pid int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d.json", pid))
+}
+
+// backgroundStore returns the engine's store, creating it on first use
+func (e *Engine) backgroundStore() (*BackgroundStore, error) {
+	e.backgroundMu.Lock()
+	defer e.backgroundMu.Unlock()
+
+	if e.background == nil {
+		store, err := NewBackgroundStore()
+		if err != nil {
+			return nil, err
+		}
+		e.background = store
+	}
+	return e.background, nil
+}
+
+// startBackground starts a background command detached, in its own process
+// group with its output going to a log file, and records it
+func (e *Engine) startBackground(cmd config.Command) (BackgroundProcess, error) {
+	store, err := e.backgroundStore()
+	if err != nil {
+		return BackgroundProcess{}, err
+	}
+
+	// Background commands outlive the ritual, so they get no timeout
+	execCmd, err := e.prepareCommand(context.Background(), cmd)
+	if err != nil {
+		return BackgroundProcess{}, err
+	}
+	detachProcess(execCmd)
+
+	started := time.Now()
+	logPath := store.LogPath(cmd.Name, started)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return BackgroundProcess{}, fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer logFile.Close()
+	execCmd.Stdout = logFile
+	execCmd.Stderr = logFile
+
+	if err := execCmd.Start(); err != nil {
+		return BackgroundProcess{}, err
+	}
+	// Reap the process if it exits while Rune is still running
+	go func() { _ = execCmd.Wait() }()
+
+	process := BackgroundProcess{
+		PID:         execCmd.Process.Pid,
+		Name:        cmd.Name,
+		Command:     cmd.CommandLine(),
+		Project:     e.project,
+		StartedAt:   started,
+		LogFile:     logPath,
+		KeepRunning: cmd.KeepRunning,
+	}
+	if err := store.Save(process); err != nil {
+		return process, err
+	}
+	return process, nil
+}
+
+// stopBackground terminates the background commands that the project's
+// start rituals left running, except those marked keep_running, and
+// forgets processes that have already exited. Each process group gets
+// SIGTERM, then SIGKILL if it is still running after the grace period.
+func (e *Engine) stopBackground(project string) error {
+	store, err := e.backgroundStore()
+	if err != nil {
+		return err
+	}
+	processes, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	var stopping []BackgroundProcess
+	for _, p := range processes {
+		switch {
+		case !p.Running():
+			_ = store.Remove(p.PID)
+		case p.Project == project && !p.KeepRunning:
+			stopping = append(stopping, p)
+		}
+	}
+	if len(stopping) == 0 {
+		return nil
+	}
+
+	for _, p := range stopping {
+		if err := terminateProcess(p.PID); err != nil {
+			fmt.Printf("⚠ Failed to stop %s (pid %d): %v\n", p.Name, p.PID, err)
+		}
+	}
+
+	deadline := time.Now().Add(e.config.Rituals.GracePeriod())
+	for _, p := range stopping {
+		for p.Running() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		status := "stopped"
+		if p.Running() {
+			if err := killProcess(p.PID); err != nil {
+				fmt.Printf("⚠ Failed to kill %s (pid %d): %v\n", p.Name, p.PID, err)
+				continue
+			}
+			status = "killed"
+			for wait := time.Now().Add(time.Second); p.Running() && time.Now().Before(wait); {
+				time.Sleep(50 * time.Millisecond)
+			}
+		}
+		fmt.Printf("  🛑 %s (pid %d) %s\n", p.Name, p.PID, status)
+		_ = store.Remove(p.PID)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package rituals
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestBackgroundStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := NewBackgroundStore()
+	if err != nil {
+		t.Fatalf("NewBackgroundStore() error = %v", err)
+	}
+
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	if path := store.LogPath("Start Dev Server!", started); !strings.HasSuffix(path, "20240301-090000-start-dev-server.log") {
+		t.Errorf("LogPath() = %q", path)
+	}
+
+	for i, pid := range []int{200, 100} {
+		p := BackgroundProcess{PID: pid, Name: "server", StartedAt: started.Add(-time.Duration(i) * time.Minute)}
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if err := store.Remove(200); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := store.Remove(200); err != nil {
+		t.Errorf("removing twice should not fail: %v", err)
+	}
+
+	processes, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(processes) != 1 || processes[0].PID != 100 {
+		t.Errorf("List() = %+v, want only pid 100", processes)
+	}
+}
+
+func TestBackgroundCommands_StoppedByStopRituals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax and signals")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	engine := &Engine{config: &config.Config{Rituals: config.Rituals{StopGrace: 500 * time.Millisecond}}, project: "api"}
+
+	server, err := engine.startBackground(config.Command{Name: "server", Command: "echo ready; exec sleep 30"})
+	if err != nil {
+		t.Fatalf("startBackground() error = %v", err)
+	}
+	// Ignores SIGTERM, so it is killed after the grace period. Both exec so
+	// no orphaned child is left for an init that may not reap it.
+	stubborn, err := engine.startBackground(config.Command{Name: "stubborn", Command: "trap '' TERM; exec sleep 30"})
+	if err != nil {
+		t.Fatalf("startBackground() error = %v", err)
+	}
+	kept, err := engine.startBackground(config.Command{Name: "watcher", Command: "sleep 30", KeepRunning: true})
+	if err != nil {
+		t.Fatalf("startBackground() error = %v", err)
+	}
+	t.Cleanup(func() { _ = killProcess(kept.PID) })
+
+	other := &Engine{config: engine.config, project: "web"}
+	unrelated, err := other.startBackground(config.Command{Name: "web server", Command: "sleep 30"})
+	if err != nil {
+		t.Fatalf("startBackground() error = %v", err)
+	}
+	t.Cleanup(func() { _ = killProcess(unrelated.PID) })
+
+	if !server.Running() {
+		t.Fatal("background command isn't running")
+	}
+
+	if err := engine.stopBackground("api"); err != nil {
+		t.Fatalf("stopBackground() error = %v", err)
+	}
+
+	if server.Running() || stubborn.Running() {
+		t.Error("background command still running after stop rituals")
+	}
+	if !kept.Running() {
+		t.Error("keep_running command was stopped")
+	}
+	if !unrelated.Running() {
+		t.Error("another project's command was stopped")
+	}
+
+	processes, _ := engine.background.List()
+	if len(processes) != 2 {
+		t.Errorf("expected the stopped command to be forgotten, got %+v", processes)
+	}
+
+	log, err := os.ReadFile(server.LogFile)
+	if err != nil || !strings.Contains(string(log), "ready") {
+		t.Errorf("expected output in the log file, got %q (%v)", log, err)
+	}
+}
+
+func TestExecuteStandardCommand_BackgroundIsRecorded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	engine := &Engine{config: &config.Config{}}
+	cmd := config.Command{Name: "server", Command: "sleep 30", Background: true, Timeout: 100 * time.Millisecond}
+	if err := engine.executeStandardCommand(io.Discard, cmd, nil); err != nil {
+		t.Fatalf("executeStandardCommand() error = %v", err)
+	}
+
+	processes, err := engine.background.List()
+	if err != nil || len(processes) != 1 {
+		t.Fatalf("List() = %+v, %v", processes, err)
+	}
+	t.Cleanup(func() { _ = killProcess(processes[0].PID) })
+
+	// The command's timeout doesn't apply to background commands
+	time.Sleep(300 * time.Millisecond)
+	if !processes[0].Running() {
+		t.Error("background command was killed")
+	}
+}
+
+func TestExecuteGraph_ParallelBackgroundCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	// Both start at once, the first creating the store the other uses
+	engine := &Engine{config: &config.Config{}}
+	commands := []config.Command{
+		{ID: "api", Name: "api", Command: "exec sleep 30", Background: true},
+		{ID: "web", Name: "web", Command: "exec sleep 30", Background: true},
+	}
+	if err := engine.executeGraph(commands, "global"); err != nil {
+		t.Fatalf("executeGraph() error = %v", err)
+	}
+
+	processes, err := engine.background.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	for _, p := range processes {
+		t.Cleanup(func() { _ = killProcess(p.PID) })
+	}
+	if len(processes) != 2 {
+		t.Errorf("List() = %+v, want both background commands", processes)
+	}
+}
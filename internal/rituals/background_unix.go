@@ -0,0 +1,43 @@
+//go:build !windows
+
+package rituals
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts a command in its own process group, so signals for
+// Rune's terminal don't reach it and it can be stopped with its children
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processAlive reports whether the process group led by pid still has
+// members. Checking the group rather than the pid counts children still running
+// after their shell exited, and makes a reused pid unlikely to match.
+func processAlive(pid int) bool {
+	err := syscall.Kill(-pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess asks a process group to exit
+func terminateProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// killProcess kills a process group
+func killProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// signalGroup signals the process group led by pid; a group that has
+// already exited is not an error
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
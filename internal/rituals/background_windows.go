@@ -0,0 +1,44 @@
+//go:build windows
+
+package rituals
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts a command in its own process group, so Ctrl+C in
+// Rune's console doesn't reach it
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processAlive reports whether a process exists
+func processAlive(pid int) bool {
+	const processQueryLimitedInformation = 0x1000
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	const stillActive = 259
+	return syscall.GetExitCodeProcess(handle, &code) == nil && code == stillActive
+}
+
+// terminateProcess stops a process. Windows has no SIGTERM for console
+// processes in another group, so this is the same as killProcess.
+func terminateProcess(pid int) error {
+	return killProcess(pid)
+}
+
+// killProcess kills a process
+func killProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return process.Kill()
+}
@@ -0,0 +1,235 @@
+package rituals
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// conditionEnv is what when: conditions are checked against. Tests replace
+// its functions; the zero value is filled in from the running system.
+type conditionEnv struct {
+	now      func() time.Time
+	hostname func() (string, error)
+	goos     string
+	getenv   func(string) string
+	branch   func(dir string) (string, error)
+}
+
+// defaultConditionEnv reads conditions from the running system
+func defaultConditionEnv() *conditionEnv {
+	return &conditionEnv{
+		now:      time.Now,
+		hostname: os.Hostname,
+		goos:     runtime.GOOS,
+		getenv:   os.Getenv,
+		branch:   gitBranch,
+	}
+}
+
+// gitBranch returns the branch checked out in dir
+func gitBranch(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (e *Engine) conditionEnv() *conditionEnv {
+	if e.conditions == nil {
+		e.conditions = defaultConditionEnv()
+	}
+	return e.conditions
+}
+
+// skipReason returns why a command's when: conditions keep it from running,
+// or "" if it runs. Conditions are checked from cheapest to most expensive,
+// so the guard command only runs when everything else holds.
+func (e *Engine) skipReason(cmd config.Command) string {
+	when := cmd.When
+	if when == nil {
+		return ""
+	}
+	env := e.conditionEnv()
+
+	now := env.now()
+	if loc, err := e.config.Settings.Location(); err == nil {
+		now = now.In(loc)
+	}
+
+	if len(when.Days) > 0 {
+		days, err := when.Weekdays()
+		if err != nil {
+			return err.Error()
+		}
+		if !containsWeekday(days, now.Weekday()) {
+			return fmt.Sprintf("not on %s", now.Weekday())
+		}
+	}
+
+	if when.Time != "" {
+		start, end, err := when.TimeWindow()
+		if err != nil {
+			return err.Error()
+		}
+		if !inWindow(now, start, end) {
+			return fmt.Sprintf("outside %s", when.Time)
+		}
+	}
+
+	if len(when.OS) > 0 && !containsString(when.OS, env.goos) {
+		return fmt.Sprintf("not on %s", env.goos)
+	}
+
+	if len(when.Host) > 0 {
+		host, err := env.hostname()
+		if err != nil || !matchesAny(when.Host, host) {
+			return fmt.Sprintf("host %s doesn't match", host)
+		}
+	}
+
+	if len(when.Env) > 0 {
+		if reason := e.envSkipReason(when.Env); reason != "" {
+			return reason
+		}
+	}
+
+	if len(when.Exists) == 0 && len(when.Branch) == 0 && when.Check == "" {
+		return ""
+	}
+
+	// A directory that can't be used isn't a condition failing: the
+	// command runs and fails on it, as it would without when:
+	dir, err := e.commandDir(cmd)
+	if err != nil {
+		return ""
+	}
+
+	if len(when.Exists) > 0 {
+		if reason := e.existsSkipReason(when.Exists, dir); reason != "" {
+			return reason
+		}
+	}
+
+	if len(when.Branch) > 0 {
+		branchDir := dir
+		if branchDir == "" {
+			branchDir = "."
+		}
+		branch, err := env.branch(branchDir)
+		if err != nil {
+			return err.Error()
+		}
+		if !matchesAny(when.Branch, branch) {
+			return fmt.Sprintf("on branch %s", branch)
+		}
+	}
+
+	if when.Check != "" {
+		if err := e.runCheck(cmd); err != nil {
+			return fmt.Sprintf("check %q failed: %v", when.Check, err)
+		}
+	}
+
+	return ""
+}
+
+// envSkipReason checks env conditions: NAME must be set and non-empty,
+// NAME=value must match exactly. Any one matching is enough.
+func (e *Engine) envSkipReason(conditions []string) string {
+	getenv := e.conditionEnv().getenv
+	for _, condition := range conditions {
+		name, want, hasValue := strings.Cut(condition, "=")
+		value := getenv(name)
+		if (hasValue && value == want) || (!hasValue && value != "") {
+			return ""
+		}
+	}
+	return fmt.Sprintf("env %s not matched", strings.Join(conditions, ", "))
+}
+
+// existsSkipReason checks exists conditions; any one path existing is
+// enough. Relative paths are resolved against the command's directory.
+func (e *Engine) existsSkipReason(paths []string, dir string) string {
+	variables := e.templateVariables()
+	for _, p := range paths {
+		p = expandHome(e.expandTemplate(p, variables))
+		if !filepath.IsAbs(p) && dir != "" {
+			p = filepath.Join(dir, p)
+		}
+		if _, err := os.Stat(p); err == nil {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s not found", strings.Join(paths, ", "))
+}
+
+// runCheck runs a command's guard with the command's shell, directory and
+// environment
+func (e *Engine) runCheck(cmd config.Command) error {
+	guard := config.Command{
+		Command: cmd.When.Check,
+		Shell:   cmd.Shell,
+		Dir:     cmd.Dir,
+		Env:     cmd.Env,
+		PassEnv: cmd.PassEnv,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout(cmd))
+	defer cancel()
+
+	execCmd, err := e.prepareCommand(ctx, guard)
+	if err != nil {
+		return err
+	}
+	return execCmd.Run()
+}
+
+// inWindow reports whether t's time of day is in [start, end). Windows
+// whose end is before their start wrap midnight.
+func inWindow(t time.Time, start, end time.Duration) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if start <= end {
+		return offset >= start && offset < end
+	}
+	return offset >= start || offset < end
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether value matches one of the glob patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
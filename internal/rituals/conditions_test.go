@@ -0,0 +1,138 @@
+package rituals
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// fakeConditions is a Wednesday morning on a Linux laptop, on branch main
+func fakeConditions() *conditionEnv {
+	return &conditionEnv{
+		now:      func() time.Time { return time.Date(2024, 3, 6, 9, 30, 0, 0, time.Local) },
+		hostname: func() (string, error) { return "laptop-work", nil },
+		goos:     "linux",
+		getenv: func(name string) string {
+			return map[string]string{"NODE_ENV": "development", "EMPTY": ""}[name]
+		},
+		branch: func(dir string) (string, error) { return "main", nil },
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		when *config.When
+		skip string // expected substring of the reason; "" runs
+	}{
+		{"no conditions", nil, ""},
+		{"weekday", &config.When{Days: []string{"weekdays"}}, ""},
+		{"wrong day", &config.When{Days: []string{"mon", "fri"}}, "not on Wednesday"},
+		{"weekend", &config.When{Days: []string{"weekends"}}, "not on Wednesday"},
+		{"in window", &config.When{Time: "09:00-12:00"}, ""},
+		{"outside window", &config.When{Time: "13:00-17:00"}, "outside 13:00-17:00"},
+		{"window across midnight", &config.When{Time: "22:00-10:00"}, ""},
+		{"host glob", &config.When{Host: []string{"desktop", "laptop-*"}}, ""},
+		{"other host", &config.When{Host: []string{"desktop"}}, "host laptop-work doesn't match"},
+		{"os", &config.When{OS: []string{"linux", "darwin"}}, ""},
+		{"other os", &config.When{OS: []string{"windows"}}, "not on linux"},
+		{"env set", &config.When{Env: []string{"NODE_ENV"}}, ""},
+		{"env value", &config.When{Env: []string{"NODE_ENV=production", "NODE_ENV=development"}}, ""},
+		{"env empty", &config.When{Env: []string{"EMPTY"}}, "env EMPTY not matched"},
+		{"env missing", &config.When{Env: []string{"CI"}}, "env CI not matched"},
+		{"exists", &config.When{Exists: []string{"docker-compose.yml"}}, ""},
+		{"missing file", &config.When{Exists: []string{"Procfile"}}, "Procfile not found"},
+		{"branch", &config.When{Branch: []string{"main", "release/*"}}, ""},
+		{"other branch", &config.When{Branch: []string{"release/*"}}, "on branch main"},
+		{"all hold", &config.When{Days: []string{"wed"}, OS: []string{"linux"}, Branch: []string{"main"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{config: &config.Config{}, conditions: fakeConditions()}
+			reason := engine.skipReason(config.Command{Name: tt.name, Command: "true", Dir: dir, When: tt.when})
+			if tt.skip == "" && reason != "" {
+				t.Errorf("expected the command to run, skipped: %s", reason)
+			}
+			if tt.skip != "" && !strings.Contains(reason, tt.skip) {
+				t.Errorf("skipReason() = %q, want it to contain %q", reason, tt.skip)
+			}
+		})
+	}
+}
+
+func TestSkipReason_Branch(t *testing.T) {
+	conditions := fakeConditions()
+	conditions.branch = func(dir string) (string, error) { return "", errors.New("not a git repository") }
+	engine := &Engine{config: &config.Config{}, conditions: conditions}
+
+	reason := engine.skipReason(config.Command{Command: "git pull", When: &config.When{Branch: []string{"*"}}})
+	if reason != "not a git repository" {
+		t.Errorf("skipReason() = %q", reason)
+	}
+}
+
+func TestSkipReason_Check(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	dir := t.TempDir()
+	engine := &Engine{config: &config.Config{}, conditions: fakeConditions()}
+	cmd := config.Command{Command: "make", Dir: dir, When: &config.When{Check: "test -f Makefile"}}
+
+	if reason := engine.skipReason(cmd); !strings.Contains(reason, `check "test -f Makefile" failed`) {
+		t.Errorf("skipReason() = %q", reason)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if reason := engine.skipReason(cmd); reason != "" {
+		t.Errorf("expected the guard to pass in the command's dir, got %q", reason)
+	}
+}
+
+func TestExecuteCommands_SkipsUnmetConditions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	out := filepath.Join(t.TempDir(), "ran")
+	engine := &Engine{config: &config.Config{}, conditions: fakeConditions()}
+	commands := []config.Command{
+		{Name: "weekend only", Command: "touch " + out, When: &config.When{Days: []string{"weekends"}}},
+	}
+
+	if err := engine.executeCommands(commands, "global"); err != nil {
+		t.Fatalf("executeCommands() error = %v", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("command ran although its condition didn't hold")
+	}
+}
+
+func TestExecuteCommands_MissingDirFailsWithConditions(t *testing.T) {
+	engine := &Engine{config: &config.Config{}, conditions: fakeConditions()}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	// A missing dir fails the command whether or not it has conditions
+	for _, when := range []*config.When{{OS: []string{"linux"}}, {Exists: []string{"go.mod"}}, {Branch: []string{"main"}}} {
+		commands := []config.Command{{Name: "build", Command: "true", Dir: missing, When: when}}
+		err := engine.executeCommands(commands, "global")
+		if err == nil || !strings.Contains(err.Error(), "working directory") {
+			t.Errorf("executeCommands() with when %+v error = %v, want the missing dir", when, err)
+		}
+	}
+}
@@ -3,17 +3,19 @@ package rituals
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/tmux"
 )
 
-// filterEnvironment removes sensitive environment variables before passing to subprocesses
-func filterEnvironment(env []string) []string {
+// filterEnvironment removes sensitive environment variables before passing to subprocesses.
+// Variables matching an allow pattern (a name or a glob such as "SSH_*") are kept.
+func filterEnvironment(env []string, allow ...string) []string {
 	// Define sensitive prefixes and exact names to filter out
 	sensitiveSubstrings := []string{
 		"AWS_", "AZURE_", "GOOGLE_", "GCP_", "GCLOUD_", "DO_", "DIGITALOCEAN_",
@@ -36,7 +38,7 @@ func filterEnvironment(env []string) []string {
 		}
 
 		// Allowlist Rune-specific runtime flags that are safe
-		if key == "RUNE_DEBUG" || key == "RUNE_ENV" {
+		if key == "RUNE_DEBUG" || key == "RUNE_ENV" || allowed(key, allow) {
 			filtered = append(filtered, kv)
 			continue
 		}
@@ -63,20 +65,53 @@ func filterEnvironment(env []string) []string {
 	return filtered
 }
 
+// allowed reports whether an environment variable matches one of the
+// patterns passed through by the configuration
+func allowed(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
 // Engine handles ritual execution
 type Engine struct {
-	config         *config.Config
-	tmuxClient     *tmux.Client
-	ptySupport     bool
-	activeSessions map[string]*tmux.Client
+	config     *config.Config
+	tmuxClient *tmux.Client
+	ptySupport bool
+
+	// project is the project whose rituals are running
+	project string
+
+	// conditions is what when: clauses are checked against
+	conditions *conditionEnv
+
+	// background records background commands, created by the first one,
+	// which can start in any of a graph's goroutines
+	backgroundMu sync.Mutex
+	background   *BackgroundStore
+
+	// run records the ritual that is running, if any
+	run *runLog
+
+	// attachLast is the session an attach-last ritual prepared, attached to
+	// when the rituals finish
+	attachMu   sync.Mutex
+	attachLast string
+
+	// completed are the commands with an undo that have finished in the
+	// running ritual
+	completedMu sync.Mutex
+	completed   []config.Command
 }
 
 // NewEngine creates a new ritual engine
 func NewEngine(cfg *config.Config) *Engine {
 	engine := &Engine{
-		config:         cfg,
-		activeSessions: make(map[string]*tmux.Client),
-		ptySupport:     true, // Assume PTY support is available by default
+		config:     cfg,
+		ptySupport: true, // Assume PTY support is available by default
 	}
 
 	// Try to initialize tmux client
@@ -91,8 +126,11 @@ func NewEngine(cfg *config.Config) *Engine {
 }
 
 // ExecuteStartRituals executes start rituals for the given project
-func (e *Engine) ExecuteStartRituals(project string) error {
+func (e *Engine) ExecuteStartRituals(project string) (err error) {
 	fmt.Println("🔮 Executing start rituals...")
+	e.project = project
+	e.beginRun("start", project)
+	defer func() { e.finishRitual(err) }()
 
 	// Execute global start rituals
 	if err := e.executeCommands(e.config.Rituals.Start.Global, "global"); err != nil {
@@ -110,8 +148,16 @@ func (e *Engine) ExecuteStartRituals(project string) error {
 }
 
 // ExecuteStopRituals executes stop rituals for the given project
-func (e *Engine) ExecuteStopRituals(project string) error {
+func (e *Engine) ExecuteStopRituals(project string) (err error) {
 	fmt.Println("🔮 Executing stop rituals...")
+	e.project = project
+	e.beginRun("stop", project)
+	defer func() { e.finishRitual(err) }()
+
+	// Stop what the start rituals left running before cleaning up after it
+	if err := e.stopBackground(project); err != nil {
+		fmt.Printf("⚠ Could not stop background commands: %v\n", err)
+	}
 
 	// Execute project-specific stop rituals first
 	if projectCommands, exists := e.config.Rituals.Stop.PerProject[project]; exists {
@@ -128,9 +174,29 @@ func (e *Engine) ExecuteStopRituals(project string) error {
 	return nil
 }
 
-// executeCommands executes a list of commands
+// finishRitual rolls back the ritual's completed commands if it failed,
+// records its result and attaches to the session left for last
+func (e *Engine) finishRitual(err error) {
+	if err != nil {
+		e.rollback()
+	}
+	e.completed = nil
+	e.finishRun(err)
+	e.attachDeferred(err)
+}
+
+// executeCommands executes a list of commands, in order or, when the
+// commands have ids, as a dependency graph
 func (e *Engine) executeCommands(commands []config.Command, scope string) error {
+	if config.IsGraph(commands) {
+		return e.executeGraph(commands, scope)
+	}
+
 	for _, cmd := range commands {
+		if reason := e.skipReason(cmd); reason != "" {
+			e.skipCommand(os.Stdout, cmd, scope, reason)
+			continue
+		}
 		if err := e.executeCommand(cmd, scope); err != nil {
 			if cmd.Optional {
 				fmt.Printf("⚠ Optional command failed: %s (%v)\n", cmd.Name, err)
@@ -144,14 +210,34 @@ func (e *Engine) executeCommands(commands []config.Command, scope string) error
 
 // executeCommand executes a single command
 func (e *Engine) executeCommand(cmd config.Command, scope string) error {
-	fmt.Printf("  ⚡ %s...", cmd.Name)
+	return e.runCommand(os.Stdout, cmd, scope)
+}
+
+// runCommand executes a single command, writing its progress to out and
+// recording it in the ritual run. Interactive commands always use the
+// terminal.
+func (e *Engine) runCommand(out io.Writer, cmd config.Command, scope string) error {
+	fmt.Fprintf(out, "  ⚡ %s...", cmd.Name)
+	log := e.run.command(cmd, scope)
 
-	// Check if this is an interactive command
+	var err error
 	if cmd.Interactive {
-		return e.executeInteractiveCommand(cmd, scope)
+		err = e.executeInteractiveCommand(cmd, scope)
+		log.finish(err)
+	} else {
+		err = e.executeStandardCommand(out, cmd, log)
 	}
 
-	return e.executeStandardCommand(cmd)
+	if err == nil {
+		e.markCompleted(cmd)
+	}
+	return err
+}
+
+// skipCommand reports and records a command that won't run
+func (e *Engine) skipCommand(out io.Writer, cmd config.Command, scope, reason string) {
+	fmt.Fprintf(out, "  ⏭ %s (skipped: %s)\n", cmd.Name, reason)
+	e.run.skip(cmd, scope, reason)
 }
 
 // executeInteractiveCommand dispatches interactive commands to appropriate handlers
@@ -170,49 +256,61 @@ func (e *Engine) executeInteractiveCommand(cmd config.Command, scope string) err
 	return e.executePTYCommand(cmd)
 }
 
-// executeStandardCommand executes a standard non-interactive command
-func (e *Engine) executeStandardCommand(cmd config.Command) error {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// executeStandardCommand executes a standard non-interactive command. Its
+// output is saved to the run log, if any, as well as shown.
+func (e *Engine) executeStandardCommand(out io.Writer, cmd config.Command, log *commandLog) error {
+	if cmd.Background {
+		process, err := e.startBackground(cmd)
+		if process.PID == 0 {
+			fmt.Fprintf(out, " ❌\n")
+			log.finish(err)
+			return err
+		}
+		log.background(process.LogFile)
+		fmt.Fprintf(out, " ✓ (background, pid %d)\n", process.PID)
+		if err != nil {
+			fmt.Fprintf(out, "    ⚠ Not recorded, 'rune stop' won't stop it: %v\n", err)
+		}
+		return nil
+	}
+
+	// Foreground commands run to completion or until their timeout
+	timeout := commandTimeout(cmd)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Parse command and arguments
-	parts := strings.Fields(cmd.Command)
-	if len(parts) == 0 {
-		return fmt.Errorf("empty command")
+	execCmd, err := e.prepareCommand(ctx, cmd)
+	if err != nil {
+		fmt.Fprintf(out, " ❌\n")
+		log.finish(err)
+		return err
 	}
 
-	// Create the command
-	execCmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
-
-	// Set up environment with filtered variables to avoid leaking secrets
-	execCmd.Env = filterEnvironment(os.Environ())
+	var combined syncBuffer
+	stdout, stderr := log.outputs()
+	execCmd.Stdout = io.MultiWriter(&combined, stdout)
+	execCmd.Stderr = io.MultiWriter(&combined, stderr)
 
-	if cmd.Background {
-		// For background commands, just start them
-		if err := execCmd.Start(); err != nil {
-			fmt.Printf(" ❌\n")
-			return err
-		}
-		fmt.Printf(" ✓ (background)\n")
-		return nil
+	err = execCmd.Run()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", timeout)
 	}
+	log.finish(err)
 
-	// For foreground commands, wait for completion
-	output, err := execCmd.CombinedOutput()
+	output := combined.Bytes()
 	if err != nil {
-		fmt.Printf(" ❌\n")
+		fmt.Fprintf(out, " ❌\n")
 		if len(output) > 0 {
-			fmt.Printf("    Output: %s\n", strings.TrimSpace(string(output)))
+			fmt.Fprintf(out, "    Output: %s\n", strings.TrimSpace(string(output)))
 		}
 		return err
 	}
 
-	fmt.Printf(" ✓\n")
+	fmt.Fprintf(out, " ✓\n")
 
 	// Show output if verbose mode is enabled
 	if len(output) > 0 && shouldShowOutput(string(output)) {
-		fmt.Printf("    %s\n", strings.TrimSpace(string(output)))
+		fmt.Fprintf(out, "    %s\n", strings.TrimSpace(string(output)))
 	}
 
 	return nil
@@ -250,9 +348,11 @@ func shouldShowOutput(output string) bool {
 	return true
 }
 
-// TestRitual tests a ritual without executing it
+// TestRitual tests a ritual without executing it. Guard commands in when:
+// clauses do run, to tell which commands would be skipped.
 func (e *Engine) TestRitual(ritualType string, project string) error {
 	fmt.Printf("🧪 Testing %s ritual for project: %s\n", ritualType, project)
+	e.project = project
 
 	var commands []config.Command
 
@@ -276,7 +376,11 @@ func (e *Engine) TestRitual(ritualType string, project string) error {
 		return nil
 	}
 
-	fmt.Println("Commands that would be executed:")
+	if config.IsGraph(commands) {
+		fmt.Printf("Commands that would be executed (independent ones in parallel, up to %d at a time):\n", e.config.Rituals.MaxConcurrency())
+	} else {
+		fmt.Println("Commands that would be executed:")
+	}
 	for i, cmd := range commands {
 		optional := ""
 		background := ""
@@ -286,7 +390,19 @@ func (e *Engine) TestRitual(ritualType string, project string) error {
 		if cmd.Background {
 			background = " (background)"
 		}
-		fmt.Printf("  %d. %s: %s%s%s\n", i+1, cmd.Name, cmd.Command, optional, background)
+		undo := ""
+		if cmd.Undo != "" {
+			undo = " (undo: " + cmd.Undo + ")"
+		}
+		after := ""
+		if len(cmd.DependsOn) > 0 {
+			after = " (after " + strings.Join(cmd.DependsOn, ", ") + ")"
+		}
+		skipped := ""
+		if reason := e.skipReason(cmd); reason != "" {
+			skipped = " ⏭ skipped: " + reason
+		}
+		fmt.Printf("  %d. %s: %s%s%s%s%s%s\n", i+1, cmd.Name, cmd.CommandLine(), optional, background, undo, after, skipped)
 	}
 
 	return nil
@@ -0,0 +1,127 @@
+package rituals
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// prepareCommand builds the process for a ritual command: its argv, working
+// directory and environment. The process is killed when ctx is done.
+func (e *Engine) prepareCommand(ctx context.Context, cmd config.Command) (*exec.Cmd, error) {
+	argv, err := e.commandArgv(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	execCmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	// Killing the shell leaves its children holding the output pipe open;
+	// don't wait on them for long
+	execCmd.WaitDelay = waitDelay
+
+	dir, err := e.commandDir(cmd)
+	if err != nil {
+		return nil, err
+	}
+	execCmd.Dir = dir
+	execCmd.Env = e.commandEnv(cmd, os.Environ())
+
+	return execCmd, nil
+}
+
+// waitDelay is how long to wait for a killed command's output to close
+const waitDelay = 2 * time.Second
+
+// commandTimeout returns how long a foreground command may run
+func commandTimeout(cmd config.Command) time.Duration {
+	if cmd.Timeout > 0 {
+		return cmd.Timeout
+	}
+	return config.DefaultCommandTimeout
+}
+
+// templateVariables are the variables available in a command's dir and env
+func (e *Engine) templateVariables() map[string]string {
+	variables := map[string]string{"Project": e.project}
+	if home, err := os.UserHomeDir(); err == nil {
+		variables["Home"] = home
+	}
+	return variables
+}
+
+// commandDir returns the working directory of a command, or "" to inherit
+// Rune's. It expands ~ and template variables and checks the directory
+// exists, so a typo fails clearly instead of as a confusing command error.
+func (e *Engine) commandDir(cmd config.Command) (string, error) {
+	if cmd.Dir == "" {
+		return "", nil
+	}
+
+	dir := expandHome(e.expandTemplate(cmd.Dir, e.templateVariables()))
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("working directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working directory %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// commandEnv returns the environment of a command: Rune's environment with
+// sensitive variables removed except those passed through, plus the
+// command's own variables
+func (e *Engine) commandEnv(cmd config.Command, environ []string) []string {
+	allow := append(append([]string{}, e.config.Rituals.PassEnv...), cmd.PassEnv...)
+	env := filterEnvironment(environ, allow...)
+	if len(cmd.Env) == 0 {
+		return env
+	}
+
+	// The config loader lowercases map keys, so names are upper-cased
+	variables := e.templateVariables()
+	added := make(map[string]string, len(cmd.Env))
+	for name, value := range cmd.Env {
+		added[strings.ToUpper(name)] = e.expandTemplate(value, variables)
+	}
+
+	merged := make([]string, 0, len(env)+len(added))
+	for _, kv := range env {
+		name := kv
+		if idx := strings.IndexByte(kv, '='); idx >= 0 {
+			name = kv[:idx]
+		}
+		if _, overridden := added[name]; !overridden {
+			merged = append(merged, kv)
+		}
+	}
+
+	names := make([]string, 0, len(added))
+	for name := range added {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		merged = append(merged, name+"="+added[name])
+	}
+	return merged
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,97 @@
+package rituals
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestExecuteStandardCommand_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+
+	engine := &Engine{config: &config.Config{}}
+	cmd := config.Command{Name: "slow", Command: "sleep 5", Timeout: 200 * time.Millisecond}
+
+	started := time.Now()
+	err := engine.executeStandardCommand(io.Discard, cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("command ran for %v despite its timeout", elapsed)
+	}
+}
+
+func TestCommandDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "projects", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &Engine{config: &config.Config{}, project: "api"}
+
+	dir, err := engine.commandDir(config.Command{Dir: "~/projects/{{.Project}}"})
+	if err != nil {
+		t.Fatalf("commandDir() error = %v", err)
+	}
+	if want := filepath.Join(home, "projects", "api"); dir != want {
+		t.Errorf("commandDir() = %q, want %q", dir, want)
+	}
+
+	if dir, _ := engine.commandDir(config.Command{}); dir != "" {
+		t.Errorf("expected no working directory by default, got %q", dir)
+	}
+
+	if _, err := engine.commandDir(config.Command{Dir: "~/missing"}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestCommandEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"NODE_ENV=production",
+		"SSH_AUTH_SOCK=/tmp/agent.sock",
+		"SSH_AGENT_PID=42",
+		"GITHUB_TOKEN=secret",
+	}
+
+	engine := &Engine{
+		config:  &config.Config{Rituals: config.Rituals{PassEnv: []string{"SSH_AUTH_SOCK"}}},
+		project: "api",
+	}
+	cmd := config.Command{
+		Env: map[string]string{"node_env": "development", "APP": "{{.Project}}"},
+	}
+
+	env := engine.commandEnv(cmd, environ)
+	want := []string{"PATH=/usr/bin", "SSH_AUTH_SOCK=/tmp/agent.sock", "APP=api", "NODE_ENV=development"}
+	if strings.Join(env, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commandEnv() = %q, want %q", env, want)
+	}
+
+	// A command can pass more through, by name or glob
+	cmd = config.Command{PassEnv: []string{"SSH_*"}}
+	env = engine.commandEnv(cmd, environ)
+	if !contains(env, "SSH_AGENT_PID=42") || contains(env, "GITHUB_TOKEN=secret") {
+		t.Errorf("commandEnv() = %q", env)
+	}
+}
+
+func contains(env []string, kv string) bool {
+	for _, e := range env {
+		if e == kv {
+			return true
+		}
+	}
+	return false
+}
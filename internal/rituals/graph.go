@@ -0,0 +1,175 @@
+package rituals
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// nodeState is how far a command of a ritual graph has got
+type nodeState int
+
+const (
+	nodePending nodeState = iota
+	nodeRunning
+	nodeSucceeded // ran, or was skipped by its when: conditions
+	nodeFailed    // failed, or was skipped because a dependency failed
+)
+
+// graphResult is a finished command of a ritual graph
+type graphResult struct {
+	index  int
+	output *bytes.Buffer // nil for interactive commands, which use the terminal
+	err    error
+}
+
+// executeGraph runs commands as a dependency graph: each starts once the
+// commands it depends on have succeeded, up to rituals.concurrency at a
+// time. A command's output is printed in one piece when it finishes. When a
+// command fails, everything downstream of it is skipped; independent
+// commands still run, and a required failure fails the ritual at the end.
+// Interactive commands need the terminal, so they run alone.
+func (e *Engine) executeGraph(commands []config.Command, scope string) error {
+	ids := make(map[string]int, len(commands))
+	for i, cmd := range commands {
+		if cmd.ID != "" {
+			ids[cmd.ID] = i
+		}
+	}
+
+	// Set up shared state before commands run concurrently
+	e.conditionEnv()
+
+	limit := e.config.Rituals.MaxConcurrency()
+	states := make([]nodeState, len(commands))
+	results := make(chan graphResult)
+	running, finished := 0, 0
+	exclusive := false // an interactive command has the terminal
+	var firstErr error
+
+	start := func(i int) {
+		cmd := commands[i]
+		states[i] = nodeRunning
+		running++
+
+		var out io.Writer = os.Stdout
+		var buffer *bytes.Buffer
+		if cmd.Interactive {
+			exclusive = true
+		} else {
+			buffer = &bytes.Buffer{}
+			out = buffer
+		}
+
+		go func() {
+			results <- graphResult{index: i, output: buffer, err: e.runGraphCommand(out, cmd, scope)}
+		}()
+	}
+
+	for finished < len(commands) {
+		// Skip commands downstream of a failure and start those that are
+		// ready, in config order. Skipping can make more skippable, so
+		// repeat until nothing changes.
+		for changed := true; changed && !exclusive; {
+			changed = false
+			for i, cmd := range commands {
+				if states[i] != nodePending || exclusive {
+					continue
+				}
+
+				ready, failed := dependencyState(cmd, ids, states)
+				if failed != "" {
+					states[i] = nodeFailed
+					finished++
+					changed = true
+					e.skipCommand(os.Stdout, cmd, scope, commands[ids[failed]].Name+" failed")
+					continue
+				}
+				if !ready || running >= limit || (cmd.Interactive && running > 0) {
+					continue
+				}
+
+				start(i)
+				changed = true
+			}
+		}
+
+		if finished == len(commands) {
+			break
+		}
+		if running == 0 {
+			return fmt.Errorf("commands can't start, check their depends_on: %s", pendingNames(commands, states))
+		}
+
+		result := <-results
+		running--
+		finished++
+		cmd := commands[result.index]
+		if cmd.Interactive {
+			exclusive = false
+		}
+		if result.output != nil {
+			os.Stdout.Write(result.output.Bytes())
+		}
+
+		if result.err == nil {
+			states[result.index] = nodeSucceeded
+			continue
+		}
+		states[result.index] = nodeFailed
+		if cmd.Optional {
+			fmt.Printf("⚠ Optional command failed: %s (%v)\n", cmd.Name, result.err)
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("command '%s' failed: %w", cmd.Name, result.err)
+		}
+	}
+
+	return firstErr
+}
+
+// runGraphCommand runs a command of a ritual graph unless its when:
+// conditions keep it from running
+func (e *Engine) runGraphCommand(out io.Writer, cmd config.Command, scope string) error {
+	if reason := e.skipReason(cmd); reason != "" {
+		e.skipCommand(out, cmd, scope, reason)
+		return nil
+	}
+	return e.runCommand(out, cmd, scope)
+}
+
+// dependencyState reports whether every dependency of cmd has succeeded,
+// or else the id of one that failed
+func dependencyState(cmd config.Command, ids map[string]int, states []nodeState) (ready bool, failed string) {
+	ready = true
+	for _, dep := range cmd.DependsOn {
+		i, exists := ids[dep]
+		if !exists {
+			return false, ""
+		}
+		switch states[i] {
+		case nodeFailed:
+			return false, dep
+		case nodeSucceeded:
+		default:
+			ready = false
+		}
+	}
+	return ready, ""
+}
+
+// pendingNames lists the commands that haven't started
+func pendingNames(commands []config.Command, states []nodeState) string {
+	var names []string
+	for i, cmd := range commands {
+		if states[i] == nodePending {
+			names = append(names, cmd.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,114 @@
+package rituals
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestExecuteGraph_Parallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+
+	commands := []config.Command{
+		{ID: "db", Name: "db", Command: "sleep 0.4"},
+		{ID: "cache", Name: "cache", Command: "sleep 0.4"},
+		{ID: "queue", Name: "queue", Command: "sleep 0.4"},
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		min, max    time.Duration
+	}{
+		{"independent commands overlap", 0, 0, time.Second},
+		{"concurrency limit", 1, 1200 * time.Millisecond, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{config: &config.Config{Rituals: config.Rituals{Concurrency: tt.concurrency}}}
+
+			started := time.Now()
+			if err := engine.executeCommands(commands, "global"); err != nil {
+				t.Fatalf("executeCommands() error = %v", err)
+			}
+			if elapsed := time.Since(started); elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("took %v, want between %v and %v", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestExecuteGraph_DependenciesRunFirst(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	dir := t.TempDir()
+	engine := &Engine{config: &config.Config{}}
+	commands := []config.Command{
+		// Listed before its dependency on purpose
+		{ID: "migrate", Name: "migrate", Command: "test -f db && touch migrated", Dir: dir, DependsOn: []string{"db"}},
+		{ID: "db", Name: "db", Command: "sleep 0.2 && touch db", Dir: dir},
+	}
+
+	if err := engine.executeCommands(commands, "global"); err != nil {
+		t.Fatalf("executeCommands() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "migrated")); err != nil {
+		t.Error("migrate ran before the command it depends on")
+	}
+}
+
+func TestExecuteGraph_FailureSkipsDownstream(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	for _, optional := range []bool{false, true} {
+		dir := t.TempDir()
+		engine := &Engine{config: &config.Config{}}
+		commands := []config.Command{
+			{ID: "build", Name: "build", Command: "false", Optional: optional},
+			{ID: "test", Name: "test", Command: "touch tested", Dir: dir, DependsOn: []string{"build"}},
+			{ID: "deploy", Name: "deploy", Command: "touch deployed", Dir: dir, DependsOn: []string{"test"}},
+			{ID: "lint", Name: "lint", Command: "touch linted", Dir: dir},
+		}
+
+		err := engine.executeCommands(commands, "global")
+		if optional && err != nil {
+			t.Errorf("optional failure: executeCommands() error = %v", err)
+		}
+		if !optional && (err == nil || !strings.Contains(err.Error(), "command 'build' failed")) {
+			t.Errorf("required failure: executeCommands() error = %v", err)
+		}
+
+		for _, file := range []string{"tested", "deployed"} {
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				t.Errorf("optional=%v: %s ran although a dependency failed", optional, file)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "linted")); err != nil {
+			t.Errorf("optional=%v: independent command didn't run", optional)
+		}
+	}
+}
+
+func TestExecuteGraph_UnknownDependency(t *testing.T) {
+	engine := &Engine{config: &config.Config{}}
+	commands := []config.Command{
+		{ID: "serve", Name: "serve", Command: "true", DependsOn: []string{"missing"}},
+	}
+
+	err := engine.executeCommands(commands, "global")
+	if err == nil || !strings.Contains(err.Error(), "serve") {
+		t.Errorf("executeCommands() error = %v, want one naming the stuck command", err)
+	}
+}
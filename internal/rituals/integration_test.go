@@ -8,6 +8,8 @@ import (
 
 // TestInteractiveCommandIntegration tests that interactive commands are properly dispatched
 func TestInteractiveCommandIntegration(t *testing.T) {
+	isolateTmux(t)
+
 	cfg := &config.Config{
 		Rituals: config.Rituals{
 			Templates: map[string]config.TmuxTemplate{
@@ -17,7 +19,7 @@ func TestInteractiveCommandIntegration(t *testing.T) {
 						{
 							Name:   "main",
 							Layout: "main-vertical",
-							Panes:  []string{"echo 'Hello {{.Project}}'"},
+							Panes:  []config.TmuxPane{{Command: "echo 'Hello {{.Project}}'"}},
 						},
 					},
 				},
@@ -124,6 +126,7 @@ func TestBackwardsCompatibility(t *testing.T) {
 	})
 
 	t.Run("should handle background commands", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir()) // background commands are recorded under ~/.rune
 		cmd := config.Command{
 			Name:       "Test Background",
 			Command:    "sleep 0.1",
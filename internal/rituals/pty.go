@@ -1,12 +1,12 @@
 package rituals
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"os/signal"
-	"strings"
 	"syscall"
 
 	"github.com/creack/pty"
@@ -16,19 +16,13 @@ import (
 
 // executePTYCommand executes a command with direct PTY allocation for interactive terminal access
 func (e *Engine) executePTYCommand(cmd config.Command) error {
-	// Parse command and arguments
-	parts := strings.Fields(cmd.Command)
-	if len(parts) == 0 {
+	// Interactive commands run as long as the user keeps them open
+	execCmd, err := e.prepareCommand(context.Background(), cmd)
+	if err != nil {
 		fmt.Printf(" ❌\n")
-		return fmt.Errorf("empty command")
+		return err
 	}
 
-	// Create the command
-	execCmd := exec.Command(parts[0], parts[1:]...)
-
-	// Set up environment with filtered variables to avoid leaking secrets
-	execCmd.Env = filterEnvironment(os.Environ())
-
 	// Start the command with a pty
 	ptmx, err := pty.Start(execCmd)
 	if err != nil {
@@ -0,0 +1,72 @@
+package rituals
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// markCompleted remembers a command that finished, so its undo command can
+// run if the ritual fails later. Commands of a ritual graph finish
+// concurrently.
+func (e *Engine) markCompleted(cmd config.Command) {
+	if cmd.Undo == "" {
+		return
+	}
+	e.completedMu.Lock()
+	e.completed = append(e.completed, cmd)
+	e.completedMu.Unlock()
+}
+
+// undoCommand is the command that reverses cmd. It runs like cmd: with its
+// shell, directory, environment and timeout.
+func undoCommand(cmd config.Command) config.Command {
+	return config.Command{
+		Name:    "Undo " + cmd.Name,
+		Command: cmd.Undo,
+		Shell:   cmd.Shell,
+		Timeout: cmd.Timeout,
+		Dir:     cmd.Dir,
+		Env:     cmd.Env,
+		PassEnv: cmd.PassEnv,
+	}
+}
+
+// rollback runs the undo commands of the completed commands, most recent
+// first, and reports what was rolled back. A failing undo command doesn't
+// stop the others.
+func (e *Engine) rollback() {
+	e.completedMu.Lock()
+	completed := e.completed
+	e.completed = nil
+	e.completedMu.Unlock()
+
+	if len(completed) == 0 {
+		return
+	}
+
+	fmt.Printf("↩ Rolling back %d completed command(s)...\n", len(completed))
+
+	var rolledBack, failed []string
+	for i := len(completed) - 1; i >= 0; i-- {
+		cmd := completed[i]
+		undo := undoCommand(cmd)
+
+		fmt.Printf("  ↩ %s...", cmd.Name)
+		log := e.run.command(undo, "rollback")
+		if err := e.executeStandardCommand(os.Stdout, undo, log); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", cmd.Name, err))
+			continue
+		}
+		rolledBack = append(rolledBack, cmd.Name)
+	}
+
+	if len(rolledBack) > 0 {
+		fmt.Printf("↩ Rolled back: %s\n", strings.Join(rolledBack, ", "))
+	}
+	if len(failed) > 0 {
+		fmt.Printf("⚠ Could not roll back: %s\n", strings.Join(failed, ", "))
+	}
+}
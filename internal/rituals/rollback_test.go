@@ -0,0 +1,116 @@
+package rituals
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestExecuteStartRituals_RollsBackOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	commands := []config.Command{
+		{Name: "Start services", Command: "true", Undo: "echo services >> undone", Dir: dir},
+		{Name: "No undo", Command: "true"},
+		{Name: "Skipped", Command: "true", Undo: "echo skipped >> undone", Dir: dir, When: &config.When{OS: []string{"plan9"}}},
+		{Name: "Optional failure", Command: "false", Undo: "echo optional >> undone", Dir: dir, Optional: true},
+		{Name: "Broken undo", Command: "true", Undo: "exit 1"},
+		{Name: "Checkout branch", Command: "true", Undo: "echo branch >> undone", Dir: dir},
+	}
+	failing := config.Command{Name: "Migrate", Command: "false", Undo: "echo migrate >> undone", Dir: dir}
+
+	tests := []struct {
+		name    string
+		global  []config.Command
+		project []config.Command
+		wantErr bool
+		undone  string
+	}{
+		{"success doesn't roll back", commands, nil, false, ""},
+		{"failure rolls back in reverse", append(append([]config.Command{}, commands...), failing), nil, true, "branch\nservices\n"},
+		{"project failure rolls back global commands", commands, []config.Command{failing}, true, "branch\nservices\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(filepath.Join(dir, "undone"))
+			cfg := &config.Config{Rituals: config.Rituals{Start: config.RitualSet{
+				Global:     tt.global,
+				PerProject: map[string][]config.Command{"api": tt.project},
+			}}}
+
+			err := (&Engine{config: cfg}).ExecuteStartRituals("api")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecuteStartRituals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			data, _ := os.ReadFile(filepath.Join(dir, "undone"))
+			if string(data) != tt.undone {
+				t.Errorf("undo commands wrote %q, want %q", data, tt.undone)
+			}
+		})
+	}
+}
+
+func TestExecuteStartRituals_RollbackIsRecorded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &config.Config{Rituals: config.Rituals{Start: config.RitualSet{
+		Global: []config.Command{
+			{Name: "Create branch", Command: "true", Undo: "echo deleted"},
+			{Name: "Push", Command: "false"},
+		},
+	}}}
+	if err := (&Engine{config: cfg}).ExecuteStartRituals("api"); err == nil {
+		t.Fatal("expected the ritual to fail")
+	}
+
+	store, _ := NewRunStore()
+	run, err := store.Get("last")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(run.Commands) != 3 {
+		t.Fatalf("expected the undo command to be recorded, got %+v", run.Commands)
+	}
+	undo := run.Commands[2]
+	if undo.Name != "Undo Create branch" || undo.Scope != "rollback" || undo.Status != StatusSucceeded {
+		t.Errorf("unexpected record: %+v", undo)
+	}
+	if data, _ := os.ReadFile(store.Path(run.ID, undo.Stdout)); string(data) != "deleted\n" {
+		t.Errorf("undo stdout = %q", data)
+	}
+}
+
+func TestExecuteGraph_RollsBackOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	cfg := &config.Config{Rituals: config.Rituals{Start: config.RitualSet{
+		Global: []config.Command{
+			{ID: "db", Name: "db", Command: "true", Undo: "echo db >> undone", Dir: dir},
+			{ID: "migrate", Name: "migrate", Command: "false", DependsOn: []string{"db"}},
+			{ID: "seed", Name: "seed", Command: "true", Undo: "echo seed >> undone", Dir: dir, DependsOn: []string{"migrate"}},
+		},
+	}}}
+	if err := (&Engine{config: cfg}).ExecuteStartRituals("api"); err == nil {
+		t.Fatal("expected the ritual to fail")
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "undone")); string(data) != "db\n" {
+		t.Errorf("undo commands wrote %q, want only the completed db command undone", data)
+	}
+}
@@ -0,0 +1,399 @@
+package rituals
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// Command statuses recorded in a ritual run
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+	StatusStarted   = "started" // background command left running
+)
+
+// Run is the record of one ritual run: what ran, for how long and with
+// what result. Each run has a directory with the output of its commands.
+type Run struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Project   string          `json:"project,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+	Status    string          `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	Commands  []CommandRecord `json:"commands"`
+}
+
+// Duration returns how long the run took, or has taken so far
+func (r Run) Duration() time.Duration {
+	if r.EndedAt == nil {
+		return time.Since(r.StartedAt)
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// CommandRecord is the record of one command in a ritual run. Output files
+// are relative to the run's directory; a background command's log is
+// absolute.
+type CommandRecord struct {
+	Name      string        `json:"name"`
+	Scope     string        `json:"scope"`
+	Command   string        `json:"command"`
+	Status    string        `json:"status"`
+	Reason    string        `json:"reason,omitempty"` // why it was skipped or failed
+	ExitCode  int           `json:"exit_code"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Stdout    string        `json:"stdout,omitempty"`
+	Stderr    string        `json:"stderr,omitempty"`
+	Log       string        `json:"log,omitempty"`
+}
+
+// RunStore keeps ritual run logs in ~/.rune/runs, one directory per run
+type RunStore struct {
+	dir string
+}
+
+// NewRunStore creates a store in ~/.rune/runs
+func NewRunStore() (*RunStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".rune", "runs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create ritual run directory: %w", err)
+	}
+
+	return &RunStore{dir: dir}, nil
+}
+
+// Path returns the path of a file in a run's directory
+func (s *RunStore) Path(id, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(s.dir, id, file)
+}
+
+// List returns the recorded runs, newest first
+func (s *RunStore) List() ([]Run, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ritual run directory: %w", err)
+	}
+
+	var runs []Run
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		run, err := s.load(entry.Name())
+		if err != nil {
+			continue
+		}
+		runs = append(runs, *run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].StartedAt.Equal(runs[j].StartedAt) {
+			return runs[i].StartedAt.After(runs[j].StartedAt)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+	return runs, nil
+}
+
+// Get returns a run by ID, or the most recent one for "last"
+func (s *RunStore) Get(id string) (*Run, error) {
+	if id == "last" {
+		runs, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) == 0 {
+			return nil, fmt.Errorf("no ritual runs recorded")
+		}
+		return &runs[0], nil
+	}
+
+	if strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid run ID: %s", id)
+	}
+	run, err := s.load(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("ritual run not found: %s", id)
+	}
+	return run, err
+}
+
+// Prune removes runs beyond the newest keep and runs older than maxAge
+func (s *RunStore) Prune(keep int, maxAge time.Duration, now time.Time) error {
+	runs, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	for i, run := range runs {
+		if i < keep && now.Sub(run.StartedAt) <= maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, run.ID)); err != nil {
+			return fmt.Errorf("failed to remove ritual run %s: %w", run.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *RunStore) load(id string) (*Run, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, id, "run.json"))
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to read ritual run %s: %w", id, err)
+	}
+	return &run, nil
+}
+
+// create makes the directory of a new run. IDs are the start time and
+// ritual type, with a counter if two runs start in the same second.
+func (s *RunStore) create(ritualType string, started time.Time) (string, error) {
+	base := fmt.Sprintf("%s-%s", started.Format("20060102-150405"), ritualType)
+	id := base
+	for n := 2; ; n++ {
+		err := os.Mkdir(filepath.Join(s.dir, id), 0755)
+		if err == nil {
+			return id, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create ritual run directory: %w", err)
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// runLog records a ritual run as it happens. Commands of a ritual graph
+// finish concurrently, so the record is guarded by a mutex.
+type runLog struct {
+	store *RunStore
+	mu    sync.Mutex
+	run   Run
+}
+
+// beginRun starts recording a ritual run. Recording is best effort: when
+// the run can't be recorded the ritual still runs, with a warning.
+func (e *Engine) beginRun(ritualType, project string) {
+	store, err := NewRunStore()
+	if err == nil {
+		now := time.Now()
+		var id string
+		if id, err = store.create(ritualType, now); err == nil {
+			e.run = &runLog{store: store, run: Run{ID: id, Type: ritualType, Project: project, StartedAt: now, Status: "running"}}
+			e.run.save()
+			return
+		}
+	}
+	fmt.Printf("⚠ Ritual output won't be logged: %v\n", err)
+}
+
+// finishRun records the result of the run and applies retention
+func (e *Engine) finishRun(err error) {
+	log := e.run
+	e.run = nil
+	if log == nil {
+		return
+	}
+
+	log.mu.Lock()
+	now := time.Now()
+	log.run.EndedAt = &now
+	log.run.Status = StatusSucceeded
+	if err != nil {
+		log.run.Status = StatusFailed
+		log.run.Error = err.Error()
+	}
+	log.mu.Unlock()
+	log.save()
+
+	if err != nil {
+		fmt.Printf("💡 See the full output with 'rune ritual logs %s'\n", log.run.ID)
+	}
+
+	keep, maxAge := e.config.Rituals.History.Retention()
+	if err := log.store.Prune(keep, maxAge, now); err != nil {
+		fmt.Printf("⚠ Could not prune ritual logs: %v\n", err)
+	}
+}
+
+// save writes run.json; a failure only costs the record
+func (l *runLog) save() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if data, err := json.MarshalIndent(l.run, "", "  "); err == nil {
+		_ = os.WriteFile(l.store.Path(l.run.ID, "run.json"), data, 0644)
+	}
+}
+
+// add appends a command record and returns its index
+func (l *runLog) add(record CommandRecord) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.run.Commands = append(l.run.Commands, record)
+	return len(l.run.Commands) - 1
+}
+
+// update changes a command record
+func (l *runLog) update(index int, change func(*CommandRecord)) {
+	l.mu.Lock()
+	change(&l.run.Commands[index])
+	l.mu.Unlock()
+	l.save()
+}
+
+// skip records a command that didn't run
+func (l *runLog) skip(cmd config.Command, scope, reason string) {
+	if l == nil {
+		return
+	}
+	l.add(CommandRecord{
+		Name:      cmd.Name,
+		Scope:     scope,
+		Command:   cmd.CommandLine(),
+		Status:    StatusSkipped,
+		Reason:    reason,
+		StartedAt: time.Now(),
+	})
+	l.save()
+}
+
+// command starts recording a command that is about to run
+func (l *runLog) command(cmd config.Command, scope string) *commandLog {
+	if l == nil {
+		return nil
+	}
+
+	index := l.add(CommandRecord{
+		Name:      cmd.Name,
+		Scope:     scope,
+		Command:   cmd.CommandLine(),
+		StartedAt: time.Now(),
+	})
+	return &commandLog{run: l, index: index, name: cmd.Name, started: time.Now()}
+}
+
+// commandLog records one command of a ritual run
+type commandLog struct {
+	run     *runLog
+	index   int
+	name    string
+	started time.Time
+	files   []*os.File
+}
+
+// outputs returns the files a command's stdout and stderr are written to
+func (c *commandLog) outputs() (stdout, stderr io.Writer) {
+	if c == nil {
+		return io.Discard, io.Discard
+	}
+
+	base := fmt.Sprintf("%02d-%s", c.index+1, logSlug(c.name))
+	stdout, stdoutName := c.create(base + ".stdout")
+	stderr, stderrName := c.create(base + ".stderr")
+	c.run.update(c.index, func(r *CommandRecord) {
+		r.Stdout, r.Stderr = stdoutName, stderrName
+	})
+	return stdout, stderr
+}
+
+// create opens an output file in the run's directory
+func (c *commandLog) create(name string) (io.Writer, string) {
+	file, err := os.Create(c.run.store.Path(c.run.run.ID, name))
+	if err != nil {
+		return io.Discard, ""
+	}
+	c.files = append(c.files, file)
+	return file, name
+}
+
+// background records a command left running with its output in a log
+func (c *commandLog) background(logFile string) {
+	if c == nil {
+		return
+	}
+	c.run.update(c.index, func(r *CommandRecord) {
+		r.Status = StatusStarted
+		r.Log = logFile
+		r.Duration = time.Since(c.started)
+	})
+}
+
+// finish records how a command ended
+func (c *commandLog) finish(err error) {
+	if c == nil {
+		return
+	}
+	for _, file := range c.files {
+		file.Close()
+	}
+
+	c.run.update(c.index, func(r *CommandRecord) {
+		r.Duration = time.Since(c.started)
+		r.Status = StatusSucceeded
+		if err == nil {
+			return
+		}
+		r.Status = StatusFailed
+		r.Reason = err.Error()
+		r.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			r.ExitCode = exitErr.ExitCode()
+		}
+	})
+}
+
+// logSlug turns a command name into part of a file name
+func logSlug(name string) string {
+	slug := strings.Trim(unsafeLogChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return "command"
+	}
+	return slug
+}
+
+// syncBuffer is a buffer that a command's stdout and stderr can both write
+// to, which exec does from separate goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes returns what has been written
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
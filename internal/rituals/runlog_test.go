@@ -0,0 +1,131 @@
+package rituals
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestExecuteStartRituals_RecordsRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &config.Config{Rituals: config.Rituals{Start: config.RitualSet{
+		Global: []config.Command{
+			{Name: "Say hello", Command: "echo out; echo err >&2"},
+			{Name: "Never", Command: "true", When: &config.When{OS: []string{"plan9"}}},
+			{Name: "Exit three", Command: "exit 3", Optional: true},
+		},
+	}}}
+	engine := &Engine{config: cfg}
+	if err := engine.ExecuteStartRituals("api"); err != nil {
+		t.Fatalf("ExecuteStartRituals() error = %v", err)
+	}
+
+	store, err := NewRunStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := store.Get("last")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if run.Type != "start" || run.Project != "api" || run.Status != StatusSucceeded || run.EndedAt == nil {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if len(run.Commands) != 3 {
+		t.Fatalf("expected 3 recorded commands, got %+v", run.Commands)
+	}
+
+	hello, skipped, failed := run.Commands[0], run.Commands[1], run.Commands[2]
+	if hello.Status != StatusSucceeded || hello.ExitCode != 0 {
+		t.Errorf("unexpected record: %+v", hello)
+	}
+	for file, want := range map[string]string{hello.Stdout: "out\n", hello.Stderr: "err\n"} {
+		data, err := os.ReadFile(store.Path(run.ID, file))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q (%v), want %q", file, data, err, want)
+		}
+	}
+	if skipped.Status != StatusSkipped || !strings.Contains(skipped.Reason, "not on") {
+		t.Errorf("unexpected record: %+v", skipped)
+	}
+	if failed.Status != StatusFailed || failed.ExitCode != 3 {
+		t.Errorf("unexpected record: %+v", failed)
+	}
+}
+
+func TestExecuteStartRituals_RecordsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &config.Config{Rituals: config.Rituals{Start: config.RitualSet{
+		Global: []config.Command{{Name: "Pull", Command: "echo conflict >&2; exit 1"}},
+	}}}
+	if err := (&Engine{config: cfg}).ExecuteStartRituals("api"); err == nil {
+		t.Fatal("expected the ritual to fail")
+	}
+
+	store, _ := NewRunStore()
+	run, err := store.Get("last")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if run.Status != StatusFailed || !strings.Contains(run.Error, "command 'Pull' failed") {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if data, _ := os.ReadFile(store.Path(run.ID, run.Commands[0].Stderr)); string(data) != "conflict\n" {
+		t.Errorf("stderr = %q", data)
+	}
+}
+
+func TestRunStore_Prune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := NewRunStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
+	for _, daysAgo := range []int{0, 0, 1, 2, 40} {
+		started := now.AddDate(0, 0, -daysAgo)
+		id, err := store.create("start", started)
+		if err != nil {
+			t.Fatal(err)
+		}
+		(&runLog{store: store, run: Run{ID: id, Type: "start", StartedAt: started}}).save()
+	}
+
+	runs, _ := store.List()
+	if len(runs) != 5 || runs[0].ID != "20240310-090000-start-2" {
+		t.Fatalf("List() = %+v", runs)
+	}
+
+	if err := store.Prune(10, 30*24*time.Hour, now); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if runs, _ := store.List(); len(runs) != 4 {
+		t.Errorf("expected the 40-day-old run to be removed, %d left", len(runs))
+	}
+
+	if err := store.Prune(2, 30*24*time.Hour, now); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	runs, _ = store.List()
+	if len(runs) != 2 || runs[1].ID != "20240310-090000-start" {
+		t.Errorf("expected the 2 newest runs to be kept, got %+v", runs)
+	}
+
+	if _, err := store.Get("../runs"); err == nil {
+		t.Error("expected an error for a run ID with a path")
+	}
+}
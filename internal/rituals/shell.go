@@ -0,0 +1,58 @@
+package rituals
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// defaultShell is the shell command strings run through unless configured
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd /C"
+	}
+	return "sh -c"
+}
+
+// commandArgv returns the program and arguments a ritual command runs as.
+// Args are used as given; a command string is passed to the shell, or split
+// on whitespace when the shell is "none".
+func (e *Engine) commandArgv(cmd config.Command) ([]string, error) {
+	if len(cmd.Args) > 0 {
+		return cmd.Args, nil
+	}
+	if strings.TrimSpace(cmd.Command) == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	shell := e.config.Rituals.ShellFor(cmd)
+	if shell == "" {
+		shell = defaultShell()
+	}
+	if shell == config.NoShell {
+		return strings.Fields(cmd.Command), nil
+	}
+
+	argv := strings.Fields(shell)
+	if len(argv) == 1 {
+		// "shell: bash" means bash -c
+		argv = append(argv, shellFlag(argv[0]))
+	}
+	return append(argv, cmd.Command), nil
+}
+
+// shellFlag returns the flag that makes a shell run a command string
+func shellFlag(shell string) string {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(shell), ".exe"))
+	switch name {
+	case "cmd":
+		return "/C"
+	case "powershell", "pwsh":
+		return "-Command"
+	default:
+		return "-c"
+	}
+}
@@ -0,0 +1,104 @@
+package rituals
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+func TestCommandArgv(t *testing.T) {
+	tests := []struct {
+		name        string
+		globalShell string
+		cmd         config.Command
+		want        []string
+	}{
+		{
+			name: "default shell",
+			cmd:  config.Command{Command: "git add -A && git commit -m 'WIP: End of day'"},
+			want: append(strings.Fields(defaultShell()), "git add -A && git commit -m 'WIP: End of day'"),
+		},
+		{
+			name:        "global shell",
+			globalShell: "bash -lc",
+			cmd:         config.Command{Command: "echo $HOME"},
+			want:        []string{"bash", "-lc", "echo $HOME"},
+		},
+		{
+			name:        "command shell overrides global",
+			globalShell: "bash -lc",
+			cmd:         config.Command{Command: "echo hi", Shell: "zsh"},
+			want:        []string{"zsh", "-c", "echo hi"},
+		},
+		{
+			name: "no shell",
+			cmd:  config.Command{Command: "git  pull --rebase", Shell: config.NoShell},
+			want: []string{"git", "pull", "--rebase"},
+		},
+		{
+			name:        "args",
+			globalShell: "bash -c",
+			cmd:         config.Command{Args: []string{"git", "commit", "-m", "WIP: End of day"}},
+			want:        []string{"git", "commit", "-m", "WIP: End of day"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{config: &config.Config{Rituals: config.Rituals{Shell: tt.globalShell}}}
+			got, err := engine.commandArgv(tt.cmd)
+			if err != nil {
+				t.Fatalf("commandArgv() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commandArgv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	engine := &Engine{config: &config.Config{}}
+	if _, err := engine.commandArgv(config.Command{Command: "  "}); err == nil {
+		t.Error("expected an error for an empty command")
+	}
+}
+
+func TestShellFlag(t *testing.T) {
+	for shell, want := range map[string]string{
+		"bash":         "-c",
+		"/usr/bin/zsh": "-c",
+		"cmd.exe":      "/C",
+		"pwsh":         "-Command",
+	} {
+		if got := shellFlag(shell); got != want {
+			t.Errorf("shellFlag(%q) = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+func TestExecuteStandardCommand_Shell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	engine := &Engine{config: &config.Config{}}
+	cmd := config.Command{Name: "pipeline", Command: "echo one && echo 'two words' | tr a-z A-Z > " + out}
+
+	if err := engine.executeStandardCommand(io.Discard, cmd, nil); err != nil {
+		t.Fatalf("executeStandardCommand() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected the shell to redirect output: %v", err)
+	}
+	if string(data) != "TWO WORDS\n" {
+		t.Errorf("output = %q, want %q", data, "TWO WORDS\n")
+	}
+}
@@ -1,17 +1,22 @@
 package rituals
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tmux"
+	"golang.org/x/term"
 )
 
 // executeTmuxCommand executes a command using tmux session management
 func (e *Engine) executeTmuxCommand(cmd config.Command, scope string) error {
 	if e.tmuxClient == nil {
 		fmt.Printf(" ⚠ (tmux not available, falling back to standard execution)\n")
-		return e.executeStandardCommand(cmd)
+		return e.executeStandardCommand(os.Stdout, cmd, nil)
 	}
 
 	// Prepare template variables
@@ -50,8 +55,7 @@ func (e *Engine) executeTemplateCommand(cmd config.Command, variables map[string
 		// If session already exists, try to attach to it instead
 		if strings.Contains(err.Error(), "already exists") {
 			sessionName := e.expandTemplate(template.SessionName, variables)
-			fmt.Printf(" 📺 (attaching to existing session '%s')\n", sessionName)
-			return e.tmuxClient.AttachSession(sessionName)
+			return e.attachSession(cmd, sessionName, false)
 		}
 		fmt.Printf(" ❌\n")
 		return fmt.Errorf("failed to create session from template: %w", err)
@@ -59,82 +63,172 @@ func (e *Engine) executeTemplateCommand(cmd config.Command, variables map[string
 
 	// Save session state for persistence
 	sessionName := e.expandTemplate(template.SessionName, variables)
-	err = e.tmuxClient.SaveSessionState(sessionName, cmd.TmuxTemplate, variables["Project"], variables)
+	err = e.tmuxClient.SaveSessionState(sessionName, cmd.TmuxTemplate, e.project, variables)
 	if err != nil {
 		// Log but don't fail - persistence is optional
-		fmt.Printf(" ⚠ (failed to save session state: %v)\n", err)
+		fmt.Printf(" ⚠ (failed to save session state: %v)", err)
 	}
 
-	// Attach to the newly created session
-	fmt.Printf(" 📺 (created and attaching to session '%s')\n", sessionName)
-	return e.tmuxClient.AttachSession(sessionName)
+	return e.attachSession(cmd, sessionName, true)
 }
 
-// executeSessionCommand manages a direct tmux session
+// executeSessionCommand runs a command in a pane of a tmux session and
+// attaches to it, as the command's attach policy says. A missing session is
+// created and the command runs in its first pane, or the pane tmux_window
+// and tmux_pane pick. An existing session is only attached to, unless the
+// command targets a window or pane.
 func (e *Engine) executeSessionCommand(cmd config.Command, variables map[string]string) error {
 	sessionName := e.expandTemplate(cmd.TmuxSession, variables)
+	existed := e.tmuxClient.SessionExists(sessionName)
 
-	// Check if session exists
-	if e.tmuxClient.SessionExists(sessionName) {
-		fmt.Printf(" 📺 (attaching to existing session '%s')\n", sessionName)
-		return e.tmuxClient.AttachSession(sessionName)
+	if existed && cmd.TmuxWindow == "" && cmd.TmuxPane == 0 {
+		return e.attachSession(cmd, sessionName, false)
 	}
 
-	// Create new session
-	err := e.tmuxClient.CreateSession(sessionName)
+	dir, err := e.commandDir(cmd)
 	if err != nil {
 		fmt.Printf(" ❌\n")
-		return fmt.Errorf("failed to create session '%s': %w", sessionName, err)
-	}
-
-	// Run the command in the new session if specified
-	if cmd.Command != "" {
-		// Parse and send the command to the session
-		_ = e.expandTemplate(cmd.Command, variables) // TODO: Send command to session
-
-		// Get the session and send the command
-		// Note: This is a simplified approach. The gotmux library might need
-		// more sophisticated command sending for complex commands.
-		sessions, err := e.tmuxClient.ListSessions()
-		if err != nil {
-			fmt.Printf(" ⚠ (session created but failed to send command: %v)\n", err)
-		} else {
-			// Find our session and send the command
-			for _, session := range sessions {
-				if session == sessionName {
-					// For now, we'll create the session and let the user manually run commands
-					// A more sophisticated implementation would send the command to the session
-					break
-				}
-			}
+		return err
+	}
+
+	target := tmux.PaneTarget{
+		Session: sessionName,
+		Window:  e.expandTemplate(cmd.TmuxWindow, variables),
+		Pane:    cmd.TmuxPane,
+	}
+	pane, created, err := e.tmuxClient.PreparePane(target, dir)
+	if err != nil {
+		fmt.Printf(" ❌\n")
+		return err
+	}
+
+	// Save session state, so Rune knows it created the session
+	if !existed {
+		if err := e.tmuxClient.SaveSessionState(sessionName, "", e.project, variables); err != nil {
+			fmt.Printf(" ⚠ (failed to save session state: %v)", err)
 		}
 	}
 
-	fmt.Printf(" 📺 (created and attaching to session '%s')\n", sessionName)
-	return e.tmuxClient.AttachSession(sessionName)
+	if err := e.sendSessionCommand(cmd, pane, !created, variables); err != nil {
+		fmt.Printf(" ❌\n")
+		return fmt.Errorf("command in session '%s' failed: %w", sessionName, err)
+	}
+
+	return e.attachSession(cmd, sessionName, !existed)
 }
 
-// cleanupSessions cleans up orphaned tmux sessions created by Rune
-// Currently unused but kept for future cleanup functionality
-func (e *Engine) cleanupSessions() error { //nolint:unused
-	if e.tmuxClient == nil {
+// sendSessionCommand types a command into a pane and waits for wait_for to
+// match its output. A command isn't typed into a pane that was already
+// there and is busy running something else.
+func (e *Engine) sendSessionCommand(cmd config.Command, pane string, checkBusy bool, variables map[string]string) error {
+	command := e.expandTemplate(cmd.CommandLine(), variables)
+	if command == "" {
 		return nil
 	}
 
-	sessions, err := e.tmuxClient.ListSessions()
+	if checkBusy {
+		if running, err := e.tmuxClient.PaneCommand(pane); err == nil && running != "" {
+			fmt.Printf(" ⚠ (pane is busy running %s, command not sent)", running)
+			return nil
+		}
+	}
+
+	if cmd.WaitFor != "" {
+		if err := e.tmuxClient.KeepPaneOnExit(pane); err != nil {
+			return err
+		}
+	}
+
+	if cmd.WaitFor == "" {
+		return e.tmuxClient.SendCommand(pane, command)
+	}
+	sent, err := e.tmuxClient.StartCommand(pane, command)
 	if err != nil {
 		return err
 	}
 
-	// Clean up sessions that match Rune naming patterns
-	// This is a basic implementation - could be more sophisticated
-	for _, sessionName := range sessions {
-		if strings.HasPrefix(sessionName, "rune-") {
-			// Only kill sessions that appear to be orphaned
-			// This is a placeholder for more sophisticated cleanup logic
-			_ = e.tmuxClient.KillSession(sessionName)
-		}
+	// The pattern was checked when the configuration loaded
+	pattern, err := regexp.Compile(cmd.WaitFor)
+	if err != nil {
+		return fmt.Errorf("invalid wait_for pattern: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout(cmd))
+	defer cancel()
+	if err := sent.WaitFor(ctx, pattern); err != nil {
+		return err
+	}
+	fmt.Printf(" ✓ (ready)")
+	return nil
+}
+
+// attachSession takes the user to a session a ritual prepared, as the
+// command's attach policy says. Attaching blocks until the user detaches,
+// so attach-last leaves it until every ritual has run. Rune can't attach
+// without a terminal, and switches the client instead of nesting tmux.
+func (e *Engine) attachSession(cmd config.Command, sessionName string, created bool) error {
+	status := fmt.Sprintf(" 📺 (session '%s' exists", sessionName)
+	if created {
+		status = fmt.Sprintf(" 📺 (created session '%s'", sessionName)
+	}
+
+	policy := e.config.Rituals.AttachFor(cmd)
+	inTmux := tmux.IsInTmuxSession()
+	switch {
+	case policy == config.AttachLast:
+		e.attachMu.Lock()
+		e.attachLast = sessionName
+		e.attachMu.Unlock()
+		fmt.Printf("%s, attaching when the rituals finish)\n", status)
+		return nil
+	case policy == config.AttachDetached, policy == config.AttachSwitch && !inTmux:
+		fmt.Printf("%s, left detached)\n", status)
+	case inTmux:
+		fmt.Printf("%s, switching to it)\n", status)
+		return e.tmuxClient.SwitchClient(sessionName)
+	case !stdinIsTerminal():
+		fmt.Printf("%s, not attaching without a terminal)\n", status)
+	default:
+		fmt.Printf("%s, attaching)\n", status)
+		return e.tmuxClient.AttachSession(sessionName)
 	}
 
+	fmt.Printf("    💡 Attach with 'rune tmux attach %s'\n", sessionName)
 	return nil
 }
+
+// attachDeferred attaches to the session an attach-last ritual prepared,
+// now that the rituals have run; with several, the last one to finish.
+// After a failed ritual it only says how to attach, if the session is
+// still there.
+func (e *Engine) attachDeferred(err error) {
+	e.attachMu.Lock()
+	sessionName := e.attachLast
+	e.attachLast = ""
+	e.attachMu.Unlock()
+	if sessionName == "" || e.tmuxClient == nil {
+		return
+	}
+
+	inTmux := tmux.IsInTmuxSession()
+	if err != nil || (!inTmux && !stdinIsTerminal()) {
+		if e.tmuxClient.SessionExists(sessionName) {
+			fmt.Printf("💡 Attach to session '%s' with 'rune tmux attach %s'\n", sessionName, sessionName)
+		}
+		return
+	}
+
+	attach := e.tmuxClient.AttachSession
+	if inTmux {
+		attach = e.tmuxClient.SwitchClient
+	}
+	fmt.Printf("📺 Attaching to session '%s'\n", sessionName)
+	if err := attach(sessionName); err != nil {
+		fmt.Printf("⚠ Could not attach to session '%s': %v\n", sessionName, err)
+	}
+}
+
+// stdinIsTerminal reports whether Rune's input is a terminal, which
+// attaching to a session needs
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
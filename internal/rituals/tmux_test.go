@@ -0,0 +1,122 @@
+package rituals
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tmux"
+)
+
+// isolateTmux points tmux at a server of the test's own, run outside
+// tmux, so tests don't touch the user's sessions or each other's, and
+// kills it when the test ends. HOME, where session state is saved, is in
+// the same directory, and the server's shells keep no history there that
+// they could write as they exit, while the directory is being removed.
+// Socket paths are limited in length, so the directory is a short one
+// rather than t.TempDir().
+func isolateTmux(t *testing.T) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "rune-tmux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := filepath.Join(dir, "home")
+	if err := os.Mkdir(home, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("HISTFILE", "")
+	t.Setenv("TMUX_TMPDIR", dir)
+	t.Setenv("TMUX", "")
+	t.Cleanup(func() {
+		_ = exec.Command("tmux", "kill-server").Run()
+		_ = os.RemoveAll(dir)
+	})
+}
+
+// newTmuxEngine returns an engine with a tmux client on a private server
+func newTmuxEngine(t *testing.T, cfg *config.Config) *Engine {
+	t.Helper()
+	if !tmux.IsAvailable() {
+		t.Skip("tmux not available, skipping tmux ritual tests")
+	}
+	isolateTmux(t)
+
+	e := NewEngine(cfg)
+	if e.tmuxClient == nil {
+		t.Skip("tmux client not available")
+	}
+	return e
+}
+
+func TestExecuteSessionCommand_WaitFor(t *testing.T) {
+	e := newTmuxEngine(t, &config.Config{Rituals: config.Rituals{Attach: config.AttachDetached}})
+
+	// The shell can take a while to start, so the timeouts are generous
+	ready := config.Command{
+		Name:        "Server",
+		Command:     "echo serving on $((4000+1))",
+		Interactive: true,
+		TmuxSession: "rune-ritual-ready-test",
+		WaitFor:     `serving on 4001`,
+		Timeout:     20 * time.Second,
+	}
+	if err := e.executeTmuxCommand(ready, "api"); err != nil {
+		t.Fatalf("executeTmuxCommand() error = %v", err)
+	}
+	if !e.tmuxClient.SessionExists("rune-ritual-ready-test") {
+		t.Error("session was not created")
+	}
+
+	exits := config.Command{
+		Name:        "Crashing server",
+		Command:     "sh -c 'exit 2'",
+		Interactive: true,
+		TmuxSession: "rune-ritual-exit-test",
+		WaitFor:     `serving`,
+		Timeout:     20 * time.Second,
+	}
+	err := e.executeTmuxCommand(exits, "api")
+	if err == nil || !strings.Contains(err.Error(), "command exited with status 2") {
+		t.Errorf("executeTmuxCommand() error = %v, want the command's exit status", err)
+	}
+}
+
+func TestExecuteSessionCommand_AttachLast(t *testing.T) {
+	e := newTmuxEngine(t, &config.Config{})
+
+	for _, name := range []string{"rune-ritual-first-test", "rune-ritual-last-test"} {
+		cmd := config.Command{
+			Name:        name,
+			Command:     "true",
+			Interactive: true,
+			TmuxSession: name,
+			Attach:      config.AttachLast,
+		}
+		if err := e.executeTmuxCommand(cmd, "api"); err != nil {
+			t.Fatalf("executeTmuxCommand(%s) error = %v", name, err)
+		}
+	}
+
+	// Both sessions are prepared; the last one is attached to at the end
+	for _, name := range []string{"rune-ritual-first-test", "rune-ritual-last-test"} {
+		if !e.tmuxClient.SessionExists(name) {
+			t.Errorf("session %s was not created", name)
+		}
+	}
+	if e.attachLast != "rune-ritual-last-test" {
+		t.Errorf("attachLast = %q, want rune-ritual-last-test", e.attachLast)
+	}
+
+	// A failed ritual doesn't attach
+	e.attachDeferred(errors.New("ritual failed"))
+	if e.attachLast != "" {
+		t.Errorf("attachLast = %q after the rituals finished, want none", e.attachLast)
+	}
+}
@@ -4,6 +4,9 @@ package tmux
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -116,6 +119,7 @@ func (c *Client) AttachSession(sessionName string) error {
 			if err != nil {
 				return fmt.Errorf("failed to attach to session '%s': %w", sessionName, err)
 			}
+			c.touchSession(sessionName)
 			return nil
 		}
 	}
@@ -123,22 +127,37 @@ func (c *Client) AttachSession(sessionName string) error {
 	return fmt.Errorf("session '%s' not found", sessionName)
 }
 
+// SwitchClient switches the tmux client Rune runs in to another session,
+// for when attaching would nest tmux
+func (c *Client) SwitchClient(sessionName string) error {
+	if !c.SessionExists(sessionName) {
+		return fmt.Errorf("session '%s' does not exist", sessionName)
+	}
+
+	if err := c.tmux.SwitchClient(&gotmux.SwitchClientOptions{TargetSession: "=" + sessionName}); err != nil {
+		return fmt.Errorf("failed to switch to session '%s': %w", sessionName, err)
+	}
+	c.touchSession(sessionName)
+	return nil
+}
+
 // CreateFromTemplate creates a tmux session from a template configuration.
 // This includes creating windows, panes, and running initial commands.
 func (c *Client) CreateFromTemplate(template *config.TmuxTemplate, variables map[string]string) error {
-	// Replace variables in session name
-	sessionName := template.SessionName
-	for key, value := range variables {
-		sessionName = strings.ReplaceAll(sessionName, "{{."+key+"}}", value)
-	}
+	sessionName := c.replaceVariables(template.SessionName, variables)
 
 	// Create the session
 	if c.SessionExists(sessionName) {
 		return fmt.Errorf("session '%s' already exists", sessionName)
 	}
 
+	var startDir string
+	if len(template.Windows) > 0 {
+		startDir = c.templateStartDir(template.Windows[0], variables)
+	}
 	session, err := c.tmux.NewSession(&gotmux.SessionOptions{
-		Name: sessionName,
+		Name:           sessionName,
+		StartDirectory: startDir,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create session from template: %w", err)
@@ -150,52 +169,28 @@ func (c *Client) CreateFromTemplate(template *config.TmuxTemplate, variables map
 
 		if i == 0 {
 			// Get the default window and rename it
-			tmuxWindow, err = session.GetWindowByIndex(0)
-			if err != nil {
-				return fmt.Errorf("failed to get first window: %w", err)
+			windows, err := session.ListWindows()
+			if err != nil || len(windows) == 0 {
+				return fmt.Errorf("failed to get first window")
 			}
+			tmuxWindow = windows[0]
 			err = tmuxWindow.Rename(window.Name)
 			if err != nil {
 				return fmt.Errorf("failed to rename first window: %w", err)
 			}
 		} else {
 			// Create new window
-			tmuxWindow, err = session.New()
+			tmuxWindow, err = session.NewWindow(&gotmux.NewWindowOptions{
+				WindowName:     window.Name,
+				StartDirectory: c.templateStartDir(window, variables),
+			})
 			if err != nil {
 				return fmt.Errorf("failed to create window '%s': %w", window.Name, err)
 			}
-			err = tmuxWindow.Rename(window.Name)
-			if err != nil {
-				return fmt.Errorf("failed to rename window '%s': %w", window.Name, err)
-			}
 		}
 
-		// Set layout if specified
-		if window.Layout != "" {
-			// Convert string layout to gotmux layout type
-			var layout gotmux.WindowLayout
-			switch window.Layout {
-			case "even-horizontal":
-				layout = gotmux.WindowLayoutEvenHorizontal
-			case "even-vertical":
-				layout = gotmux.WindowLayoutEvenVertical
-			case "main-horizontal":
-				layout = gotmux.WindowLayoutMainVertical // Note: gotmux only has MainVertical
-			case "main-vertical":
-				layout = gotmux.WindowLayoutMainVertical
-			case "tiled":
-				layout = gotmux.WindowLayoutTiled
-			default:
-				return fmt.Errorf("unsupported layout '%s' for window '%s'", window.Layout, window.Name)
-			}
-			err = tmuxWindow.SelectLayout(layout)
-			if err != nil {
-				return fmt.Errorf("failed to set layout '%s' for window '%s': %w", window.Layout, window.Name, err)
-			}
-		}
-
-		// Create panes and run commands
-		if err := c.createPanes(tmuxWindow, window.Panes, variables); err != nil {
+		// Create panes, apply the layout and run commands
+		if err := c.createPanes(tmuxWindow, window, variables); err != nil {
 			return fmt.Errorf("failed to create panes for window '%s': %w", window.Name, err)
 		}
 	}
@@ -203,67 +198,62 @@ func (c *Client) CreateFromTemplate(template *config.TmuxTemplate, variables map
 	return nil
 }
 
-// createPanes creates panes in a window and runs the specified commands.
-func (c *Client) createPanes(window *gotmux.Window, panes []string, variables map[string]string) error {
-	if len(panes) == 0 {
-		return nil
-	}
-
-	// Get the first pane (should exist by default)
+// createPanes splits a window into the template's panes, applies its
+// layout and runs each pane's command. Each pane after the first splits an
+// earlier one, given by its position in the template.
+func (c *Client) createPanes(window *gotmux.Window, spec config.TmuxWindow, variables map[string]string) error {
+	// The first pane exists with the window
 	windowPanes, err := window.ListPanes()
 	if err != nil {
 		return fmt.Errorf("failed to get window panes: %w", err)
 	}
-
 	if len(windowPanes) == 0 {
 		return fmt.Errorf("no panes found in window")
 	}
 
-	// Run command in first pane if specified
-	if len(panes) > 0 {
-		command := c.replaceVariables(panes[0], variables)
-		if command != "" {
-			err = windowPanes[0].SendKeys(command)
-			if err != nil {
-				return fmt.Errorf("failed to send command to first pane: %w", err)
-			}
+	ids := []string{windowPanes[0].Id}
+	for i := 1; i < len(spec.Panes); i++ {
+		pane := spec.Panes[i]
+		if pane.Split < 0 || pane.Split >= i {
+			return fmt.Errorf("pane %d splits pane %d, which doesn't exist yet", i, pane.Split)
 		}
-	}
 
-	// Create additional panes and run commands
-	for i := 1; i < len(panes); i++ {
-		// Split the first pane to create a new pane
-		// Alternate between horizontal and vertical splits
-		var splitOptions *gotmux.SplitWindowOptions
-		if i%2 == 0 {
-			splitOptions = &gotmux.SplitWindowOptions{
-				SplitDirection: gotmux.PaneSplitDirectionHorizontal,
-			}
-		} else {
-			splitOptions = &gotmux.SplitWindowOptions{
-				SplitDirection: gotmux.PaneSplitDirectionVertical,
-			}
+		args := []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-t", ids[pane.Split]}
+		args = append(args, splitFlags(pane.Direction, i)...)
+		if pane.Size > 0 {
+			args = append(args, "-l", fmt.Sprintf("%d%%", pane.Size))
 		}
-		err := windowPanes[0].SplitWindow(splitOptions)
-		if err != nil {
-			return fmt.Errorf("failed to split pane: %w", err)
+		if dir := c.paneDir(pane, variables); dir != "" {
+			args = append(args, "-c", dir)
 		}
 
-		// Refresh pane list to get the new pane
-		windowPanes, err = window.ListPanes()
+		out, err := c.tmux.Command(args...)
 		if err != nil {
-			return fmt.Errorf("failed to refresh pane list: %w", err)
+			return fmt.Errorf("failed to split pane %d: %w", pane.Split, err)
 		}
+		ids = append(ids, strings.TrimSpace(out))
+	}
+
+	// Presets and layout strings both go to select-layout; apply the
+	// layout once every pane exists
+	if spec.Layout != "" {
+		if err := window.SelectLayout(gotmux.WindowLayout(spec.Layout)); err != nil {
+			return fmt.Errorf("failed to set layout '%s': %w", spec.Layout, err)
+		}
+	}
 
-		// Use the last pane (newest one) for the command
-		if len(windowPanes) > i {
-			newPane := windowPanes[len(windowPanes)-1]
-			command := c.replaceVariables(panes[i], variables)
-			if command != "" {
-				err = newPane.SendKeys(command)
-				if err != nil {
-					return fmt.Errorf("failed to send command to pane: %w", err)
-				}
+	for i, pane := range spec.Panes {
+		if command := c.replaceVariables(pane.Command, variables); command != "" {
+			if err := c.runInPane(ids[i], command); err != nil {
+				return err
+			}
+		}
+		if pane.Focus {
+			if _, err := c.tmux.Command("select-pane", "-t", ids[i]); err != nil {
+				return fmt.Errorf("failed to focus pane %d: %w", i, err)
+			}
+			if err := window.Select(); err != nil {
+				return fmt.Errorf("failed to select window: %w", err)
 			}
 		}
 	}
@@ -271,6 +261,47 @@ func (c *Client) createPanes(window *gotmux.Window, panes []string, variables ma
 	return nil
 }
 
+// splitFlags returns the split-window flags for a pane direction. Panes
+// without a direction alternate below and to the right, by position.
+func splitFlags(direction string, index int) []string {
+	switch direction {
+	case "right":
+		return []string{"-h"}
+	case "left":
+		return []string{"-h", "-b"}
+	case "below":
+		return []string{"-v"}
+	case "above":
+		return []string{"-v", "-b"}
+	}
+	if index%2 == 1 {
+		return []string{"-v"}
+	}
+	return []string{"-h"}
+}
+
+// paneDir returns a pane's working directory with variables and ~ expanded
+func (c *Client) paneDir(pane config.TmuxPane, variables map[string]string) string {
+	dir := c.replaceVariables(pane.Dir, variables)
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~"))
+}
+
+// templateStartDir returns the working directory of a template window's first
+// pane, which the window is created in
+func (c *Client) templateStartDir(window config.TmuxWindow, variables map[string]string) string {
+	if len(window.Panes) == 0 {
+		return ""
+	}
+	return c.paneDir(window.Panes[0], variables)
+}
+
 // replaceVariables replaces template variables in a command string.
 func (c *Client) replaceVariables(command string, variables map[string]string) string {
 	result := command
@@ -295,30 +326,129 @@ func (c *Client) ListSessions() ([]string, error) {
 	return sessionNames, nil
 }
 
-// SaveSessionState persists the current state of a session
+// Session options that mark the sessions Rune created
+const (
+	managedOption = "@rune_managed"
+	projectOption = "@rune_project"
+)
+
+// SessionInfo describes a live tmux session
+type SessionInfo struct {
+	Name     string
+	Managed  bool   // created by Rune
+	Project  string // project of the ritual that created it
+	Attached bool
+	Windows  int
+}
+
+// Sessions returns the live tmux sessions, with the ones Rune created
+// marked as managed.
+func (c *Client) Sessions() ([]SessionInfo, error) {
+	format := strings.Join([]string{
+		"#{session_name}", "#{session_attached}", "#{session_windows}",
+		"#{" + managedOption + "}", "#{" + projectOption + "}",
+	}, "\t")
+	out, err := c.tmux.Command("list-sessions", "-F", format)
+	if err != nil {
+		// tmux fails to list sessions when no server is running
+		return nil, nil
+	}
+
+	var sessions []SessionInfo
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			continue
+		}
+		windows, _ := strconv.Atoi(fields[2])
+		sessions = append(sessions, SessionInfo{
+			Name:     fields[0],
+			Attached: fields[1] != "0",
+			Windows:  windows,
+			Managed:  fields[3] != "",
+			Project:  fields[4],
+		})
+	}
+	return sessions, nil
+}
+
+// MarkSession records on a live session that Rune created it, and for
+// which project
+func (c *Client) MarkSession(sessionName, project string) error {
+	target := "=" + sessionName + ":"
+	if err := c.tmux.SetOption(target, managedOption, "1", ""); err != nil {
+		return fmt.Errorf("failed to mark session '%s': %w", sessionName, err)
+	}
+	if project == "" {
+		return nil
+	}
+	if err := c.tmux.SetOption(target, projectOption, project, ""); err != nil {
+		return fmt.Errorf("failed to mark session '%s': %w", sessionName, err)
+	}
+	return nil
+}
+
+// SaveSessionState marks a session Rune created and persists its state: the
+// template and variables it was created from and a snapshot of its windows
+// and panes.
 func (c *Client) SaveSessionState(sessionName, template, project string, variables map[string]string) error {
 	if c.persistence == nil {
 		return fmt.Errorf("session persistence not initialized")
 	}
 
+	if err := c.MarkSession(sessionName, project); err != nil {
+		return err
+	}
+
+	windows, err := c.snapshotWindows(sessionName)
+	if err != nil {
+		return err
+	}
+
 	state := &SessionState{
 		Name:      sessionName,
 		Template:  template,
 		Variables: variables,
 		CreatedAt: time.Now(),
 		Project:   project,
+		Windows:   windows,
 	}
 
 	return c.persistence.SaveSession(state)
 }
 
-// RestoreSession attempts to restore a session from persisted state
-func (c *Client) RestoreSession(sessionName string) error {
+// SnapshotSession records the current windows, panes and layouts of a
+// session, keeping the template, variables and project it was saved with.
+func (c *Client) SnapshotSession(sessionName string) (*SessionState, error) {
+	if c.persistence == nil {
+		return nil, fmt.Errorf("session persistence not initialized")
+	}
+
+	windows, err := c.snapshotWindows(sessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.persistence.LoadSession(sessionName)
+	if err != nil {
+		state = &SessionState{Name: sessionName, CreatedAt: time.Now()}
+	}
+	state.Windows = windows
+
+	if err := c.persistence.SaveSession(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// RestoreSession recreates a session from persisted state, using the
+// recorded template when it is one of templates
+func (c *Client) RestoreSession(sessionName string, templates map[string]config.TmuxTemplate) error {
 	if c.persistence == nil {
 		return fmt.Errorf("session persistence not initialized")
 	}
 
-	return c.persistence.RestoreSession(c, sessionName)
+	return c.persistence.RestoreSession(c, sessionName, templates)
 }
 
 // ListPersistedSessions returns all sessions with saved state
@@ -338,3 +468,87 @@ func (c *Client) CleanupStaleSessions() error {
 
 	return c.persistence.CleanupStaleSessions(c)
 }
+
+// LoadSessionState returns the persisted state of a session
+func (c *Client) LoadSessionState(sessionName string) (*SessionState, error) {
+	if c.persistence == nil {
+		return nil, fmt.Errorf("session persistence not initialized")
+	}
+
+	return c.persistence.LoadSession(sessionName)
+}
+
+// DeleteSessionState forgets the persisted state of a session
+func (c *Client) DeleteSessionState(sessionName string) error {
+	if c.persistence == nil {
+		return fmt.Errorf("session persistence not initialized")
+	}
+
+	return c.persistence.DeleteSession(sessionName)
+}
+
+// touchSession records activity in a session's persisted state, if it has
+// one. Attaching counts as activity, so sessions in use aren't pruned.
+func (c *Client) touchSession(sessionName string) {
+	if c.persistence == nil {
+		return
+	}
+	if state, err := c.persistence.LoadSession(sessionName); err == nil {
+		_ = c.persistence.SaveSession(state)
+	}
+}
+
+// PruneSessions kills orphaned sessions Rune created and forgets the saved
+// state of sessions that no longer exist. A session is orphaned when nobody
+// is attached to it and its saved state shows no activity within maxAge;
+// sessions without saved state are left alone. Only sessions marked as
+// Rune's or named rune-* are considered. It returns the killed sessions.
+func (c *Client) PruneSessions(maxAge time.Duration) ([]string, error) {
+	if c.persistence == nil {
+		return nil, fmt.Errorf("session persistence not initialized")
+	}
+
+	sessions, err := c.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	running := make(map[string]bool)
+	var killed []string
+	for _, session := range sessions {
+		running[session.Name] = true
+		if session.Attached || !(session.Managed || strings.HasPrefix(session.Name, "rune-")) {
+			continue
+		}
+		state, err := c.persistence.LoadSession(session.Name)
+		if err != nil || !state.Stale(maxAge, now) {
+			continue
+		}
+		if err := c.KillSession(session.Name); err != nil {
+			return killed, err
+		}
+		killed = append(killed, session.Name)
+		running[session.Name] = false
+	}
+
+	// Forget the killed sessions and saved sessions that are just as stale;
+	// fresher ones are kept to be restored
+	saved, err := c.persistence.ListPersistedSessions()
+	if err != nil {
+		return killed, err
+	}
+	for _, name := range saved {
+		if running[name] {
+			continue
+		}
+		state, err := c.persistence.LoadSession(name)
+		if err != nil || !state.Stale(maxAge, now) {
+			continue
+		}
+		if err := c.persistence.DeleteSession(name); err != nil {
+			return killed, err
+		}
+	}
+	return killed, nil
+}
@@ -1,6 +1,9 @@
 package tmux
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ferg-cod3s/rune/internal/config"
@@ -90,6 +93,7 @@ func TestSessionManagement(t *testing.T) {
 	if !IsAvailable() {
 		t.Skip("tmux not available, skipping session management tests")
 	}
+	isolateServer(t)
 
 	client, err := NewClient()
 	require.NoError(t, err)
@@ -147,6 +151,7 @@ func TestCreateFromTemplate(t *testing.T) {
 	if !IsAvailable() {
 		t.Skip("tmux not available, skipping template tests")
 	}
+	isolateServer(t)
 
 	client, err := NewClient()
 	require.NoError(t, err)
@@ -172,7 +177,7 @@ func TestCreateFromTemplate(t *testing.T) {
 				{
 					Name:   "main",
 					Layout: "main-horizontal",
-					Panes:  []string{"echo 'Hello World'"},
+					Panes:  []config.TmuxPane{{Command: "echo 'Hello World'"}},
 				},
 			},
 		}
@@ -201,7 +206,7 @@ func TestCreateFromTemplate(t *testing.T) {
 				{
 					Name:   "editor",
 					Layout: "main-horizontal",
-					Panes:  []string{"cd {{.Project}}", "echo {{.User}}"},
+					Panes:  []config.TmuxPane{{Command: "cd {{.Project}}"}, {Command: "echo {{.User}}"}},
 				},
 			},
 		}
@@ -237,7 +242,7 @@ func TestCreateFromTemplate(t *testing.T) {
 			Windows: []config.TmuxWindow{
 				{
 					Name:  "main",
-					Panes: []string{"echo 'test'"},
+					Panes: []config.TmuxPane{{Command: "echo 'test'"}},
 				},
 			},
 		}
@@ -255,6 +260,96 @@ func TestCreateFromTemplate(t *testing.T) {
 	})
 }
 
+// paneGeometry is where a pane sits in its window
+type paneGeometry struct {
+	Left, Top, Width, Height int
+	Path                     string
+	Active                   bool
+}
+
+func listPaneGeometry(t *testing.T, client *Client, target string) []paneGeometry {
+	t.Helper()
+	out, err := client.tmux.Command("list-panes", "-t", target, "-F",
+		"#{pane_left} #{pane_top} #{pane_width} #{pane_height} #{pane_active} #{pane_current_path}")
+	require.NoError(t, err)
+
+	var panes []paneGeometry
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var p paneGeometry
+		var active int
+		_, err := fmt.Sscanf(line, "%d %d %d %d %d %s", &p.Left, &p.Top, &p.Width, &p.Height, &active, &p.Path)
+		require.NoError(t, err)
+		p.Active = active == 1
+		panes = append(panes, p)
+	}
+	return panes
+}
+
+func TestCreateFromTemplatePanes(t *testing.T) {
+	if !IsAvailable() {
+		t.Skip("tmux not available, skipping template tests")
+	}
+	isolateServer(t)
+
+	client, err := NewClient()
+	require.NoError(t, err)
+
+	sessionName := "rune-panes-test"
+	if client.SessionExists(sessionName) {
+		_ = client.KillSession(sessionName)
+	}
+	t.Cleanup(func() {
+		if client.SessionExists(sessionName) {
+			_ = client.KillSession(sessionName)
+		}
+	})
+
+	// tmux reports the physical directory
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	template := &config.TmuxTemplate{
+		SessionName: sessionName,
+		Windows: []config.TmuxWindow{
+			{
+				// Editor on the left 70%, two terminals stacked on the right
+				Name: "code",
+				Panes: []config.TmuxPane{
+					{Focus: true},
+					{Split: 0, Direction: "right", Size: 30, Dir: "{{.Dir}}"},
+					{Split: 1, Direction: "below"},
+				},
+			},
+			{
+				Name:   "logs",
+				Layout: "ad31,80x24,0,0{59x24,0,0,101,20x24,60,0,102}",
+				Panes:  []config.TmuxPane{{}, {}},
+			},
+		},
+	}
+	require.NoError(t, client.CreateFromTemplate(template, map[string]string{"Dir": dir}))
+
+	code := listPaneGeometry(t, client, "="+sessionName+":code")
+	require.Len(t, code, 3)
+	editor, top, bottom := code[0], code[1], code[2]
+
+	assert.Equal(t, 0, editor.Left)
+	assert.Equal(t, 24, editor.Height)
+	assert.InDelta(t, 56, editor.Width, 2, "editor should take about 70%% of the width")
+	assert.True(t, editor.Active, "focused pane should be active")
+
+	assert.Equal(t, editor.Width+1, top.Left, "terminals should sit right of the editor")
+	assert.Equal(t, top.Left, bottom.Left)
+	assert.Equal(t, 0, top.Top)
+	assert.Greater(t, bottom.Top, 0, "second terminal should be below the first")
+	assert.Equal(t, dir, top.Path)
+
+	logs := listPaneGeometry(t, client, "="+sessionName+":logs")
+	require.Len(t, logs, 2)
+	assert.Equal(t, 59, logs[0].Width)
+	assert.Equal(t, 20, logs[1].Width)
+}
+
 // BenchmarkSessionExists benchmarks the SessionExists function
 func BenchmarkSessionExists(b *testing.B) {
 	if !IsAvailable() {
@@ -0,0 +1,285 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GianlucaP106/gotmux/gotmux"
+)
+
+// PaneTarget identifies the pane a ritual command runs in: a window of a
+// session by name, or the session's first window if Window is empty, and a
+// pane of that window by position
+type PaneTarget struct {
+	Session string
+	Window  string
+	Pane    int
+}
+
+// PreparePane returns the ID of the target pane. A missing session or
+// window is created, starting in dir; created reports whether it was, and
+// so whether the pane is fresh.
+func (c *Client) PreparePane(target PaneTarget, dir string) (paneID string, created bool, err error) {
+	if !c.SessionExists(target.Session) {
+		if _, err := c.tmux.NewSession(&gotmux.SessionOptions{
+			Name:           target.Session,
+			StartDirectory: dir,
+		}); err != nil {
+			return "", false, fmt.Errorf("failed to create session '%s': %w", target.Session, err)
+		}
+		created = true
+	}
+
+	windowID, err := c.findWindow(target.Session, target.Window)
+	if err != nil {
+		return "", false, err
+	}
+	if windowID == "" {
+		if created {
+			// Name the new session's only window rather than adding another
+			if windowID, err = c.findWindow(target.Session, ""); err == nil {
+				_, err = c.tmux.Command("rename-window", "-t", windowID, target.Window)
+			}
+		} else {
+			args := []string{"new-window", "-d", "-P", "-F", "#{window_id}", "-t", "=" + target.Session + ":", "-n", target.Window}
+			if dir != "" {
+				args = append(args, "-c", dir)
+			}
+			windowID, err = c.tmux.Command(args...)
+			windowID = strings.TrimSpace(windowID)
+			created = true
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("failed to create window '%s': %w", target.Window, err)
+		}
+	}
+
+	out, err := c.tmux.Command("list-panes", "-t", windowID, "-F", "#{pane_id}")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list panes of session '%s': %w", target.Session, err)
+	}
+	panes := strings.Fields(out)
+	if target.Pane < 0 || target.Pane >= len(panes) {
+		return "", false, fmt.Errorf("no pane %d in session '%s' (the window has %s)", target.Pane, target.Session, paneCount(len(panes)))
+	}
+	return panes[target.Pane], created, nil
+}
+
+// findWindow returns the ID of a session's window by name, its first
+// window for an empty name, or "" if there is no such window
+func (c *Client) findWindow(sessionName, windowName string) (string, error) {
+	out, err := c.tmux.Command("list-windows", "-t", "="+sessionName, "-F", "#{window_id}\t#{window_name}")
+	if err != nil {
+		return "", fmt.Errorf("failed to list windows of session '%s': %w", sessionName, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		id, name, _ := strings.Cut(line, "\t")
+		if windowName == "" || name == windowName {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// PaneCommand returns the program running in a pane, or "" if the pane is
+// at a shell prompt
+func (c *Client) PaneCommand(paneID string) (string, error) {
+	out, err := c.tmux.Command("display-message", "-p", "-t", paneID, "#{pane_current_command}")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect pane %s: %w", paneID, err)
+	}
+	command := strings.TrimSpace(out)
+	if shells[filepath.Base(command)] {
+		return "", nil
+	}
+	return command, nil
+}
+
+// KeepPaneOnExit keeps a pane open when its program exits, so how it
+// exited can be reported
+func (c *Client) KeepPaneOnExit(paneID string) error {
+	if _, err := c.tmux.Command("set-option", "-p", "-t", paneID, "remain-on-exit", "on"); err != nil {
+		return fmt.Errorf("failed to set remain-on-exit on pane %s: %w", paneID, err)
+	}
+	return nil
+}
+
+// exitStatusWait is how long WaitFor gives tmux to collect the exit status
+// of a pane's program after reporting the pane dead
+const exitStatusWait = time.Second
+
+// exitReport is the line a started command prints when it exits, using
+// the variable each shell keeps the last exit status in. Other shells, such
+// as nu, only have an exit noticed if the pane's program exits.
+var (
+	exitReport     = regexp.MustCompile(`(?m)^\[rune\] exited with status (-?\d+)[ \t]*\n?`)
+	exitStatusVars = map[string]string{
+		"bash": "$?", "zsh": "$?", "sh": "$?", "dash": "$?", "ksh": "$?",
+		"fish": "$status", "csh": "$status", "tcsh": "$status",
+	}
+)
+
+// SentCommand is a command typed into a pane, whose output can be followed
+type SentCommand struct {
+	client  *Client
+	paneID  string
+	command string
+	mark    int // line of the pane's history where the output starts
+}
+
+// SendCommand types a command into a pane and presses Enter
+func (c *Client) SendCommand(paneID, command string) error {
+	return c.runInPane(paneID, command)
+}
+
+// StartCommand types a command into a pane so that its output can be
+// followed. At a shell prompt the command is followed by an echo of its
+// exit status, so WaitFor can tell when it exits and the shell survives.
+func (c *Client) StartCommand(paneID, command string) (*SentCommand, error) {
+	out, err := c.tmux.Command("display-message", "-p", "-t", paneID, "#{pane_current_command}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect pane %s: %w", paneID, err)
+	}
+	if status, ok := exitStatusVars[filepath.Base(strings.TrimSpace(out))]; ok {
+		command = fmt.Sprintf(`%s; echo "[rune] exited with status %s"`, command, status)
+	}
+
+	historySize, cursorY, err := c.paneLines(paneID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.runInPane(paneID, command); err != nil {
+		return nil, err
+	}
+	return &SentCommand{client: c, paneID: paneID, command: command, mark: historySize + cursorY}, nil
+}
+
+// Output returns what the pane has shown since the command was sent, with
+// the command's own echo and exit status line removed. The echo can appear
+// twice when the command is typed before the shell shows its prompt.
+func (s *SentCommand) Output() (string, error) {
+	out, err := s.capture()
+	if err != nil {
+		return "", err
+	}
+	return exitReport.ReplaceAllString(out, ""), nil
+}
+
+// capture returns what the pane has shown since the command was sent,
+// without the command's echo
+func (s *SentCommand) capture() (string, error) {
+	historySize, _, err := s.client.paneLines(s.paneID)
+	if err != nil {
+		return "", err
+	}
+	start := strconv.Itoa(s.mark - historySize)
+	out, err := s.client.tmux.Command("capture-pane", "-p", "-J", "-t", s.paneID, "-S", start)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pane %s: %w", s.paneID, err)
+	}
+	return strings.ReplaceAll(out, s.command, ""), nil
+}
+
+// WaitFor waits until the command's output matches pattern. It fails if
+// the command exits first, or the pane's program does, which tmux only
+// reports for panes kept open with KeepPaneOnExit, or if ctx is done.
+func (s *SentCommand) WaitFor(ctx context.Context, pattern *regexp.Regexp) error {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	var deadAt time.Time
+	for {
+		// Check for exit first, so output printed just before exiting counts
+		dead, status, err := s.client.paneExit(s.paneID)
+		if err != nil {
+			return err
+		}
+		raw, err := s.capture()
+		if err != nil {
+			return err
+		}
+		output := exitReport.ReplaceAllString(raw, "")
+		if pattern.MatchString(output) {
+			return nil
+		}
+		if exited := exitReport.FindStringSubmatchIndex(raw); exited != nil {
+			// The last output is the command's, not the prompt after it
+			return fmt.Errorf("command exited with status %s before printing %q%s",
+				raw[exited[2]:exited[3]], pattern, lastLine(raw[:exited[0]]))
+		}
+		// tmux can report the pane dead before it has the exit status;
+		// give it a moment to collect it, and report the exit without it
+		// if it doesn't
+		if dead && deadAt.IsZero() {
+			deadAt = time.Now()
+		}
+		if dead && (status != "" || time.Since(deadAt) >= exitStatusWait) {
+			if status != "" {
+				status = " with status " + status
+			}
+			return fmt.Errorf("pane exited%s before printing %q%s", status, pattern, lastLine(output))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %q%s", pattern, lastLine(output))
+		case <-ticker.C:
+		}
+	}
+}
+
+// paneLines returns the number of lines in a pane's history and the
+// cursor's line on screen
+func (c *Client) paneLines(paneID string) (historySize, cursorY int, err error) {
+	out, err := c.tmux.Command("display-message", "-p", "-t", paneID, "#{history_size} #{cursor_y}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("pane %s is gone: %w", paneID, err)
+	}
+	if _, err := fmt.Sscan(out, &historySize, &cursorY); err != nil {
+		return 0, 0, fmt.Errorf("failed to read position in pane %s: %w", paneID, err)
+	}
+	return historySize, cursorY, nil
+}
+
+// paneExit reports whether a pane's program has exited, and its exit
+// status if tmux knows it
+func (c *Client) paneExit(paneID string) (dead bool, status string, err error) {
+	out, err := c.tmux.Command("display-message", "-p", "-t", paneID, "#{pane_dead} #{pane_dead_status}")
+	if err != nil {
+		return false, "", fmt.Errorf("pane %s is gone: %w", paneID, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 || fields[0] != "1" {
+		return false, "", nil
+	}
+	if len(fields) > 1 {
+		status = fields[1]
+	}
+	return true, status, nil
+}
+
+// paneCount formats a number of panes
+func paneCount(n int) string {
+	if n == 1 {
+		return "1 pane"
+	}
+	return fmt.Sprintf("%d panes", n)
+}
+
+// lastLine returns the last line of output worth showing in an error,
+// skipping the notice tmux shows in a pane whose program exited
+func lastLine(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "Pane is dead") {
+			return fmt.Sprintf(" (last output: %s)", line)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package tmux
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeSession returns a session name that is free now and killed when
+// the test ends
+func freeSession(t *testing.T, client *Client, name string) string {
+	t.Helper()
+	if client.SessionExists(name) {
+		_ = client.KillSession(name)
+	}
+	t.Cleanup(func() {
+		if client.SessionExists(name) {
+			_ = client.KillSession(name)
+		}
+	})
+	return name
+}
+
+func TestPreparePane(t *testing.T) {
+	client := newTestClient(t)
+	sessionName := freeSession(t, client, "rune-prepare-pane-test")
+
+	// A missing session is created, with the window named
+	first, created, err := client.PreparePane(PaneTarget{Session: sessionName, Window: "editor"}, t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	windows, err := client.snapshotWindows(sessionName)
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, "editor", windows[0].Name)
+
+	// The same target is found again
+	again, created, err := client.PreparePane(PaneTarget{Session: sessionName, Window: "editor"}, "")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first, again)
+
+	// A missing window is added to the existing session
+	server, created, err := client.PreparePane(PaneTarget{Session: sessionName, Window: "server"}, "")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, first, server)
+
+	windows, err = client.snapshotWindows(sessionName)
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "server", windows[1].Name)
+
+	// Panes are not created, only picked
+	_, _, err = client.PreparePane(PaneTarget{Session: sessionName, Window: "server", Pane: 1}, "")
+	assert.ErrorContains(t, err, "no pane 1")
+}
+
+func TestSendCommandWaitFor(t *testing.T) {
+	client := newTestClient(t)
+	sessionName := freeSession(t, client, "rune-send-command-test")
+
+	pane, _, err := client.PreparePane(PaneTarget{Session: sessionName}, "")
+	require.NoError(t, err)
+
+	// The shell can take a while to start, so the timeouts are generous
+	sent, err := client.StartCommand(pane, "echo listening on $((8000+80))")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	require.NoError(t, sent.WaitFor(ctx, regexp.MustCompile(`listening on 8080`)))
+
+	// The echo of the command itself doesn't count as output
+	sent, err = client.StartCommand(pane, "sleep 1 # ready")
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.ErrorContains(t, sent.WaitFor(ctx, regexp.MustCompile(`ready`)), "timed out")
+}
+
+func TestWaitForCommandExit(t *testing.T) {
+	client := newTestClient(t)
+	sessionName := freeSession(t, client, "rune-command-exit-test")
+
+	pane, _, err := client.PreparePane(PaneTarget{Session: sessionName}, "")
+	require.NoError(t, err)
+
+	// A command that fails leaves the pane at its shell
+	sent, err := client.StartCommand(pane, "sh -c 'echo starting; exit 3'")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	err = sent.WaitFor(ctx, regexp.MustCompile(`ready`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command exited with status 3 before printing")
+	assert.Contains(t, err.Error(), "starting")
+
+	output, err := sent.Output()
+	require.NoError(t, err)
+	assert.NotContains(t, output, "[rune]")
+}
+
+func TestWaitForPaneExit(t *testing.T) {
+	client := newTestClient(t)
+	sessionName := freeSession(t, client, "rune-pane-exit-test")
+
+	pane, _, err := client.PreparePane(PaneTarget{Session: sessionName}, "")
+	require.NoError(t, err)
+	require.NoError(t, client.KeepPaneOnExit(pane))
+
+	// Replacing the shell takes the exit status line with it, and tmux
+	// doesn't always have the status of a program that exits at once
+	sent, err := client.StartCommand(pane, "exec sh -c 'echo starting; exit 3'")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	err = sent.WaitFor(ctx, regexp.MustCompile(`ready`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pane exited")
+	assert.Contains(t, err.Error(), "starting")
+}
+
+func TestPaneCommand(t *testing.T) {
+	client := newTestClient(t)
+	sessionName := freeSession(t, client, "rune-pane-command-test")
+
+	pane, _, err := client.PreparePane(PaneTarget{Session: sessionName}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, client.SendCommand(pane, "exec sleep 300"))
+
+	require.Eventually(t, func() bool {
+		running, err := client.PaneCommand(pane)
+		return err == nil && running == "sleep"
+	}, 20*time.Second, 100*time.Millisecond)
+}
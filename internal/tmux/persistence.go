@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
 )
 
 // SessionState represents the state of a tmux session for persistence
@@ -19,10 +21,16 @@ type SessionState struct {
 	Windows      []WindowState     `json:"windows,omitempty"`
 }
 
+// Stale reports whether the session has seen no activity within maxAge
+func (s *SessionState) Stale(maxAge time.Duration, now time.Time) bool {
+	return now.Sub(s.LastActivity) > maxAge
+}
+
 // WindowState represents the state of a tmux window
 type WindowState struct {
 	Name   string      `json:"name"`
 	Layout string      `json:"layout,omitempty"`
+	Active bool        `json:"active,omitempty"`
 	Panes  []PaneState `json:"panes,omitempty"`
 }
 
@@ -31,6 +39,7 @@ type PaneState struct {
 	Command     string            `json:"command,omitempty"`
 	WorkingDir  string            `json:"working_dir,omitempty"`
 	Environment map[string]string `json:"environment,omitempty"`
+	Active      bool              `json:"active,omitempty"`
 }
 
 // SessionPersistence handles saving and loading tmux session states
@@ -160,8 +169,11 @@ func (sp *SessionPersistence) CleanupStaleSessions(client *Client) error {
 	return nil
 }
 
-// RestoreSession attempts to restore a tmux session from persisted state
-func (sp *SessionPersistence) RestoreSession(client *Client, sessionName string) error {
+// RestoreSession recreates a tmux session from persisted state. A session
+// created from a template that is still configured is recreated from the
+// template with its recorded variables; otherwise the recorded windows,
+// panes and layouts are rebuilt.
+func (sp *SessionPersistence) RestoreSession(client *Client, sessionName string, templates map[string]config.TmuxTemplate) error {
 	state, err := sp.LoadSession(sessionName)
 	if err != nil {
 		return fmt.Errorf("failed to load session state: %w", err)
@@ -172,14 +184,22 @@ func (sp *SessionPersistence) RestoreSession(client *Client, sessionName string)
 		return fmt.Errorf("session '%s' already exists", sessionName)
 	}
 
-	// If this was created from a template, try to recreate using the template
-	if state.Template != "" {
-		// This would require access to the config, so we'll implement this later
-		// For now, we'll create a basic session
-		return client.CreateSession(sessionName)
+	template, ok := templates[state.Template]
+	switch {
+	case ok && state.Template != "":
+		err = client.CreateFromTemplate(&template, state.Variables)
+	case len(state.Windows) > 0:
+		err = client.restoreWindows(sessionName, state.Windows)
+	default:
+		err = client.CreateSession(sessionName)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Create basic session for now
-	// A more sophisticated implementation would recreate the full window/pane structure
-	return client.CreateSession(sessionName)
+	// The restored session is Rune's, like the one it replaces
+	if err := client.MarkSession(sessionName, state.Project); err != nil {
+		return err
+	}
+	return sp.SaveSession(state)
 }
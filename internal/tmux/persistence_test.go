@@ -109,6 +109,7 @@ func TestSessionPersistenceCleanup(t *testing.T) {
 	if !IsAvailable() {
 		t.Skip("tmux not available, skipping cleanup test")
 	}
+	isolateServer(t)
 
 	tempDir := t.TempDir()
 	sp := &SessionPersistence{
@@ -149,3 +150,11 @@ func TestSessionPersistenceCleanup(t *testing.T) {
 		}
 	})
 }
+
+func TestSessionStateStale(t *testing.T) {
+	now := time.Now()
+	state := &SessionState{LastActivity: now.Add(-2 * time.Hour)}
+
+	assert.True(t, state.Stale(time.Hour, now))
+	assert.False(t, state.Stale(3*time.Hour, now))
+}
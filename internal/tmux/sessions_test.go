@@ -0,0 +1,131 @@
+package tmux
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSession creates a detached session and kills it when the test ends
+func newTestSession(t *testing.T, client *Client, name string) {
+	t.Helper()
+	if client.SessionExists(name) {
+		_ = client.KillSession(name)
+	}
+	require.NoError(t, client.CreateSession(name))
+	t.Cleanup(func() {
+		if client.SessionExists(name) {
+			_ = client.KillSession(name)
+		}
+	})
+}
+
+// writeState persists a session state as is; SaveSession would reset its
+// last activity
+func writeState(t *testing.T, client *Client, state SessionState) {
+	t.Helper()
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	path := filepath.Join(client.persistence.stateDir, state.Name+".json")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func findSession(sessions []SessionInfo, name string) *SessionInfo {
+	for i := range sessions {
+		if sessions[i].Name == name {
+			return &sessions[i]
+		}
+	}
+	return nil
+}
+
+func TestMarkSession(t *testing.T) {
+	client := newTestClient(t)
+	newTestSession(t, client, "rune-mark-test")
+	newTestSession(t, client, "mark-test-other")
+
+	require.NoError(t, client.MarkSession("rune-mark-test", "api"))
+
+	sessions, err := client.Sessions()
+	require.NoError(t, err)
+
+	marked := findSession(sessions, "rune-mark-test")
+	require.NotNil(t, marked)
+	assert.True(t, marked.Managed)
+	assert.Equal(t, "api", marked.Project)
+	assert.Equal(t, 1, marked.Windows)
+	assert.False(t, marked.Attached)
+
+	other := findSession(sessions, "mark-test-other")
+	require.NotNil(t, other)
+	assert.False(t, other.Managed)
+	assert.Empty(t, other.Project)
+}
+
+func TestSaveSessionStateMarksSession(t *testing.T) {
+	client := newTestClient(t)
+	newTestSession(t, client, "rune-save-state-test")
+
+	require.NoError(t, client.SaveSessionState("rune-save-state-test", "", "web", nil))
+
+	sessions, err := client.Sessions()
+	require.NoError(t, err)
+	session := findSession(sessions, "rune-save-state-test")
+	require.NotNil(t, session)
+	assert.True(t, session.Managed)
+	assert.Equal(t, "web", session.Project)
+
+	state, err := client.LoadSessionState("rune-save-state-test")
+	require.NoError(t, err)
+	assert.Equal(t, "web", state.Project)
+	assert.Len(t, state.Windows, 1)
+}
+
+func TestPruneSessions(t *testing.T) {
+	client := newTestClient(t)
+	old := time.Now().Add(-48 * time.Hour)
+
+	// Stale rune-* session: pruned
+	newTestSession(t, client, "rune-prune-stale")
+	writeState(t, client, SessionState{Name: "rune-prune-stale", LastActivity: old})
+
+	// Stale session marked as Rune's: pruned
+	newTestSession(t, client, "prune-marked-stale")
+	require.NoError(t, client.MarkSession("prune-marked-stale", "api"))
+	writeState(t, client, SessionState{Name: "prune-marked-stale", LastActivity: old})
+
+	// Recently active: kept
+	newTestSession(t, client, "rune-prune-fresh")
+	writeState(t, client, SessionState{Name: "rune-prune-fresh", LastActivity: time.Now()})
+
+	// No saved state: kept
+	newTestSession(t, client, "rune-prune-unknown")
+
+	// Not Rune's: kept
+	newTestSession(t, client, "prune-other-stale")
+	writeState(t, client, SessionState{Name: "prune-other-stale", LastActivity: old})
+
+	// Saved state of a session that no longer exists: forgotten when stale,
+	// kept for restoring otherwise
+	writeState(t, client, SessionState{Name: "rune-prune-gone-stale", LastActivity: old})
+	writeState(t, client, SessionState{Name: "rune-prune-gone", LastActivity: time.Now()})
+
+	killed, err := client.PruneSessions(24 * time.Hour)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rune-prune-stale", "prune-marked-stale"}, killed)
+
+	assert.False(t, client.SessionExists("rune-prune-stale"))
+	assert.False(t, client.SessionExists("prune-marked-stale"))
+	assert.True(t, client.SessionExists("rune-prune-fresh"))
+	assert.True(t, client.SessionExists("rune-prune-unknown"))
+	assert.True(t, client.SessionExists("prune-other-stale"))
+
+	saved, err := client.ListPersistedSessions()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rune-prune-fresh", "prune-other-stale", "rune-prune-gone"}, saved)
+}
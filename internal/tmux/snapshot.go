@@ -0,0 +1,232 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/GianlucaP106/gotmux/gotmux"
+)
+
+// shells are not recorded as a pane's command: a pane sitting at a prompt
+// gets its shell back when it is restored
+var shells = map[string]bool{
+	"bash": true, "zsh": true, "sh": true, "fish": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true, "pwsh": true,
+}
+
+// snapshotWindows captures the windows of a live session: each window's
+// name and layout string, and each pane's working directory and running
+// command.
+func (c *Client) snapshotWindows(sessionName string) ([]WindowState, error) {
+	session, err := c.tmux.GetSessionByName(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session '%s': %w", sessionName, err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("session '%s' does not exist", sessionName)
+	}
+
+	windows, err := session.ListWindows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows of session '%s': %w", sessionName, err)
+	}
+
+	states := make([]WindowState, 0, len(windows))
+	for _, window := range windows {
+		panes, err := window.ListPanes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list panes of window '%s': %w", window.Name, err)
+		}
+
+		state := WindowState{
+			Name:   window.Name,
+			Layout: window.Layout,
+			Active: window.Active,
+		}
+		for _, pane := range panes {
+			paneState := PaneState{
+				WorkingDir: pane.CurrentPath,
+				Active:     pane.Active,
+			}
+			if !shells[filepath.Base(pane.CurrentCommand)] {
+				paneState.Command = foregroundCommand(pane)
+			}
+			state.Panes = append(state.Panes, paneState)
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// foregroundCommand returns the command line of the process running in the
+// foreground of a pane. tmux only reports the program's name, so the
+// arguments come from ps, when it can tell.
+func foregroundCommand(pane *gotmux.Pane) string {
+	pid := strconv.Itoa(int(pane.Pid))
+	out, err := exec.Command("ps", "-o", "tpgid=", "-p", pid).Output()
+	if err != nil {
+		return pane.CurrentCommand
+	}
+	foreground := strings.TrimSpace(string(out))
+	if foreground == "" || foreground == "-1" {
+		return pane.CurrentCommand
+	}
+
+	out, err = exec.Command("ps", "-o", "args=", "-p", foreground).Output()
+	if args := strings.TrimSpace(string(out)); err == nil && args != "" {
+		return args
+	}
+	return pane.CurrentCommand
+}
+
+// restoreWindows rebuilds a session from a snapshot: its windows with their
+// names, panes in their working directories, the recorded layouts and the
+// commands that were running.
+func (c *Client) restoreWindows(sessionName string, windows []WindowState) error {
+	if len(windows) == 0 {
+		return fmt.Errorf("no windows recorded for session '%s'", sessionName)
+	}
+
+	// Start at the recorded size so layouts apply without being squeezed
+	width, height := layoutSize(windows[0].Layout)
+	session, err := c.tmux.NewSession(&gotmux.SessionOptions{
+		Name:           sessionName,
+		StartDirectory: firstPaneDir(windows[0]),
+		Width:          width,
+		Height:         height,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session '%s': %w", sessionName, err)
+	}
+
+	var active *gotmux.Window
+	for i, state := range windows {
+		var window *gotmux.Window
+		if i == 0 {
+			created, err := session.ListWindows()
+			if err != nil || len(created) == 0 {
+				return fmt.Errorf("failed to get first window of session '%s'", sessionName)
+			}
+			window = created[0]
+			if err := window.Rename(state.Name); err != nil {
+				return fmt.Errorf("failed to rename window '%s': %w", state.Name, err)
+			}
+		} else {
+			window, err = session.NewWindow(&gotmux.NewWindowOptions{
+				WindowName:     state.Name,
+				StartDirectory: firstPaneDir(state),
+				DoNotAttach:    true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create window '%s': %w", state.Name, err)
+			}
+		}
+
+		if err := c.restorePanes(window, state); err != nil {
+			return fmt.Errorf("failed to restore window '%s': %w", state.Name, err)
+		}
+		if state.Active {
+			active = window
+		}
+	}
+
+	if active != nil {
+		if err := active.Select(); err != nil {
+			return fmt.Errorf("failed to select window '%s': %w", active.Name, err)
+		}
+	}
+	return nil
+}
+
+// restorePanes splits a new window into the recorded panes, applies the
+// recorded layout and starts the panes' commands
+func (c *Client) restorePanes(window *gotmux.Window, state WindowState) error {
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		return fmt.Errorf("failed to get window panes")
+	}
+
+	for i := 1; i < len(state.Panes); i++ {
+		last := panes[len(panes)-1]
+		err := last.SplitWindow(&gotmux.SplitWindowOptions{
+			StartDirectory: state.Panes[i].WorkingDir,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to split pane: %w", err)
+		}
+		// tmux refuses to split panes that are too small; even them out
+		// as we go, the recorded layout is applied at the end
+		if _, err := c.tmux.Command("select-layout", "-t", window.Id, "tiled"); err != nil {
+			return fmt.Errorf("failed to arrange panes: %w", err)
+		}
+		if panes, err = window.ListPanes(); err != nil {
+			return fmt.Errorf("failed to refresh pane list: %w", err)
+		}
+	}
+
+	if state.Layout != "" {
+		if err := window.SelectLayout(gotmux.WindowLayout(state.Layout)); err != nil {
+			return fmt.Errorf("failed to apply layout '%s': %w", state.Layout, err)
+		}
+	}
+
+	for i, pane := range panes {
+		if i >= len(state.Panes) {
+			break
+		}
+		if command := state.Panes[i].Command; command != "" {
+			if err := c.runInPane(pane.Id, command); err != nil {
+				return err
+			}
+		}
+		if state.Panes[i].Active {
+			if err := pane.Select(); err != nil {
+				return fmt.Errorf("failed to select pane: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// runInPane types a command into a pane and presses Enter
+func (c *Client) runInPane(paneID, command string) error {
+	if _, err := c.tmux.Command("send-keys", "-t", paneID, "-l", command); err != nil {
+		return fmt.Errorf("failed to send command to pane: %w", err)
+	}
+	if _, err := c.tmux.Command("send-keys", "-t", paneID, "Enter"); err != nil {
+		return fmt.Errorf("failed to send command to pane: %w", err)
+	}
+	return nil
+}
+
+// layoutSize returns the window size recorded in a tmux layout string such
+// as "b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,2}", or zeros if there is none
+func layoutSize(layout string) (width, height int) {
+	fields := strings.SplitN(layout, ",", 3)
+	if len(fields) < 2 {
+		return 0, 0
+	}
+	w, h, ok := strings.Cut(fields[1], "x")
+	if !ok {
+		return 0, 0
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// firstPaneDir returns the working directory of a window's first pane
+func firstPaneDir(window WindowState) string {
+	if len(window.Panes) == 0 {
+		return ""
+	}
+	return window.Panes[0].WorkingDir
+}
@@ -0,0 +1,198 @@
+package tmux
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutSize(t *testing.T) {
+	tests := []struct {
+		layout        string
+		width, height int
+	}{
+		{"b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,2}", 80, 24},
+		{"4f1e,200x50,0,0,3", 200, 50},
+		{"main-vertical", 0, 0},
+		{"", 0, 0},
+		{"abcd,wide,0,0", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			width, height := layoutSize(tt.layout)
+			assert.Equal(t, tt.width, width)
+			assert.Equal(t, tt.height, height)
+		})
+	}
+}
+
+// isolateServer points tmux at a server of the test's own, so tests don't
+// touch the user's sessions or each other's, and kills it when the test
+// ends. Socket paths are limited in length, so the directory is a short
+// one rather than t.TempDir().
+func isolateServer(t *testing.T) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "rune-tmux")
+	require.NoError(t, err)
+	t.Setenv("TMUX_TMPDIR", dir)
+	t.Setenv("TMUX", "")
+	t.Cleanup(func() {
+		_ = exec.Command("tmux", "kill-server").Run()
+		_ = os.RemoveAll(dir)
+	})
+}
+
+// newTestClient returns a client on a private tmux server that keeps
+// session state in a temporary directory
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	if !IsAvailable() {
+		t.Skip("tmux not available, skipping snapshot tests")
+	}
+	isolateServer(t)
+
+	client, err := NewClient()
+	require.NoError(t, err)
+	client.persistence = &SessionPersistence{stateDir: t.TempDir()}
+	return client
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	client := newTestClient(t)
+
+	sessionName := "rune-snapshot-test"
+	if client.SessionExists(sessionName) {
+		_ = client.KillSession(sessionName)
+	}
+	t.Cleanup(func() {
+		if client.SessionExists(sessionName) {
+			_ = client.KillSession(sessionName)
+		}
+	})
+
+	// Resolve symlinks, as tmux reports the physical directory
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	editDir := filepath.Join(root, "edit")
+	serverDir := filepath.Join(root, "server")
+	logsDir := filepath.Join(root, "logs")
+	for _, dir := range []string{editDir, serverDir, logsDir} {
+		require.NoError(t, os.Mkdir(dir, 0755))
+	}
+
+	err = client.restoreWindows(sessionName, []WindowState{
+		{Name: "editor", Panes: []PaneState{{WorkingDir: editDir}}},
+		{
+			Name:   "server",
+			Active: true,
+			Panes: []PaneState{
+				{WorkingDir: serverDir},
+				{WorkingDir: logsDir, Command: "exec sleep 300", Active: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// Give the shell time to start up and run the command
+	var saved *SessionState
+	require.Eventually(t, func() bool {
+		saved, err = client.SnapshotSession(sessionName)
+		require.NoError(t, err)
+		return saved.Windows[1].Panes[1].Command == "sleep 300"
+	}, 20*time.Second, 100*time.Millisecond)
+
+	require.Len(t, saved.Windows, 2)
+	assert.Equal(t, "editor", saved.Windows[0].Name)
+	assert.Equal(t, []PaneState{{WorkingDir: editDir, Active: true}}, saved.Windows[0].Panes)
+	assert.Equal(t, "server", saved.Windows[1].Name)
+	assert.True(t, saved.Windows[1].Active)
+	assert.Equal(t, []PaneState{
+		{WorkingDir: serverDir},
+		{WorkingDir: logsDir, Command: "sleep 300", Active: true},
+	}, saved.Windows[1].Panes)
+
+	// Restoring rebuilds the same windows, panes and layouts
+	require.NoError(t, client.KillSession(sessionName))
+	require.NoError(t, client.RestoreSession(sessionName, nil))
+
+	var restored []WindowState
+	require.Eventually(t, func() bool {
+		restored, err = client.snapshotWindows(sessionName)
+		require.NoError(t, err)
+		return restored[1].Panes[1].Command == "sleep 300"
+	}, 20*time.Second, 100*time.Millisecond)
+
+	require.Len(t, restored, 2)
+	for i := range restored {
+		assert.Equal(t, saved.Windows[i].Name, restored[i].Name)
+		assert.Equal(t, saved.Windows[i].Active, restored[i].Active)
+		assert.Equal(t, saved.Windows[i].Panes, restored[i].Panes)
+		assert.Equal(t, stripPaneIDs(saved.Windows[i].Layout), stripPaneIDs(restored[i].Layout))
+	}
+}
+
+func TestRestoreSessionUsesTemplate(t *testing.T) {
+	client := newTestClient(t)
+
+	sessionName := "rune-restore-template-test"
+	if client.SessionExists(sessionName) {
+		_ = client.KillSession(sessionName)
+	}
+	t.Cleanup(func() {
+		if client.SessionExists(sessionName) {
+			_ = client.KillSession(sessionName)
+		}
+	})
+
+	require.NoError(t, client.persistence.SaveSession(&SessionState{
+		Name:      sessionName,
+		Template:  "dev",
+		Variables: map[string]string{"Project": "restore-template-test"},
+		Project:   "restore-template-test",
+		CreatedAt: time.Now(),
+		Windows:   []WindowState{{Name: "recorded"}},
+	}))
+
+	templates := map[string]config.TmuxTemplate{
+		"dev": {
+			SessionName: "rune-{{.Project}}",
+			Windows: []config.TmuxWindow{
+				{Name: "code"},
+				{Name: "tests"},
+			},
+		},
+	}
+	require.NoError(t, client.RestoreSession(sessionName, templates))
+
+	windows, err := client.snapshotWindows(sessionName)
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "code", windows[0].Name)
+	assert.Equal(t, "tests", windows[1].Name)
+
+	// The restored session is marked as Rune's again
+	sessions, err := client.Sessions()
+	require.NoError(t, err)
+	session := findSession(sessions, sessionName)
+	require.NotNil(t, session)
+	assert.True(t, session.Managed)
+	assert.Equal(t, "restore-template-test", session.Project)
+}
+
+// layoutPaneIDs matches the checksum of a layout string and the IDs of its
+// panes (the last field of a pane's WxH,X,Y,ID)
+var layoutPaneIDs = regexp.MustCompile(`^[0-9a-f]{4},|(\d+x\d+,\d+,\d+),\d+`)
+
+// stripPaneIDs removes the checksum and pane IDs from a layout string, which
+// differ between a session and its restored copy
+func stripPaneIDs(layout string) string {
+	return layoutPaneIDs.ReplaceAllString(layout, "$1")
+}
@@ -3,6 +3,7 @@
 package tmux
 
 import (
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -53,7 +54,5 @@ func GetDefaultInstallPath() string {
 // IsInTmuxSession checks if the current process is running inside a tmux session
 // by checking for the TMUX environment variable that tmux sets.
 func IsInTmuxSession() bool {
-	cmd := exec.Command("tmux", "display-message", "-p", "#{session_name}")
-	err := cmd.Run()
-	return err == nil
+	return os.Getenv("TMUX") != ""
 }
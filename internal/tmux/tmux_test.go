@@ -78,6 +78,15 @@ func TestIsInTmuxSession(t *testing.T) {
 		// Result can be true or false, just ensure it's a valid boolean
 		assert.IsType(t, true, result, "Should return boolean value")
 	})
+
+	t.Run("should follow the TMUX variable", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+		assert.True(t, IsInTmuxSession())
+
+		// A running server doesn't put Rune inside tmux
+		t.Setenv("TMUX", "")
+		assert.False(t, IsInTmuxSession())
+	})
 }
 
 // TestTmuxIntegration tests the basic tmux integration
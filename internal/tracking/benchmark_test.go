@@ -0,0 +1,100 @@
+package tracking
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// benchmarkSessionCount approximates several years of imported history
+const benchmarkSessionCount = 10000
+
+// setupBenchmarkTracker creates a tracker filled with a few years of sessions
+func setupBenchmarkTracker(b *testing.B) *Tracker {
+	tracker := setupTestTracker(b)
+	b.Cleanup(func() { tracker.Close() })
+
+	start := time.Now().AddDate(0, 0, -benchmarkSessionCount/4)
+	for i := 0; i < benchmarkSessionCount; i++ {
+		// Four two-hour sessions a day, rotating over a handful of projects
+		day := start.AddDate(0, 0, i/4)
+		sessionStart := day.Add(time.Duration(i%4) * 2 * time.Hour)
+		session := &Session{
+			ID:        fmt.Sprintf("bench_%d", i),
+			Project:   fmt.Sprintf("project-%d", i%8),
+			StartTime: sessionStart,
+			Duration:  2 * time.Hour,
+			State:     StateStopped,
+			Tags:      []string{"benchmark"},
+		}
+		end := sessionStart.Add(2 * time.Hour)
+		session.EndTime = &end
+		if err := tracker.SaveImportedSession(session); err != nil {
+			b.Fatalf("failed to seed session: %v", err)
+		}
+	}
+
+	return tracker
+}
+
+// BenchmarkGetDailyTotal measures today's total with a large history
+func BenchmarkGetDailyTotal(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetDailyTotal(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetWeeklyTotal measures this week's total with a large history
+func BenchmarkGetWeeklyTotal(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetWeeklyTotal(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetSessionHistory measures fetching the most recent sessions
+func BenchmarkGetSessionHistory(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetSessionHistory(50); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetProjectStats measures per-project totals over the whole history
+func BenchmarkGetProjectStats(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetProjectStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkQuerySessionsMonth measures a one-month range query
+func BenchmarkQuerySessionsMonth(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+	to := time.Now()
+	from := to.AddDate(0, -1, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.QuerySessions(SessionQuery{From: from, To: to}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
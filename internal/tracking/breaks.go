@@ -0,0 +1,174 @@
+package tracking
+
+import (
+	"fmt"
+	"time"
+)
+
+// BreakGrace is how soon after a break reminder the session must pause for
+// the break to count as taken
+const BreakGrace = 10 * time.Minute
+
+// BreakSchedule decides when a break is due. Breaks fall due after Interval
+// of continuous work; pausing the session starts a new stretch. With
+// Pomodoro set, work stretches last Interval and every LongBreakEvery-th
+// break is a long one.
+type BreakSchedule struct {
+	Interval       time.Duration
+	Pomodoro       bool
+	ShortBreak     time.Duration
+	LongBreak      time.Duration
+	LongBreakEvery int
+}
+
+// BreakDue describes a break that is due
+type BreakDue struct {
+	// Worked is the continuous work since the session last resumed
+	Worked time.Duration
+	// Number counts the break reminders in the session, this one included
+	Number int
+	// Length is the suggested break length; zero outside Pomodoro mode
+	Length time.Duration
+	Long   bool
+}
+
+// Stretch returns when the session's current stretch of continuous work
+// began, or false if the session isn't running
+func (s *Session) Stretch() (time.Time, bool) {
+	if s.State != StateRunning {
+		return time.Time{}, false
+	}
+	open := s.openInterval()
+	if open == nil {
+		return time.Time{}, false
+	}
+	return open.Start, true
+}
+
+// NextBreak returns when a break falls due in the current stretch, taking a
+// snooze into account. It returns false if the session isn't running or the
+// break was already announced and not snoozed since.
+func (b BreakSchedule) NextBreak(session *Session, snoozedUntil, now time.Time) (time.Time, bool) {
+	stretch, ok := session.Stretch()
+	if !ok || b.Interval <= 0 {
+		return time.Time{}, false
+	}
+
+	due := stretch.Add(b.Interval)
+	if snoozedUntil.After(due) {
+		due = snoozedUntil
+	}
+
+	// One reminder per stretch, unless snoozed after it was sent
+	if last, ok := session.lastBreakReminder(); ok && !last.Before(stretch) && !due.After(last) {
+		return time.Time{}, false
+	}
+
+	return due, true
+}
+
+// Due returns the break to announce now, if one is due
+func (b BreakSchedule) Due(session *Session, snoozedUntil, now time.Time) (BreakDue, bool) {
+	due, ok := b.NextBreak(session, snoozedUntil, now)
+	if !ok || now.Before(due) {
+		return BreakDue{}, false
+	}
+
+	return b.Upcoming(session, now), true
+}
+
+// Upcoming describes the session's next break
+func (b BreakSchedule) Upcoming(session *Session, now time.Time) BreakDue {
+	var worked time.Duration
+	if stretch, ok := session.Stretch(); ok {
+		worked = now.Sub(stretch)
+	}
+	return b.describe(len(session.BreakReminders)+1, worked)
+}
+
+// Suggested returns the break suggested by the session's latest reminder
+func (b BreakSchedule) Suggested(session *Session) (BreakDue, bool) {
+	if len(session.BreakReminders) == 0 {
+		return BreakDue{}, false
+	}
+	return b.describe(len(session.BreakReminders), 0), true
+}
+
+func (b BreakSchedule) describe(number int, worked time.Duration) BreakDue {
+	due := BreakDue{Worked: worked, Number: number}
+	if !b.Pomodoro {
+		return due
+	}
+
+	due.Length = b.ShortBreak
+	if b.LongBreakEvery > 0 && number%b.LongBreakEvery == 0 {
+		due.Length = b.LongBreak
+		due.Long = true
+	}
+	return due
+}
+
+// lastBreakReminder returns the most recent break reminder
+func (s *Session) lastBreakReminder() (time.Time, bool) {
+	if len(s.BreakReminders) == 0 {
+		return time.Time{}, false
+	}
+	return s.BreakReminders[len(s.BreakReminders)-1], true
+}
+
+// BreakCompliance counts the session's break reminders and how many were
+// followed by a pause within BreakGrace
+func (s *Session) BreakCompliance() (reminders, taken int) {
+	for _, at := range s.BreakReminders {
+		reminders++
+
+		for _, interval := range s.Intervals {
+			if at.Before(interval.Start) || (interval.End != nil && !interval.End.After(at)) {
+				continue
+			}
+			if interval.End != nil && !interval.End.After(at.Add(BreakGrace)) {
+				taken++
+			}
+			break
+		}
+	}
+	return reminders, taken
+}
+
+// LongestStretch returns the session's longest stretch of continuous work
+func (s *Session) LongestStretch(now time.Time) time.Duration {
+	var longest time.Duration
+	for _, interval := range s.Intervals {
+		end := now
+		if interval.End != nil {
+			end = *interval.End
+		}
+		if d := end.Sub(interval.Start); d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// RecordBreakReminder notes that a break reminder was sent for the current
+// session, so reports can show whether breaks were taken
+func (t *Tracker) RecordBreakReminder(at time.Time) (*Session, error) {
+	session, err := t.GetCurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+
+	session.BreakReminders = append(session.BreakReminders, at)
+
+	if err := t.saveSession(session); err != nil {
+		return nil, err
+	}
+	if err := t.setCurrentSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
@@ -0,0 +1,97 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakSchedule_Due(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	session := &Session{StartTime: start, State: StateRunning, Intervals: []Interval{{Start: start}}}
+	schedule := BreakSchedule{Interval: 50 * time.Minute}
+
+	_, ok := schedule.Due(session, time.Time{}, start.Add(49*time.Minute))
+	assert.False(t, ok)
+
+	due, ok := schedule.Due(session, time.Time{}, start.Add(52*time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, 52*time.Minute, due.Worked)
+	assert.Equal(t, 1, due.Number)
+	assert.Zero(t, due.Length, "no suggested length outside Pomodoro mode")
+
+	// Once reminded, the stretch stays quiet unless snoozed past the reminder
+	session.BreakReminders = []time.Time{start.Add(52 * time.Minute)}
+	_, ok = schedule.Due(session, time.Time{}, start.Add(90*time.Minute))
+	assert.False(t, ok)
+	_, ok = schedule.Due(session, start.Add(62*time.Minute), start.Add(62*time.Minute))
+	assert.True(t, ok)
+
+	// A paused session has no break due
+	session.State = StatePaused
+	_, ok = schedule.Due(session, time.Time{}, start.Add(2*time.Hour))
+	assert.False(t, ok)
+}
+
+func TestBreakSchedule_PomodoroLengths(t *testing.T) {
+	schedule := BreakSchedule{
+		Interval:       25 * time.Minute,
+		Pomodoro:       true,
+		ShortBreak:     5 * time.Minute,
+		LongBreak:      15 * time.Minute,
+		LongBreakEvery: 4,
+	}
+
+	var lengths []time.Duration
+	for number := 1; number <= 8; number++ {
+		due := schedule.describe(number, 0)
+		assert.Equal(t, number%4 == 0, due.Long)
+		lengths = append(lengths, due.Length)
+	}
+	short, long := 5*time.Minute, 15*time.Minute
+	assert.Equal(t, []time.Duration{short, short, short, long, short, short, short, long}, lengths)
+}
+
+func TestSession_BreakCompliance(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	end := func(minutes int) *time.Time { t := at(minutes); return &t }
+
+	session := &Session{
+		StartTime: start,
+		State:     StateRunning,
+		Intervals: []Interval{
+			{Start: at(0), End: end(55)},   // paused 5m after the reminder
+			{Start: at(60), End: end(180)}, // reminder ignored for an hour
+			{Start: at(190)},               // still running
+		},
+		BreakReminders: []time.Time{at(50), at(110), at(240)},
+	}
+
+	reminders, taken := session.BreakCompliance()
+	assert.Equal(t, 3, reminders)
+	assert.Equal(t, 1, taken)
+	assert.Equal(t, 2*time.Hour, session.LongestStretch(at(200)))
+}
+
+func TestTracker_RecordBreakReminder(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	_, err := tracker.RecordBreakReminder(time.Now())
+	assert.Error(t, err, "no active session")
+
+	started, err := tracker.Start("api")
+	require.NoError(t, err)
+
+	at := time.Now()
+	_, err = tracker.RecordBreakReminder(at)
+	require.NoError(t, err)
+
+	stored, err := tracker.GetSession(started.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.BreakReminders, 1)
+	assert.True(t, stored.BreakReminders[0].Equal(at))
+}
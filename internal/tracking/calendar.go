@@ -0,0 +1,157 @@
+package tracking
+
+import (
+	"time"
+)
+
+// Calendar computes day, week and month boundaries in a timezone. Boundaries
+// are built with time.Date, so days that are 23 or 25 hours long because of a
+// DST change still start at local midnight.
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
+
+// DefaultCalendar uses the system timezone and weeks starting on Sunday
+func DefaultCalendar() Calendar {
+	return Calendar{Location: time.Local, WeekStart: time.Sunday}
+}
+
+// location returns the calendar's timezone, defaulting to local time
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// StartOfDay returns midnight at the start of t's day
+func (c Calendar) StartOfDay(t time.Time) time.Time {
+	t = t.In(c.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location())
+}
+
+// StartOfWeek returns midnight at the start of t's week
+func (c Calendar) StartOfWeek(t time.Time) time.Time {
+	day := c.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(c.WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfMonth returns midnight on the first day of t's month
+func (c Calendar) StartOfMonth(t time.Time) time.Time {
+	t = t.In(c.location())
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.location())
+}
+
+// Day returns the bounds [start, end) of t's day
+func (c Calendar) Day(t time.Time) (time.Time, time.Time) {
+	start := c.StartOfDay(t)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Week returns the bounds [start, end) of t's week
+func (c Calendar) Week(t time.Time) (time.Time, time.Time) {
+	start := c.StartOfWeek(t)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// Month returns the bounds [start, end) of t's month
+func (c Calendar) Month(t time.Time) (time.Time, time.Time) {
+	start := c.StartOfMonth(t)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// SplitByDay splits a stopped session at midnight into one piece per day,
+// clipped to [from, to). A zero from or to leaves that side unclipped. Each
+// piece is a copy of the session whose bounds, intervals and Duration cover
+// only that day; days without worked time are left out.
+func (c Calendar) SplitByDay(session *Session, from, to time.Time) []*Session {
+	start, end := session.Span(time.Now())
+	if !from.IsZero() && start.Before(from) {
+		start = from
+	}
+	if !to.IsZero() && end.After(to) {
+		end = to
+	}
+
+	var pieces []*Session
+	for day := c.StartOfDay(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		pieceStart, pieceEnd := day, day.AddDate(0, 0, 1)
+		if pieceStart.Before(start) {
+			pieceStart = start.In(c.location())
+		}
+		if pieceEnd.After(end) {
+			pieceEnd = end.In(c.location())
+		}
+		if !pieceStart.Before(pieceEnd) {
+			continue
+		}
+
+		piece := *session
+		piece.StartTime = pieceStart
+		piece.EndTime = &pieceEnd
+		piece.Intervals = clipIntervals(session.Intervals, pieceStart, pieceEnd)
+		piece.BreakReminders = clipTimes(session.BreakReminders, pieceStart, pieceEnd)
+		piece.Duration = piece.WorkedDuration(pieceEnd)
+		if piece.Duration <= 0 {
+			continue
+		}
+
+		pieces = append(pieces, &piece)
+	}
+
+	return pieces
+}
+
+// clipTimes returns the times within [from, to)
+func clipTimes(times []time.Time, from, to time.Time) []time.Time {
+	var clipped []time.Time
+	for _, t := range times {
+		if !t.Before(from) && t.Before(to) {
+			clipped = append(clipped, t)
+		}
+	}
+	return clipped
+}
+
+// WorkedBetween returns the time worked during [from, to). Both bounds must be set.
+func (s *Session) WorkedBetween(from, to, now time.Time) time.Duration {
+	var total time.Duration
+	for _, interval := range clipIntervals(s.Intervals, from, to) {
+		end := now
+		if interval.End != nil {
+			end = *interval.End
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(interval.Start) {
+			total += end.Sub(interval.Start)
+		}
+	}
+	return total
+}
+
+// clipIntervals returns the parts of intervals that fall within [from, to).
+// Open intervals stay open.
+func clipIntervals(intervals []Interval, from, to time.Time) []Interval {
+	var clipped []Interval
+	for _, interval := range intervals {
+		if !interval.Start.Before(to) {
+			continue
+		}
+		if interval.End != nil && !interval.End.After(from) {
+			continue
+		}
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.End != nil && interval.End.After(to) {
+			end := to
+			interval.End = &end
+		}
+		clipped = append(clipped, interval)
+	}
+	return clipped
+}
@@ -0,0 +1,101 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_DayBoundariesAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	calendar := Calendar{Location: loc, WeekStart: time.Sunday}
+
+	// Clocks spring forward on 2026-03-08, so that day is 23 hours long
+	start, end := calendar.Day(time.Date(2026, 3, 8, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 3, 8, 0, 0, 0, 0, loc), start)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, loc), end)
+	assert.Equal(t, 23*time.Hour, end.Sub(start))
+
+	// A UTC instant is placed on the calendar's local day
+	start, _ = calendar.Day(time.Date(2026, 3, 9, 3, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 3, 8, 0, 0, 0, 0, loc), start)
+}
+
+func TestCalendar_WeekStart(t *testing.T) {
+	wednesday := time.Date(2026, 9, 16, 9, 0, 0, 0, time.UTC)
+
+	sundays := Calendar{Location: time.UTC, WeekStart: time.Sunday}
+	assert.Equal(t, time.Date(2026, 9, 13, 0, 0, 0, 0, time.UTC), sundays.StartOfWeek(wednesday))
+
+	mondays := Calendar{Location: time.UTC, WeekStart: time.Monday}
+	assert.Equal(t, time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC), mondays.StartOfWeek(wednesday))
+
+	sunday := time.Date(2026, 9, 20, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC), mondays.StartOfWeek(sunday))
+}
+
+func TestCalendar_SplitByDay(t *testing.T) {
+	calendar := Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+	// 22:00 - 02:30 with a break from 23:30 to 00:30
+	start := time.Date(2026, 9, 14, 22, 0, 0, 0, time.UTC)
+	pause := start.Add(90 * time.Minute)
+	resume := pause.Add(time.Hour)
+	end := time.Date(2026, 9, 15, 2, 30, 0, 0, time.UTC)
+	session := &Session{
+		ID:        "overnight",
+		Project:   "api",
+		StartTime: start,
+		EndTime:   &end,
+		State:     StateStopped,
+		Intervals: []Interval{{Start: start, End: &pause}, {Start: resume, End: &end}},
+		// A break reminder on each side of midnight
+		BreakReminders: []time.Time{start.Add(80 * time.Minute), resume.Add(50 * time.Minute)},
+	}
+	session.Duration = session.WorkedDuration(end)
+
+	pieces := calendar.SplitByDay(session, time.Time{}, time.Time{})
+	require.Len(t, pieces, 2)
+	assert.Equal(t, start, pieces[0].StartTime)
+	assert.Equal(t, 90*time.Minute, pieces[0].Duration)
+	assert.Equal(t, time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC), pieces[1].StartTime)
+	assert.Equal(t, 2*time.Hour, pieces[1].Duration)
+	assert.Equal(t, session.Duration, pieces[0].Duration+pieces[1].Duration)
+	assert.Equal(t, session.BreakReminders[:1], pieces[0].BreakReminders, "reminders stay with their day")
+	assert.Equal(t, session.BreakReminders[1:], pieces[1].BreakReminders)
+
+	// Clipping to the second day keeps only its piece
+	from := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	pieces = calendar.SplitByDay(session, from, from.AddDate(0, 0, 1))
+	require.Len(t, pieces, 1)
+	assert.Equal(t, 2*time.Hour, pieces[0].Duration)
+
+	// The original session is left untouched
+	assert.Len(t, session.Intervals, 2)
+	assert.Equal(t, end, *session.EndTime)
+}
+
+func TestTracker_DailyTotalSplitsAtMidnight(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	tracker.SetCalendar(Calendar{Location: loc, WeekStart: time.Monday})
+
+	// A session from 23:00 yesterday to 01:00 today counts one hour today
+	today, _ := tracker.Calendar().Day(time.Now())
+	_, err = tracker.AddSession("api", today.Add(-time.Hour), today.Add(time.Hour), nil, "")
+	require.NoError(t, err)
+
+	total, err := tracker.workedBetween(tracker.Calendar().Day(today))
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, total)
+
+	total, err = tracker.workedBetween(tracker.Calendar().Day(today.Add(-time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, total)
+}
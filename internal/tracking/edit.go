@@ -0,0 +1,223 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// GetSession returns the session with the given ID
+func (t *Tracker) GetSession(id string) (*Session, error) {
+	var session *Session
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+
+		session = &Session{}
+		if err := json.Unmarshal(data, session); err != nil {
+			return err
+		}
+		session.ensureIntervals()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+
+	return session, nil
+}
+
+// AddSession records a completed session that was not tracked live
+func (t *Tracker) AddSession(project string, start, end time.Time, tags []string, note string) (*Session, error) {
+	session := &Session{
+		ID:        generateSessionID(),
+		Project:   project,
+		StartTime: start,
+		EndTime:   &end,
+		State:     StateStopped,
+		Intervals: []Interval{{Start: start, End: &end}},
+		Tags:      NormalizeTags(tags),
+		Note:      note,
+	}
+	session.Duration = session.WorkedDuration(end)
+
+	if err := ValidateSession(session); err != nil {
+		return nil, err
+	}
+
+	if err := t.saveSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// UpdateSession saves changes to an existing session. If the session is the
+// current one, the current session record is updated as well.
+func (t *Tracker) UpdateSession(session *Session) error {
+	if _, err := t.GetSession(session.ID); err != nil {
+		return err
+	}
+
+	session.Tags = NormalizeTags(session.Tags)
+	if err := ValidateSession(session); err != nil {
+		return err
+	}
+	if session.State == StateStopped {
+		session.Duration = session.WorkedDuration(*session.EndTime)
+	}
+
+	if err := t.saveSession(session); err != nil {
+		return err
+	}
+
+	current, err := t.GetCurrentSession()
+	if err != nil {
+		return err
+	}
+	if current != nil && current.ID == session.ID {
+		return t.setCurrentSession(session)
+	}
+
+	return nil
+}
+
+// DeleteSession removes a session. The current session must be stopped first.
+func (t *Tracker) DeleteSession(id string) error {
+	current, err := t.GetCurrentSession()
+	if err != nil {
+		return err
+	}
+	if current != nil && current.ID == id {
+		return fmt.Errorf("session %s is still active; stop it before deleting", id)
+	}
+
+	if _, err := t.GetSession(id); err != nil {
+		return err
+	}
+
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		return removeSession(tx, id)
+	})
+}
+
+// FindOverlaps returns the sessions whose time span overlaps the given session
+func (t *Tracker) FindOverlaps(session *Session) ([]*Session, error) {
+	now := time.Now()
+	start, end := session.Span(now)
+
+	var overlaps []*Session
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		// Sessions starting up to the longest recorded span earlier may still overlap
+		from := start.Add(-getMaxSpan(tx))
+
+		return forEachIndexed(tx, "", from, end, func(other *Session) bool {
+			if other.ID == session.ID {
+				return true
+			}
+
+			otherStart, otherEnd := other.Span(now)
+			if otherStart.Before(end) && start.Before(otherEnd) {
+				overlaps = append(overlaps, other)
+			}
+			return true
+		})
+	})
+
+	return overlaps, err
+}
+
+// ValidateSession checks that a session's times are consistent
+func ValidateSession(session *Session) error {
+	if session.Project == "" {
+		return fmt.Errorf("session project cannot be empty")
+	}
+	if session.StartTime.IsZero() {
+		return fmt.Errorf("session start time cannot be empty")
+	}
+
+	if session.State == StateStopped {
+		if session.EndTime == nil {
+			return fmt.Errorf("stopped session must have an end time")
+		}
+		if !session.EndTime.After(session.StartTime) {
+			return fmt.Errorf("session ends (%s) before it starts (%s)",
+				session.EndTime.Format(time.RFC3339), session.StartTime.Format(time.RFC3339))
+		}
+	} else if session.StartTime.After(time.Now()) {
+		return fmt.Errorf("active session cannot start in the future")
+	}
+
+	for i, interval := range session.Intervals {
+		if interval.End != nil && interval.End.Before(interval.Start) {
+			return fmt.Errorf("interval %d ends before it starts", i+1)
+		}
+		if i > 0 {
+			prev := session.Intervals[i-1]
+			if prev.End == nil || interval.Start.Before(*prev.End) {
+				return fmt.Errorf("interval %d overlaps the previous interval", i+1)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Span returns when the session started and ended. Active sessions end now.
+func (s *Session) Span(now time.Time) (time.Time, time.Time) {
+	if s.EndTime != nil {
+		return s.StartTime, *s.EndTime
+	}
+	return s.StartTime, now
+}
+
+// SetBounds moves the start and (for stopped sessions) the end of a session,
+// clipping its work intervals to the new span. Intervals are stretched when the
+// span grows, so the first interval begins at start and the last ends at end.
+func (s *Session) SetBounds(start time.Time, end *time.Time) {
+	s.ensureIntervals()
+
+	var clipped []Interval
+	for _, interval := range s.Intervals {
+		if end != nil && !interval.Start.Before(*end) {
+			continue
+		}
+		if interval.End != nil && !interval.End.After(start) {
+			continue
+		}
+		if interval.Start.Before(start) {
+			interval.Start = start
+		}
+		if end != nil && interval.End != nil && interval.End.After(*end) {
+			e := *end
+			interval.End = &e
+		}
+		clipped = append(clipped, interval)
+	}
+
+	if len(clipped) == 0 {
+		clipped = []Interval{{Start: start}}
+		if end != nil {
+			e := *end
+			clipped[0].End = &e
+		}
+	}
+
+	clipped[0].Start = start
+	if end != nil {
+		e := *end
+		clipped[len(clipped)-1].End = &e
+		s.EndTime = &e
+	}
+
+	s.StartTime = start
+	s.Intervals = clipped
+}
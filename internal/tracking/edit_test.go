@@ -0,0 +1,131 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_AddSession(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	end := start.Add(90 * time.Minute)
+
+	session, err := tracker.AddSession("api", start, end, []string{"review"}, "backfill")
+	require.NoError(t, err)
+	assert.Equal(t, StateStopped, session.State)
+	assert.Equal(t, 90*time.Minute, session.Duration)
+
+	loaded, err := tracker.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api", loaded.Project)
+	assert.Equal(t, []string{"review"}, loaded.Tags)
+	assert.Equal(t, "backfill", loaded.Note)
+
+	// Sessions must not end before they start
+	_, err = tracker.AddSession("api", end, start, nil, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "before it starts")
+}
+
+func TestTracker_UpdateSession(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	session, err := tracker.AddSession("api", start, start.Add(14*time.Hour), nil, "")
+	require.NoError(t, err)
+
+	// Fix a session that was left running overnight
+	end := start.Add(8 * time.Hour)
+	session.SetBounds(start, &end)
+	session.Project = "web"
+	require.NoError(t, tracker.UpdateSession(session))
+
+	loaded, err := tracker.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "web", loaded.Project)
+	assert.Equal(t, 8*time.Hour, loaded.Duration)
+	assert.True(t, loaded.EndTime.Equal(end))
+
+	// Invalid edits are rejected
+	badEnd := start.Add(-time.Hour)
+	loaded.SetBounds(start, &badEnd)
+	assert.Error(t, tracker.UpdateSession(loaded))
+
+	_, err = tracker.GetSession("missing")
+	assert.Error(t, err)
+}
+
+func TestTracker_DeleteSession(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	session, err := tracker.AddSession("api", start, start.Add(time.Hour), nil, "")
+	require.NoError(t, err)
+
+	require.NoError(t, tracker.DeleteSession(session.ID))
+	_, err = tracker.GetSession(session.ID)
+	assert.Error(t, err)
+
+	// The active session cannot be deleted
+	active, err := tracker.Start("api")
+	require.NoError(t, err)
+	err = tracker.DeleteSession(active.ID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "still active")
+}
+
+func TestTracker_FindOverlaps(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	first, err := tracker.AddSession("api", start, start.Add(2*time.Hour), nil, "")
+	require.NoError(t, err)
+	_, err = tracker.AddSession("api", start.Add(3*time.Hour), start.Add(4*time.Hour), nil, "")
+	require.NoError(t, err)
+
+	overlapping, err := tracker.AddSession("web", start.Add(time.Hour), start.Add(150*time.Minute), nil, "")
+	require.NoError(t, err)
+
+	overlaps, err := tracker.FindOverlaps(overlapping)
+	require.NoError(t, err)
+	require.Len(t, overlaps, 1)
+	assert.Equal(t, first.ID, overlaps[0].ID)
+}
+
+func TestSession_SetBounds(t *testing.T) {
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		ts := base.Add(time.Duration(minutes) * time.Minute)
+		return &ts
+	}
+
+	session := &Session{
+		StartTime: base,
+		EndTime:   at(180),
+		State:     StateStopped,
+		Intervals: []Interval{
+			{Start: base, End: at(60)},
+			{Start: *at(90), End: at(180)},
+		},
+	}
+
+	// Shrinking the end drops time after it but keeps the break
+	session.SetBounds(*at(30), at(120))
+	require.Len(t, session.Intervals, 2)
+	assert.True(t, session.StartTime.Equal(*at(30)))
+	assert.Equal(t, 60*time.Minute, session.WorkedDuration(*at(200)))
+	assert.Equal(t, 1, session.Breaks())
+
+	// Moving the end before the break removes the later interval
+	session.SetBounds(*at(30), at(45))
+	require.Len(t, session.Intervals, 1)
+	assert.Equal(t, 15*time.Minute, session.WorkedDuration(*at(200)))
+}
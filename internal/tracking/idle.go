@@ -12,12 +12,25 @@ import (
 // IdleDetector handles idle time detection across platforms
 type IdleDetector struct {
 	threshold time.Duration
+	// sources are tried in order on Linux; the first that works is used
+	sources []IdleSource
 }
 
 // NewIdleDetector creates a new idle detector with the given threshold
 func NewIdleDetector(threshold time.Duration) *IdleDetector {
+	return NewIdleDetectorWithSources(threshold, nil)
+}
+
+// NewIdleDetectorWithSources creates an idle detector that asks the given
+// sources instead of picking them for the current environment. Nil picks
+// them, now, so the detector can be shared between goroutines.
+func NewIdleDetectorWithSources(threshold time.Duration, sources []IdleSource) *IdleDetector {
+	if sources == nil {
+		sources = DefaultIdleSources()
+	}
 	return &IdleDetector{
 		threshold: threshold,
+		sources:   sources,
 	}
 }
 
@@ -78,46 +91,21 @@ func (id *IdleDetector) getIdleTimeMacOS() (time.Duration, error) {
 	return 0, fmt.Errorf("could not find HIDIdleTime in ioreg output")
 }
 
-// getIdleTimeLinux gets idle time on Linux using xprintidle or similar
+// getIdleTimeLinux asks each idle source in turn and returns the first answer
 func (id *IdleDetector) getIdleTimeLinux() (time.Duration, error) {
-	// Try xprintidle first (most common)
-	cmd := exec.Command("xprintidle")
-	output, err := cmd.Output()
-	if err == nil {
-		idleMs, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
-		if err != nil {
-			return 0, fmt.Errorf("failed to parse xprintidle output: %w", err)
+	var failures []string
+	for _, source := range id.sources {
+		idle, err := source.IdleTime()
+		if err == nil {
+			return idle, nil
 		}
-		return time.Duration(idleMs) * time.Millisecond, nil
+		failures = append(failures, fmt.Sprintf("%s: %v", source.Name(), err))
 	}
 
-	// Try xssstate as fallback
-	cmd = exec.Command("xssstate", "-i")
-	output, err = cmd.Output()
-	if err == nil {
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "idle:") {
-				parts := strings.Fields(line)
-				if len(parts) >= 2 {
-					idleMs, err := strconv.ParseInt(parts[1], 10, 64)
-					if err == nil {
-						return time.Duration(idleMs) * time.Millisecond, nil
-					}
-				}
-			}
-		}
+	if len(failures) == 0 {
+		return 0, fmt.Errorf("no idle sources configured")
 	}
-
-	// Try parsing /proc/uptime and /proc/stat as last resort
-	return id.getIdleTimeLinuxProc()
-}
-
-// getIdleTimeLinuxProc gets idle time from /proc filesystem (less accurate)
-func (id *IdleDetector) getIdleTimeLinuxProc() (time.Duration, error) {
-	// This is a simplified approach - in practice, calculating true idle time
-	// from /proc/stat is complex and not very accurate for user idle detection
-	return 0, fmt.Errorf("xprintidle not available and /proc method not implemented")
+	return 0, fmt.Errorf("no idle source available (%s)", strings.Join(failures, "; "))
 }
 
 // getIdleTimeWindows gets idle time on Windows using GetLastInputInfo
@@ -165,7 +153,7 @@ Write-Output $idleTime
 }
 
 // StartIdleMonitoring starts monitoring for idle state changes
-func (id *IdleDetector) StartIdleMonitoring(onIdleStart, onIdleEnd func()) chan struct{} {
+func (id *IdleDetector) StartIdleMonitoring(onIdleStart, onIdleEnd func(idle time.Duration)) chan struct{} {
 	stop := make(chan struct{})
 
 	go func() {
@@ -179,22 +167,23 @@ func (id *IdleDetector) StartIdleMonitoring(onIdleStart, onIdleEnd func()) chan
 			case <-stop:
 				return
 			case <-ticker.C:
-				isIdle, err := id.IsIdle()
+				idle, err := id.GetIdleTime()
 				if err != nil {
 					// Log error but continue monitoring
 					continue
 				}
+				isIdle := idle >= id.threshold
 
 				if isIdle && !wasIdle {
-					// Just became idle
+					// Just became idle; the idle time says when it began
 					if onIdleStart != nil {
-						onIdleStart()
+						onIdleStart(idle)
 					}
 					wasIdle = true
 				} else if !isIdle && wasIdle {
-					// Just became active
+					// Just became active; the idle time says when input resumed
 					if onIdleEnd != nil {
-						onIdleEnd()
+						onIdleEnd(idle)
 					}
 					wasIdle = false
 				}
@@ -0,0 +1,16 @@
+package tracking
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileAccessInfo returns a file's access time and owner
+func fileAccessInfo(info os.FileInfo) (time.Time, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime(), -1, false
+	}
+	return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), int(stat.Uid), true
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package tracking
+
+import (
+	"os"
+	"time"
+)
+
+// fileAccessInfo returns a file's modification time; access times and owners
+// are only read on Linux, where the tty and input idle sources apply
+func fileAccessInfo(info os.FileInfo) (time.Time, int, bool) {
+	return info.ModTime(), -1, false
+}
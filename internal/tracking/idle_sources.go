@@ -0,0 +1,381 @@
+package tracking
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdleSource reports how long the user has been idle. Sources return an error
+// when they cannot tell, so the detector can try the next one.
+type IdleSource interface {
+	Name() string
+	IdleTime() (time.Duration, error)
+}
+
+// Idle source names accepted in settings.idle_sources
+const (
+	IdleSourceX11        = "x11"
+	IdleSourceLogind     = "logind"
+	IdleSourceInput      = "input"
+	IdleSourceInterrupts = "interrupts"
+	IdleSourceTTY        = "tty"
+)
+
+// IdleSourceNames lists the available idle sources
+var IdleSourceNames = []string{IdleSourceX11, IdleSourceLogind, IdleSourceInput, IdleSourceInterrupts, IdleSourceTTY}
+
+// commandRunner runs a command and returns its standard output
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+// runCommand is the default commandRunner
+func runCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// NewIdleSource creates the idle source with the given name
+func NewIdleSource(name string) (IdleSource, error) {
+	switch name {
+	case IdleSourceX11:
+		return &X11IdleSource{}, nil
+	case IdleSourceLogind:
+		return &LogindIdleSource{}, nil
+	case IdleSourceInput:
+		return &InputIdleSource{}, nil
+	case IdleSourceInterrupts:
+		return &InterruptsIdleSource{}, nil
+	case IdleSourceTTY:
+		return &TTYIdleSource{}, nil
+	default:
+		return nil, fmt.Errorf("unknown idle source %q (available: %s)", name, strings.Join(IdleSourceNames, ", "))
+	}
+}
+
+// DefaultIdleSources picks Linux idle sources for the current environment:
+// X11 sessions use xprintidle, Wayland sessions ask logind, and SSH or
+// headless sessions watch terminal activity. Each list ends with a source
+// that works without a desktop.
+func DefaultIdleSources() []IdleSource {
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		return []IdleSource{&LogindIdleSource{}, &InputIdleSource{}, &InterruptsIdleSource{}}
+	case os.Getenv("DISPLAY") != "":
+		return []IdleSource{&X11IdleSource{}, &LogindIdleSource{}, &InterruptsIdleSource{}}
+	default:
+		return []IdleSource{&TTYIdleSource{}, &InterruptsIdleSource{}}
+	}
+}
+
+// X11IdleSource reads the X server's idle time with xprintidle or xssstate
+type X11IdleSource struct {
+	Run commandRunner
+}
+
+// Name returns the source name
+func (s *X11IdleSource) Name() string { return IdleSourceX11 }
+
+// IdleTime returns the time since the last X input event
+func (s *X11IdleSource) IdleTime() (time.Duration, error) {
+	run := s.Run
+	if run == nil {
+		run = runCommand
+	}
+
+	// Try xprintidle first (most common)
+	if output, err := run("xprintidle"); err == nil {
+		idleMs, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse xprintidle output: %w", err)
+		}
+		return time.Duration(idleMs) * time.Millisecond, nil
+	}
+
+	// Try xssstate as fallback
+	output, err := run("xssstate", "-i")
+	if err != nil {
+		return 0, fmt.Errorf("neither xprintidle nor xssstate is available")
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.Contains(line, "idle:") {
+			if idleMs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				return time.Duration(idleMs) * time.Millisecond, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("could not parse xssstate output")
+}
+
+// LogindIdleSource reads the IdleHint and IdleSinceHint properties of the
+// current logind session over D-Bus (via loginctl). Desktops such as GNOME and
+// KDE set the hint on Wayland, where X11 tools cannot see input.
+type LogindIdleSource struct {
+	// SessionID defaults to $XDG_SESSION_ID, then to logind's "auto" session
+	SessionID string
+	Run       commandRunner
+	Now       func() time.Time
+}
+
+// Name returns the source name
+func (s *LogindIdleSource) Name() string { return IdleSourceLogind }
+
+// IdleTime returns how long logind has considered the session idle
+func (s *LogindIdleSource) IdleTime() (time.Duration, error) {
+	run := s.Run
+	if run == nil {
+		run = runCommand
+	}
+	session := s.SessionID
+	if session == "" {
+		session = os.Getenv("XDG_SESSION_ID")
+	}
+	if session == "" {
+		session = "auto"
+	}
+
+	output, err := run("loginctl", "show-session", session, "-p", "IdleHint", "-p", "IdleSinceHint")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query logind session %s: %w", session, err)
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+
+	switch props["IdleHint"] {
+	case "no":
+		return 0, nil
+	case "yes":
+	default:
+		return 0, fmt.Errorf("logind did not report IdleHint for session %s", session)
+	}
+
+	// IdleSinceHint is a CLOCK_REALTIME timestamp in microseconds
+	sinceMicros, err := strconv.ParseInt(props["IdleSinceHint"], 10, 64)
+	if err != nil || sinceMicros <= 0 {
+		return 0, fmt.Errorf("logind reported an invalid IdleSinceHint %q", props["IdleSinceHint"])
+	}
+	idle := nowFunc(s.Now)().Sub(time.UnixMicro(sinceMicros))
+	if idle < 0 {
+		idle = 0
+	}
+	return idle, nil
+}
+
+// InputIdleSource measures idle time from the access and modification times
+// of input device nodes, which change as the compositor reads events
+type InputIdleSource struct {
+	// Dir defaults to /dev/input
+	Dir string
+	Now func() time.Time
+}
+
+// Name returns the source name
+func (s *InputIdleSource) Name() string { return IdleSourceInput }
+
+// IdleTime returns the time since any input device was last used
+func (s *InputIdleSource) IdleTime() (time.Duration, error) {
+	dir := s.Dir
+	if dir == "" {
+		dir = "/dev/input"
+	}
+
+	devices, err := filepath.Glob(filepath.Join(dir, "event*"))
+	if err != nil || len(devices) == 0 {
+		return 0, fmt.Errorf("no input devices found in %s", dir)
+	}
+
+	latest, found := latestActivity(devices, -1)
+	if !found {
+		return 0, fmt.Errorf("could not read input devices in %s", dir)
+	}
+	return sinceActivity(nowFunc(s.Now)(), latest), nil
+}
+
+// defaultInputInterrupts match /proc/interrupts lines for keyboards, mice,
+// touchpads and the USB controllers they are usually attached to
+var defaultInputInterrupts = []string{"i8042", "hid", "keyboard", "mouse", "touchpad", "xhci", "ehci", "ohci", "uhci"}
+
+// InterruptsIdleSource watches the interrupt counters of input devices in
+// /proc/interrupts and reports the time since they last changed. It needs
+// two samples, so the first call reports the user as active; it is most
+// useful to a long-running monitor.
+type InterruptsIdleSource struct {
+	// Path defaults to /proc/interrupts
+	Path string
+	// Match selects interrupt lines by device name (case-insensitive)
+	Match []string
+	Now   func() time.Time
+
+	mu         sync.Mutex
+	lastCount  uint64
+	lastChange time.Time
+}
+
+// Name returns the source name
+func (s *InterruptsIdleSource) Name() string { return IdleSourceInterrupts }
+
+// IdleTime returns the time since the input interrupt counters last changed
+func (s *InterruptsIdleSource) IdleTime() (time.Duration, error) {
+	path := s.Path
+	if path == "" {
+		path = "/proc/interrupts"
+	}
+	match := s.Match
+	if match == nil {
+		match = defaultInputInterrupts
+	}
+
+	count, err := countInterrupts(path, match)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := nowFunc(s.Now)()
+	if s.lastChange.IsZero() || count != s.lastCount {
+		s.lastCount = count
+		s.lastChange = now
+	}
+	return now.Sub(s.lastChange), nil
+}
+
+// countInterrupts sums the per-CPU counts of interrupt lines matching names
+func countInterrupts(path string, names []string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var total uint64
+	matched := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lower := strings.ToLower(line)
+
+		relevant := false
+		for _, name := range names {
+			if strings.Contains(lower, strings.ToLower(name)) {
+				relevant = true
+				break
+			}
+		}
+		if !relevant {
+			continue
+		}
+
+		// "  1:   9   0   IR-IO-APIC   1-edge   i8042": counts follow the IRQ label
+		fields := strings.Fields(line)
+		for _, field := range fields[1:] {
+			n, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				break
+			}
+			total += n
+			matched = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !matched {
+		return 0, fmt.Errorf("no input device interrupts found in %s", path)
+	}
+
+	return total, nil
+}
+
+// TTYIdleSource measures idle time from the access times of the user's
+// terminals, like the IDLE column of `w`. It works over SSH and on consoles
+// without a desktop session.
+type TTYIdleSource struct {
+	// Patterns default to /dev/pts/* and /dev/tty[0-9]*
+	Patterns []string
+	// UID selects terminals owned by this user; defaults to the current user
+	UID *int
+	Now func() time.Time
+}
+
+// Name returns the source name
+func (s *TTYIdleSource) Name() string { return IdleSourceTTY }
+
+// IdleTime returns the time since the user last typed in any terminal
+func (s *TTYIdleSource) IdleTime() (time.Duration, error) {
+	patterns := s.Patterns
+	if patterns == nil {
+		patterns = []string{"/dev/pts/[0-9]*", "/dev/tty[0-9]*"}
+	}
+	uid := os.Getuid()
+	if s.UID != nil {
+		uid = *s.UID
+	}
+
+	var terminals []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		terminals = append(terminals, matches...)
+	}
+
+	latest, found := latestActivity(terminals, uid)
+	if !found {
+		return 0, fmt.Errorf("no terminals owned by the current user")
+	}
+	return sinceActivity(nowFunc(s.Now)(), latest), nil
+}
+
+// latestActivity returns the most recent access or modification time among
+// paths, only considering files owned by uid unless uid is negative
+func latestActivity(paths []string, uid int) (time.Time, bool) {
+	var latest time.Time
+	found := false
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		accessed, owner, ok := fileAccessInfo(info)
+		if uid >= 0 && (!ok || owner != uid) {
+			continue
+		}
+		if info.ModTime().After(accessed) {
+			accessed = info.ModTime()
+		}
+		if !found || accessed.After(latest) {
+			latest = accessed
+			found = true
+		}
+	}
+
+	return latest, found
+}
+
+// sinceActivity returns the idle time given the last activity
+func sinceActivity(now, last time.Time) time.Duration {
+	if idle := now.Sub(last); idle > 0 {
+		return idle
+	}
+	return 0
+}
+
+// nowFunc returns now, defaulting to time.Now
+func nowFunc(now func() time.Time) func() time.Time {
+	if now == nil {
+		return time.Now
+	}
+	return now
+}
@@ -0,0 +1,210 @@
+package tracking
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner returns canned output for commands, keyed by the command line
+func fakeRunner(outputs map[string]string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		line := strings.Join(append([]string{name}, args...), " ")
+		output, ok := outputs[line]
+		if !ok {
+			return nil, errors.New("command not found")
+		}
+		return []byte(output), nil
+	}
+}
+
+// fixedNow returns a clock stopped at t
+func fixedNow(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+type fakeIdleSource struct {
+	name string
+	idle time.Duration
+	err  error
+}
+
+func (s fakeIdleSource) Name() string                     { return s.name }
+func (s fakeIdleSource) IdleTime() (time.Duration, error) { return s.idle, s.err }
+
+func TestNewIdleSource(t *testing.T) {
+	for _, name := range IdleSourceNames {
+		source, err := NewIdleSource(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, name, source.Name())
+	}
+
+	_, err := NewIdleSource("xidle")
+	assert.Error(t, err)
+}
+
+func TestIdleDetector_TriesSourcesInOrder(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("idle sources are only used on Linux")
+	}
+
+	detector := NewIdleDetectorWithSources(5*time.Minute, []IdleSource{
+		fakeIdleSource{name: "broken", err: errors.New("unavailable")},
+		fakeIdleSource{name: "working", idle: 10 * time.Minute},
+		fakeIdleSource{name: "unused", idle: time.Minute},
+	})
+
+	idle, err := detector.GetIdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, idle)
+	isIdle, err := detector.IsIdle()
+	require.NoError(t, err)
+	assert.True(t, isIdle)
+
+	detector = NewIdleDetectorWithSources(5*time.Minute, []IdleSource{
+		fakeIdleSource{name: "broken", err: errors.New("unavailable")},
+	})
+	_, err = detector.GetIdleTime()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken: unavailable")
+}
+
+func TestX11IdleSource(t *testing.T) {
+	source := &X11IdleSource{Run: fakeRunner(map[string]string{"xprintidle": "90000\n"})}
+	idle, err := source.IdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, idle)
+
+	source = &X11IdleSource{Run: fakeRunner(map[string]string{"xssstate -i": "state: on\nidle: 4000\n"})}
+	idle, err = source.IdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Second, idle)
+
+	source = &X11IdleSource{Run: fakeRunner(nil)}
+	_, err = source.IdleTime()
+	assert.Error(t, err)
+}
+
+func TestLogindIdleSource(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-7 * time.Minute)
+
+	tests := []struct {
+		name    string
+		output  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"idle", "IdleHint=yes\nIdleSinceHint=" + strconv.FormatInt(since.UnixMicro(), 10) + "\n", 7 * time.Minute, false},
+		{"active", "IdleHint=no\nIdleSinceHint=0\n", 0, false},
+		{"missing hint", "", 0, true},
+		{"idle without timestamp", "IdleHint=yes\nIdleSinceHint=0\n", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &LogindIdleSource{
+				SessionID: "c2",
+				Run: fakeRunner(map[string]string{
+					"loginctl show-session c2 -p IdleHint -p IdleSinceHint": test.output,
+				}),
+				Now: fixedNow(now),
+			}
+
+			idle, err := source.IdleTime()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, idle)
+		})
+	}
+}
+
+func TestInputIdleSource(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	_, err := (&InputIdleSource{Dir: dir}).IdleTime()
+	assert.Error(t, err, "no devices")
+
+	for name, age := range map[string]time.Duration{"event0": time.Hour, "event1": 3 * time.Minute, "mouse0": 0} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+		require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+
+	idle, err := (&InputIdleSource{Dir: dir, Now: fixedNow(now)}).IdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Minute, idle, "only event devices count")
+}
+
+func TestInterruptsIdleSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "interrupts")
+	write := func(keyboard, disk int) {
+		content := "           CPU0       CPU1\n" +
+			"  1:   " + strconv.Itoa(keyboard) + "   3   IR-IO-APIC    1-edge      i8042\n" +
+			" 27:   " + strconv.Itoa(disk) + "   0   IR-PCI-MSI 512000-edge      ahci[0000:00:17.0]\n" +
+			"NMI:   0   0   Non-maskable interrupts\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	source := &InterruptsIdleSource{Path: path, Now: func() time.Time { return now }}
+
+	write(100, 500)
+	idle, err := source.IdleTime()
+	require.NoError(t, err)
+	assert.Zero(t, idle, "first sample counts as activity")
+
+	// Disk interrupts are not user activity
+	now = now.Add(4 * time.Minute)
+	write(100, 900)
+	idle, err = source.IdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Minute, idle)
+
+	now = now.Add(time.Minute)
+	write(101, 900)
+	idle, err = source.IdleTime()
+	require.NoError(t, err)
+	assert.Zero(t, idle)
+
+	noInput := &InterruptsIdleSource{Path: path, Match: []string{"touchpad"}}
+	_, err = noInput.IdleTime()
+	assert.Error(t, err)
+}
+
+func TestTTYIdleSource(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("terminal owners are only read on Linux")
+	}
+
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	for name, age := range map[string]time.Duration{"0": 20 * time.Minute, "1": 2 * time.Minute} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+		require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-time.Hour)))
+	}
+
+	uid := os.Getuid()
+	source := &TTYIdleSource{Patterns: []string{filepath.Join(dir, "*")}, UID: &uid, Now: fixedNow(now)}
+	idle, err := source.IdleTime()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, idle, "newest access time wins")
+
+	other := uid + 1
+	source.UID = &other
+	_, err = source.IdleTime()
+	assert.Error(t, err, "terminals of other users are ignored")
+}
@@ -0,0 +1,394 @@
+package tracking
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// The sessions bucket is keyed by session ID. Two secondary indexes make
+// time-range and per-project queries possible without decoding every session:
+//
+//   - index_start:   <start time><session ID> -> session ID
+//   - index_project: <project>/<start time><session ID> -> worked duration
+//
+// Start times are encoded as big-endian Unix nanoseconds so keys sort
+// chronologically and bbolt cursors can seek straight to a date.
+var (
+	startIndexBucket   = []byte("index_start")
+	projectIndexBucket = []byte("index_project")
+	maxSpanKey         = []byte("max_span")
+)
+
+// SessionQuery selects stopped sessions from the database
+type SessionQuery struct {
+	// From and To bound the query to sessions overlapping [From, To).
+	// A zero value leaves that side unbounded.
+	From time.Time
+	To   time.Time
+	// Project restricts the query to one project when set. Packages of a
+	// repository ("platform/api") are included when querying the repository
+	// ("platform").
+	Project string
+}
+
+// ProjectMatches reports whether project is filter or one of its packages
+func ProjectMatches(project, filter string) bool {
+	return project == filter || strings.HasPrefix(project, filter+"/")
+}
+
+// QuerySessions returns the stopped sessions matching the query, ordered by
+// start time. Sessions that started before From but were still running at
+// From are included.
+func (t *Tracker) QuerySessions(q SessionQuery) ([]*Session, error) {
+	var sessions []*Session
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		from := q.From
+		if !from.IsZero() {
+			// Look back far enough to catch sessions that started earlier
+			// but overlap the start of the range
+			from = from.Add(-getMaxSpan(tx))
+		}
+
+		collect := func(session *Session) bool {
+			if session.State != StateStopped {
+				return true
+			}
+			if !q.From.IsZero() && session.EndTime != nil && !session.EndTime.After(q.From) {
+				return true
+			}
+			sessions = append(sessions, session)
+			return true
+		}
+
+		if q.Project == "" {
+			return forEachIndexed(tx, "", from, q.To, collect)
+		}
+		for _, project := range indexedProjects(tx, q.Project) {
+			if err := forEachIndexed(tx, project, from, q.To, collect); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Results from several project indexes need merging into start order
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	return sessions, nil
+}
+
+// indexedProjects returns the indexed projects matching a project filter
+func indexedProjects(tx *bbolt.Tx, filter string) []string {
+	var projects []string
+
+	cursor := tx.Bucket(projectIndexBucket).Cursor()
+	for k, _ := cursor.Seek([]byte(filter)); k != nil && bytes.HasPrefix(k, []byte(filter)); k, _ = cursor.Next() {
+		if ProjectMatches(string(k), filter) {
+			projects = append(projects, string(k))
+		}
+	}
+
+	return projects
+}
+
+// workedBetween totals the time worked in stopped sessions during [from, to),
+// counting only the part of a session that falls inside the range
+func (t *Tracker) workedBetween(from, to time.Time) (time.Duration, error) {
+	sessions, err := t.QuerySessions(SessionQuery{From: from, To: to})
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, session := range sessions {
+		total += session.WorkedBetween(from, to, to)
+	}
+	return total, nil
+}
+
+// forEachIndexed calls fn for each session starting in [from, to), in start
+// order, using the project index when a project is given. Iteration stops
+// early when fn returns false.
+func forEachIndexed(tx *bbolt.Tx, project string, from, to time.Time, fn func(*Session) bool) error {
+	var index *bbolt.Bucket
+	if project != "" {
+		index = tx.Bucket(projectIndexBucket).Bucket([]byte(project))
+		if index == nil {
+			return nil
+		}
+	} else {
+		index = tx.Bucket(startIndexBucket)
+	}
+	sessions := tx.Bucket(sessionsBucket)
+
+	var upper []byte
+	if !to.IsZero() {
+		upper = encodeIndexTime(to)
+	}
+
+	cursor := index.Cursor()
+	var k []byte
+	if from.IsZero() {
+		k, _ = cursor.First()
+	} else {
+		k, _ = cursor.Seek(encodeIndexTime(from))
+	}
+
+	for ; k != nil; k, _ = cursor.Next() {
+		if upper != nil && bytes.Compare(k[:8], upper) >= 0 {
+			break
+		}
+
+		data := sessions.Get(k[8:])
+		if data == nil {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+		session.ensureIntervals()
+
+		if !fn(&session) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// recentSessions returns up to limit stopped sessions, most recent first
+func recentSessions(tx *bbolt.Tx, limit int) []*Session {
+	var result []*Session
+	sessions := tx.Bucket(sessionsBucket)
+
+	cursor := tx.Bucket(startIndexBucket).Cursor()
+	for k, _ := cursor.Last(); k != nil; k, _ = cursor.Prev() {
+		data := sessions.Get(k[8:])
+		if data == nil {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+		if session.State != StateStopped {
+			continue
+		}
+		session.ensureIntervals()
+
+		result = append(result, &session)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// projectStats sums worked time per project from the project index
+func projectStats(tx *bbolt.Tx) map[string]time.Duration {
+	stats := make(map[string]time.Duration)
+
+	_ = tx.Bucket(projectIndexBucket).ForEach(func(name, _ []byte) error {
+		project := tx.Bucket(projectIndexBucket).Bucket(name)
+		if project == nil {
+			return nil
+		}
+		return project.ForEach(func(_, v []byte) error {
+			if len(v) == 8 {
+				if d := time.Duration(binary.BigEndian.Uint64(v)); d > 0 {
+					stats[string(name)] += d
+				}
+			}
+			return nil
+		})
+	})
+
+	return stats
+}
+
+// putSession stores a session and keeps the indexes in sync
+func putSession(tx *bbolt.Tx, session *Session) error {
+	bucket := tx.Bucket(sessionsBucket)
+
+	if old := bucket.Get([]byte(session.ID)); old != nil {
+		var previous Session
+		if err := json.Unmarshal(old, &previous); err == nil {
+			if err := unindexSession(tx, &previous); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put([]byte(session.ID), data); err != nil {
+		return err
+	}
+
+	return indexSession(tx, session)
+}
+
+// removeSession deletes a session and its index entries
+func removeSession(tx *bbolt.Tx, id string) error {
+	bucket := tx.Bucket(sessionsBucket)
+
+	if old := bucket.Get([]byte(id)); old != nil {
+		var previous Session
+		if err := json.Unmarshal(old, &previous); err == nil {
+			if err := unindexSession(tx, &previous); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bucket.Delete([]byte(id))
+}
+
+// indexSession adds a session to the start and project indexes
+func indexSession(tx *bbolt.Tx, session *Session) error {
+	key := indexKey(session)
+
+	if err := tx.Bucket(startIndexBucket).Put(key, []byte(session.ID)); err != nil {
+		return err
+	}
+
+	project, err := tx.Bucket(projectIndexBucket).CreateBucketIfNotExists(indexProject(session.Project))
+	if err != nil {
+		return err
+	}
+	var duration time.Duration
+	if session.State == StateStopped {
+		duration = session.Duration
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(duration))
+	if err := project.Put(key, value); err != nil {
+		return err
+	}
+
+	if session.EndTime != nil {
+		return updateMaxSpan(tx, session.EndTime.Sub(session.StartTime))
+	}
+	return nil
+}
+
+// unindexSession removes a session from the start and project indexes
+func unindexSession(tx *bbolt.Tx, session *Session) error {
+	key := indexKey(session)
+
+	if err := tx.Bucket(startIndexBucket).Delete(key); err != nil {
+		return err
+	}
+
+	projects := tx.Bucket(projectIndexBucket)
+	project := projects.Bucket(indexProject(session.Project))
+	if project == nil {
+		return nil
+	}
+	if err := project.Delete(key); err != nil {
+		return err
+	}
+
+	// Drop the project bucket once its last session is gone
+	if k, _ := project.Cursor().First(); k == nil {
+		return projects.DeleteBucket(indexProject(session.Project))
+	}
+	return nil
+}
+
+// indexProject returns the project index bucket for a project. bbolt
+// buckets need a name, so sessions without a project, e.g. imported from
+// a tool that had none, are indexed under "default".
+func indexProject(project string) []byte {
+	if project == "" {
+		return []byte("default")
+	}
+	return []byte(project)
+}
+
+// getMaxSpan returns the longest start-to-end span of any stored session
+func getMaxSpan(tx *bbolt.Tx) time.Duration {
+	data := tx.Bucket(metaBucket).Get(maxSpanKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return time.Duration(binary.BigEndian.Uint64(data))
+}
+
+// updateMaxSpan records span if it is longer than any seen before
+func updateMaxSpan(tx *bbolt.Tx, span time.Duration) error {
+	if span <= getMaxSpan(tx) {
+		return nil
+	}
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(span))
+	return tx.Bucket(metaBucket).Put(maxSpanKey, data)
+}
+
+// indexKey builds the index key for a session: start time followed by ID
+func indexKey(session *Session) []byte {
+	return append(encodeIndexTime(session.StartTime), session.ID...)
+}
+
+// encodeIndexTime encodes a time as sortable big-endian Unix nanoseconds.
+// Times before the Unix epoch sort first.
+func encodeIndexTime(t time.Time) []byte {
+	nanos := t.UnixNano()
+	if nanos < 0 {
+		nanos = 0
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(nanos))
+	return key
+}
+
+// migrateSessionIndexes builds the start and project indexes for databases
+// created before sessions were indexed
+func migrateSessionIndexes(tx *bbolt.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists(startIndexBucket); err != nil {
+		return err
+	}
+	if _, err := tx.CreateBucketIfNotExists(projectIndexBucket); err != nil {
+		return err
+	}
+
+	var sessions []*Session
+	if err := tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+		var session Session
+		if err := json.Unmarshal(v, &session); err != nil {
+			return nil
+		}
+		sessions = append(sessions, &session)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	for _, session := range sessions {
+		if err := indexSession(tx, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
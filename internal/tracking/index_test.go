@@ -0,0 +1,183 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestTracker_QuerySessions(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	add := func(project string, start time.Time, length time.Duration) *Session {
+		session, err := tracker.AddSession(project, start, start.Add(length), nil, "")
+		require.NoError(t, err)
+		return session
+	}
+
+	overnight := add("api", day.Add(-2*time.Hour), 4*time.Hour) // 22:00 - 02:00
+	morning := add("web", day.Add(9*time.Hour), 2*time.Hour)
+	add("api", day.Add(-48*time.Hour), time.Hour)
+	add("api", day.Add(30*time.Hour), time.Hour)
+
+	sessions, err := tracker.QuerySessions(SessionQuery{From: day, To: day.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, overnight.ID, sessions[0].ID)
+	assert.Equal(t, morning.ID, sessions[1].ID)
+
+	sessions, err = tracker.QuerySessions(SessionQuery{From: day, To: day.Add(24 * time.Hour), Project: "api"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, overnight.ID, sessions[0].ID)
+
+	sessions, err = tracker.QuerySessions(SessionQuery{Project: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestTracker_IndexesFollowEdits(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	session, err := tracker.AddSession("api", start, start.Add(time.Hour), nil, "")
+	require.NoError(t, err)
+
+	// Moving a session to another day and project re-indexes it
+	newStart := start.AddDate(0, 0, 1)
+	newEnd := newStart.Add(2 * time.Hour)
+	session.SetBounds(newStart, &newEnd)
+	session.Project = "web"
+	require.NoError(t, tracker.UpdateSession(session))
+
+	sessions, err := tracker.QuerySessions(SessionQuery{From: start, To: start.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	stats, err := tracker.GetProjectStats()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"web": 2 * time.Hour}, stats)
+
+	require.NoError(t, tracker.DeleteSession(session.ID))
+	stats, err = tracker.GetProjectStats()
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestTracker_MigratesIndexes(t *testing.T) {
+	tracker := setupTestTracker(t)
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		_, err := tracker.AddSession(fmt.Sprintf("project-%d", i), start.Add(time.Duration(i)*time.Hour), start.Add(time.Duration(i)*time.Hour+30*time.Minute), nil, "")
+		require.NoError(t, err)
+	}
+
+	// Simulate a database written before the indexes existed
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(startIndexBucket); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(projectIndexBucket); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Delete(schemaVersionKey)
+	}))
+	require.NoError(t, tracker.Close())
+
+	tracker, err := NewTracker()
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	sessions, err := tracker.GetSessionHistory(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, "project-2", sessions[0].Project)
+
+	stats, err := tracker.GetProjectStats()
+	require.NoError(t, err)
+	assert.Len(t, stats, 3)
+}
+
+func TestTracker_MigratesIndexesWithoutProject(t *testing.T) {
+	tracker := setupTestTracker(t)
+
+	// A session without a project, as older imports could write
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	end := start.Add(time.Hour)
+	session := &Session{
+		ID:        "imported",
+		StartTime: start,
+		EndTime:   &end,
+		Duration:  time.Hour,
+		State:     StateStopped,
+		Intervals: []Interval{{Start: start, End: &end}},
+	}
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(sessionsBucket).Put([]byte(session.ID), data); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(startIndexBucket); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(projectIndexBucket); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Delete(schemaVersionKey)
+	}))
+	require.NoError(t, tracker.Close())
+
+	tracker, err = NewTracker()
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	sessions, err := tracker.QuerySessions(SessionQuery{Project: "default"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "imported", sessions[0].ID)
+
+	// Saving and removing it keeps the index in step
+	sessions[0].Note = "edited"
+	require.NoError(t, tracker.saveSession(sessions[0]))
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		return removeSession(tx, "imported")
+	}))
+
+	stats, err := tracker.GetProjectStats()
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestTracker_QuerySessionsIncludesPackages(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	for i, project := range []string{"platform/web", "platform", "platform/api", "platformer"} {
+		sessionStart := start.Add(time.Duration(i) * time.Hour)
+		_, err := tracker.AddSession(project, sessionStart, sessionStart.Add(30*time.Minute), nil, "")
+		require.NoError(t, err)
+	}
+
+	sessions, err := tracker.QuerySessions(SessionQuery{Project: "platform"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, "platform/web", sessions[0].Project)
+	assert.Equal(t, "platform", sessions[1].Project)
+	assert.Equal(t, "platform/api", sessions[2].Project)
+
+	sessions, err = tracker.QuerySessions(SessionQuery{Project: "platform/api"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+}
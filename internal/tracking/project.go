@@ -11,44 +11,63 @@ import (
 )
 
 // ProjectDetector handles automatic project detection
-type ProjectDetector struct{}
+type ProjectDetector struct {
+	rules []ProjectRule
+}
 
 // NewProjectDetector creates a new project detector
 func NewProjectDetector() *ProjectDetector {
 	return &ProjectDetector{}
 }
 
+// NewProjectDetectorWithRules creates a project detector that tries the given
+// detect rules before falling back to project files
+func NewProjectDetectorWithRules(rules []ProjectRule) *ProjectDetector {
+	return &ProjectDetector{rules: rules}
+}
+
 // DetectProject attempts to detect the current project based on working directory
 func (pd *ProjectDetector) DetectProject() string {
+	return pd.Detect().Project
+}
+
+// Detect detects the current project and explains how it was found. Configured
+// rules are tried first; otherwise the project is named after the package and
+// repository it is in, project files or the directory.
+func (pd *ProjectDetector) Detect() ProjectMatch {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return "default"
+		return ProjectMatch{Project: "default", Reason: "working directory unavailable"}
+	}
+
+	if match, ok := pd.matchRules(cwd); ok {
+		return match
+	}
+
+	// Inside a repository, look for packages and workspaces up to its root
+	if gitRoot := pd.findGitRoot(cwd); gitRoot != "" {
+		return pd.detectInRepository(cwd, gitRoot)
 	}
 
 	// Check for common project indicators
 	if pd.hasFile(cwd, "package.json") {
-		return pd.getProjectNameFromPackageJSON(cwd)
+		return ProjectMatch{Project: pd.getProjectNameFromPackageJSON(cwd), Reason: "name in package.json"}
 	}
 
 	if pd.hasFile(cwd, "go.mod") {
-		return pd.getProjectNameFromGoMod(cwd)
+		return ProjectMatch{Project: pd.getProjectNameFromGoMod(cwd), Reason: "module path in go.mod"}
 	}
 
 	if pd.hasFile(cwd, "Cargo.toml") {
-		return pd.getProjectNameFromCargoToml(cwd)
+		return ProjectMatch{Project: pd.getProjectNameFromCargoToml(cwd), Reason: "package name in Cargo.toml"}
 	}
 
 	if pd.hasFile(cwd, "pyproject.toml") || pd.hasFile(cwd, "setup.py") {
-		return pd.getProjectNameFromPython(cwd)
-	}
-
-	// Check for git repository
-	if pd.isGitRepo(cwd) {
-		return pd.getProjectNameFromGit(cwd)
+		return ProjectMatch{Project: pd.getProjectNameFromPython(cwd), Reason: "name in pyproject.toml or setup.py"}
 	}
 
 	// Fall back to directory name
-	return filepath.Base(cwd)
+	return ProjectMatch{Project: filepath.Base(cwd), Reason: "directory name"}
 }
 
 // hasFile checks if a file exists in the given directory
@@ -215,15 +234,10 @@ func (pd *ProjectDetector) getProjectNameFromGit(dir string) string {
 	remoteURL := strings.TrimSpace(string(output))
 	if remoteURL != "" {
 		// Extract project name from git URL
-		// Handle both SSH and HTTPS URLs
-		if strings.Contains(remoteURL, "github.com") || strings.Contains(remoteURL, "gitlab.com") || strings.Contains(remoteURL, "bitbucket.org") {
-			// Remove .git suffix
-			remoteURL = strings.TrimSuffix(remoteURL, ".git")
-			// Extract the last part of the path
-			parts := strings.Split(remoteURL, "/")
-			if len(parts) > 0 {
-				return parts[len(parts)-1]
-			}
+		// Handle both SSH (host:path) and HTTPS URLs on any host
+		remoteURL = strings.TrimSuffix(strings.TrimSuffix(remoteURL, "/"), ".git")
+		if name := remoteURL[strings.LastIndexAny(remoteURL, "/:")+1:]; name != "" {
+			return name
 		}
 	}
 
@@ -0,0 +1,337 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// idleStretchKey stores the unanswered idle stretch in the meta bucket
+var idleStretchKey = []byte("idle_stretch")
+
+// IdleResolution says what an idle stretch was spent on
+type IdleResolution string
+
+const (
+	// IdleWork counts the idle stretch as work on the session, e.g. reading or a meeting
+	IdleWork IdleResolution = "work"
+	// IdleBreak keeps the idle stretch as a pause in the session
+	IdleBreak IdleResolution = "break"
+	// IdleOtherProject records the idle stretch as a session for another project
+	IdleOtherProject IdleResolution = "project"
+)
+
+// IdleStretch is a stretch of idle time that paused a session and is waiting
+// for the user to say what it was. End is set once activity resumes.
+type IdleStretch struct {
+	SessionID string     `json:"session_id"`
+	Project   string     `json:"project"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+}
+
+// Duration returns the length of the stretch, up to now if it hasn't ended
+func (s *IdleStretch) Duration(now time.Time) time.Duration {
+	end := now
+	if s.End != nil {
+		end = *s.End
+	}
+	return end.Sub(s.Start)
+}
+
+// PauseForIdle pauses the running session as of since, the moment the user
+// went idle, so the idle threshold is not counted as work. The stretch is
+// kept until ResolveIdle records what it was; an earlier unanswered stretch
+// stays a break.
+func (t *Tracker) PauseForIdle(since time.Time) (*Session, error) {
+	session, err := t.GetCurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.State != StateRunning {
+		return nil, fmt.Errorf("no running session to pause")
+	}
+
+	open := session.openInterval()
+	if now := time.Now(); since.After(now) {
+		since = now
+	}
+	if open != nil && since.Before(open.Start) {
+		since = open.Start
+	}
+	if open != nil {
+		open.End = &since
+	}
+	session.PausedAt = &since
+	session.State = StatePaused
+
+	if err := t.saveSession(session); err != nil {
+		return nil, err
+	}
+	if err := t.setCurrentSession(session); err != nil {
+		return nil, err
+	}
+
+	stretch := &IdleStretch{SessionID: session.ID, Project: session.Project, Start: since}
+	if err := t.putIdleStretch(stretch); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// MarkIdleEnded records when activity resumed after an idle stretch
+func (t *Tracker) MarkIdleEnded(at time.Time) (*IdleStretch, error) {
+	stretch, err := t.PendingIdle()
+	if err != nil || stretch == nil || stretch.End != nil {
+		return stretch, err
+	}
+
+	if at.Before(stretch.Start) {
+		at = stretch.Start
+	}
+	stretch.End = &at
+	if err := t.putIdleStretch(stretch); err != nil {
+		return nil, err
+	}
+
+	return stretch, nil
+}
+
+// PendingIdle returns the idle stretch waiting for an answer, if any
+func (t *Tracker) PendingIdle() (*IdleStretch, error) {
+	var stretch *IdleStretch
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(idleStretchKey)
+		if data == nil {
+			return nil
+		}
+		stretch = &IdleStretch{}
+		return json.Unmarshal(data, stretch)
+	})
+
+	return stretch, err
+}
+
+// ResolveIdle records what the pending idle stretch was. Work fills the gap
+// in the session, a break leaves it, and another project gets a session of
+// its own covering the stretch. If the session is still paused for the
+// stretch, it resumes from when activity came back. It returns the session
+// the stretch paused.
+func (t *Tracker) ResolveIdle(resolution IdleResolution, project string) (*Session, error) {
+	stretch, err := t.PendingIdle()
+	if err != nil {
+		return nil, err
+	}
+	if stretch == nil {
+		return nil, fmt.Errorf("no idle time to reclaim")
+	}
+	if resolution == IdleOtherProject && project == "" {
+		return nil, fmt.Errorf("a project is required to reclaim idle time for another project")
+	}
+
+	session, isCurrent, err := t.idleSession(stretch.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if stretch.End != nil {
+		end = *stretch.End
+	} else if next := firstIntervalAfter(session.Intervals, stretch.Start); next != nil {
+		// Resumed by hand before the monitor saw the user come back
+		end = next.Start
+	}
+	if session.State == StateStopped && session.EndTime != nil && end.After(*session.EndTime) {
+		end = *session.EndTime
+	}
+
+	switch resolution {
+	case IdleWork:
+		if end.After(stretch.Start) {
+			session.Intervals = append(session.Intervals, Interval{Start: stretch.Start, End: &end})
+		}
+	case IdleBreak:
+	case IdleOtherProject:
+		if end.After(stretch.Start) {
+			if _, err := t.AddSession(project, stretch.Start, end, nil, "reclaimed idle time"); err != nil {
+				return nil, fmt.Errorf("failed to record idle time for %s: %w", project, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown idle resolution %q (use work, break or project)", resolution)
+	}
+
+	// Still paused for this stretch: pick up where activity resumed
+	if isCurrent && session.State == StatePaused && session.PausedAt != nil && session.PausedAt.Equal(stretch.Start) {
+		session.Intervals = append(session.Intervals, Interval{Start: end})
+		session.PausedAt = nil
+		session.State = StateRunning
+	}
+
+	session.Intervals = mergeIntervals(session.Intervals)
+	if session.State == StateStopped && session.EndTime != nil {
+		session.Duration = session.WorkedDuration(*session.EndTime)
+	}
+
+	if err := t.saveSession(session); err != nil {
+		return nil, err
+	}
+	if isCurrent {
+		if err := t.setCurrentSession(session); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.clearIdleStretch(); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// SetIdleEndHandler sets a function called when activity resumes after the
+// idle monitor paused the session, e.g. to ask what the stretch was
+func (t *Tracker) SetIdleEndHandler(handler func(*IdleStretch)) {
+	t.idleEndHandler = handler
+}
+
+// SetIdleLock sets the lock the idle monitor holds while it pauses the
+// session, so the pause doesn't interleave with other changes to it, e.g.
+// the daemon's calls. Without one the tracker uses a lock of its own.
+func (t *Tracker) SetIdleLock(lock sync.Locker) {
+	t.idleLock = lock
+}
+
+// idleLocker returns the lock the idle monitor holds
+func (t *Tracker) idleLocker() sync.Locker {
+	if t.idleLock != nil {
+		return t.idleLock
+	}
+	return &t.idleMu
+}
+
+// handleIdleStart pauses the running session when the user goes idle
+func (t *Tracker) handleIdleStart(idle time.Duration) {
+	lock := t.idleLocker()
+	lock.Lock()
+	defer lock.Unlock()
+
+	session, err := t.GetCurrentSession()
+	if err != nil || session == nil || session.State != StateRunning {
+		return
+	}
+	if _, err := t.PauseForIdle(time.Now().Add(-idle)); err == nil {
+		t.idlePaused = true
+	}
+}
+
+// handleIdleEnd records the end of the idle stretch when activity resumes.
+// The session stays paused until the stretch is resolved, to avoid resuming
+// the wrong session. The handler runs after the lock is released, so it
+// can call back into the tracker.
+func (t *Tracker) handleIdleEnd(idle time.Duration) {
+	stretch := t.markIdleEnded(idle)
+	if stretch != nil && t.idleEndHandler != nil {
+		t.idleEndHandler(stretch)
+	}
+}
+
+// markIdleEnded ends the stretch the idle monitor started, if it did
+func (t *Tracker) markIdleEnded(idle time.Duration) *IdleStretch {
+	lock := t.idleLocker()
+	lock.Lock()
+	defer lock.Unlock()
+
+	if !t.idlePaused {
+		return nil
+	}
+	t.idlePaused = false
+
+	stretch, err := t.MarkIdleEnded(time.Now().Add(-idle))
+	if err != nil {
+		return nil
+	}
+	return stretch
+}
+
+// idleSession loads the session an idle stretch belongs to
+func (t *Tracker) idleSession(id string) (*Session, bool, error) {
+	current, err := t.GetCurrentSession()
+	if err != nil {
+		return nil, false, err
+	}
+	if current != nil && current.ID == id {
+		return current, true, nil
+	}
+
+	session, err := t.GetSession(id)
+	if err != nil {
+		return nil, false, err
+	}
+	return session, false, nil
+}
+
+func (t *Tracker) putIdleStretch(stretch *IdleStretch) error {
+	data, err := json.Marshal(stretch)
+	if err != nil {
+		return err
+	}
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(idleStretchKey, data)
+	})
+}
+
+func (t *Tracker) clearIdleStretch() error {
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Delete(idleStretchKey)
+	})
+}
+
+// firstIntervalAfter returns the earliest interval starting after t
+func firstIntervalAfter(intervals []Interval, t time.Time) *Interval {
+	var first *Interval
+	for i := range intervals {
+		if intervals[i].Start.After(t) && (first == nil || intervals[i].Start.Before(first.Start)) {
+			first = &intervals[i]
+		}
+	}
+	return first
+}
+
+// mergeIntervals sorts intervals and joins those that overlap or touch. An
+// open interval absorbs everything after its start.
+func mergeIntervals(intervals []Interval) []Interval {
+	if len(intervals) < 2 {
+		return intervals
+	}
+
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	merged := []Interval{sorted[0]}
+	for _, interval := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if last.End != nil && interval.Start.After(*last.End) {
+			merged = append(merged, interval)
+			continue
+		}
+		switch {
+		case last.End == nil:
+		case interval.End == nil:
+			last.End = nil
+		case interval.End.After(*last.End):
+			end := *interval.End
+			last.End = &end
+		}
+	}
+
+	return merged
+}
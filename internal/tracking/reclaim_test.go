@@ -0,0 +1,200 @@
+package tracking
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBackdated starts a session that began the given time ago
+func startBackdated(t *testing.T, tracker *Tracker, project string, ago time.Duration) *Session {
+	t.Helper()
+
+	session, err := tracker.Start(project)
+	require.NoError(t, err)
+
+	session.StartTime = session.StartTime.Add(-ago)
+	session.Intervals[0].Start = session.StartTime
+	require.NoError(t, tracker.saveSession(session))
+	require.NoError(t, tracker.setCurrentSession(session))
+	return session
+}
+
+func TestTracker_PauseForIdleBackdates(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	started := startBackdated(t, tracker, "api", time.Hour)
+	idleSince := time.Now().Add(-20 * time.Minute)
+
+	session, err := tracker.PauseForIdle(idleSince)
+	require.NoError(t, err)
+	assert.Equal(t, StatePaused, session.State)
+	assert.True(t, session.PausedAt.Equal(idleSince))
+	assert.InDelta(t, (40 * time.Minute).Seconds(), session.WorkedDuration(time.Now()).Seconds(), 1,
+		"the idle threshold is not counted as work")
+
+	stretch, err := tracker.PendingIdle()
+	require.NoError(t, err)
+	require.NotNil(t, stretch)
+	assert.Equal(t, started.ID, stretch.SessionID)
+	assert.True(t, stretch.Start.Equal(idleSince))
+	assert.Nil(t, stretch.End)
+
+	// Idle can't start before the session did
+	_, err = tracker.ResolveIdle(IdleBreak, "")
+	require.NoError(t, err)
+	_, err = tracker.PauseForIdle(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	stretch, err = tracker.PendingIdle()
+	require.NoError(t, err)
+	assert.False(t, stretch.Start.Before(started.StartTime))
+}
+
+func TestTracker_ResolveIdle(t *testing.T) {
+	tests := []struct {
+		name       string
+		resolution IdleResolution
+		project    string
+		worked     time.Duration
+		breaks     int
+	}{
+		{"work fills the gap", IdleWork, "", time.Hour, 0},
+		{"break keeps the gap", IdleBreak, "", 40 * time.Minute, 1},
+		{"other project gets the gap", IdleOtherProject, "support", 40 * time.Minute, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := setupTestTracker(t)
+			defer tracker.Close()
+
+			now := time.Now()
+			startBackdated(t, tracker, "api", time.Hour)
+			_, err := tracker.PauseForIdle(now.Add(-30 * time.Minute))
+			require.NoError(t, err)
+			_, err = tracker.MarkIdleEnded(now.Add(-10 * time.Minute))
+			require.NoError(t, err)
+
+			session, err := tracker.ResolveIdle(test.resolution, test.project)
+			require.NoError(t, err)
+
+			// Work resumes from when activity came back, not from when the answer came
+			assert.Equal(t, StateRunning, session.State)
+			assert.InDelta(t, test.worked.Seconds(), session.WorkedDuration(now).Seconds(), 1)
+			assert.Equal(t, test.breaks, session.Breaks())
+
+			stretch, err := tracker.PendingIdle()
+			require.NoError(t, err)
+			assert.Nil(t, stretch)
+
+			if test.project != "" {
+				sessions, err := tracker.QuerySessions(SessionQuery{Project: test.project})
+				require.NoError(t, err)
+				require.Len(t, sessions, 1)
+				assert.Equal(t, 20*time.Minute, sessions[0].Duration)
+			}
+		})
+	}
+}
+
+func TestTracker_ResolveIdleAfterManualResume(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	startBackdated(t, tracker, "api", time.Hour)
+	_, err := tracker.PauseForIdle(time.Now().Add(-30 * time.Minute))
+	require.NoError(t, err)
+	_, err = tracker.Resume()
+	require.NoError(t, err)
+
+	session, err := tracker.ResolveIdle(IdleWork, "")
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, session.State)
+	assert.Equal(t, 0, session.Breaks(), "the gap up to the manual resume is work")
+	assert.InDelta(t, time.Hour.Seconds(), session.WorkedDuration(time.Now()).Seconds(), 1)
+
+	_, err = tracker.ResolveIdle(IdleWork, "")
+	assert.Error(t, err, "nothing left to reclaim")
+}
+
+func TestTracker_HandleIdleEnd(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	var notified *IdleStretch
+	tracker.SetIdleEndHandler(func(stretch *IdleStretch) { notified = stretch })
+
+	startBackdated(t, tracker, "api", time.Hour)
+	tracker.handleIdleStart(15 * time.Minute)
+	tracker.handleIdleEnd(5 * time.Second)
+
+	require.NotNil(t, notified)
+	require.NotNil(t, notified.End)
+	assert.InDelta(t, (15*time.Minute - 5*time.Second).Seconds(), notified.Duration(time.Now()).Seconds(), 1)
+
+	// The session stays paused until the stretch is resolved
+	session, err := tracker.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Equal(t, StatePaused, session.State)
+
+	// Activity after a manual pause isn't an idle stretch
+	notified = nil
+	tracker.handleIdleEnd(0)
+	assert.Nil(t, notified)
+}
+
+func TestTracker_IdleLock(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	var lock sync.Mutex
+	tracker.SetIdleLock(&lock)
+	startBackdated(t, tracker, "api", time.Hour)
+
+	// The idle monitor waits for whoever holds the lock
+	lock.Lock()
+	paused := make(chan struct{})
+	go func() {
+		tracker.handleIdleStart(15 * time.Minute)
+		close(paused)
+	}()
+
+	select {
+	case <-paused:
+		t.Fatal("idle pause didn't wait for the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	session, err := tracker.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, session.State)
+
+	lock.Unlock()
+	<-paused
+	session, err = tracker.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Equal(t, StatePaused, session.State)
+}
+
+func TestMergeIntervals(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		t := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+
+	merged := mergeIntervals([]Interval{
+		{Start: *at(60), End: nil},
+		{Start: *at(0), End: at(30)},
+		{Start: *at(30), End: at(45)},
+		{Start: *at(50), End: at(60)},
+	})
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, *at(0), merged[0].Start)
+	assert.Equal(t, *at(45), *merged[0].End)
+	assert.Equal(t, *at(50), merged[1].Start)
+	assert.Nil(t, merged[1].End, "an open interval stays open")
+}
@@ -0,0 +1,244 @@
+package tracking
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule kinds, in the order they are evaluated. More explicit signals win: an
+// environment variable set for a shell beats the branch, which beats the
+// repository, which beats where the checkout happens to live on disk.
+const (
+	RuleEnv       = "env"
+	RuleBranch    = "branch"
+	RuleGitRemote = "git-remote"
+	RuleGit       = "git"
+	RuleDir       = "dir"
+	RuleFile      = "file"
+)
+
+// ruleKinds lists the rule kinds in priority order
+var ruleKinds = []string{RuleEnv, RuleBranch, RuleGitRemote, RuleGit, RuleDir, RuleFile}
+
+// ProjectRule maps one `projects[].detect` pattern to a configured project.
+//
+// Patterns have the form kind:value:
+//
+//	env:VAR or env:VAR=regex   environment variable is set (and matches)
+//	branch:regex               current git branch
+//	git-remote:regex           URL of the origin remote (unanchored)
+//	git:regex                  git repository name
+//	dir:glob                   working directory or one of its parents (~ expands)
+//	file:glob                  file in the working directory
+//
+// A pattern without a known kind is treated as file:pattern, so marker files
+// such as "go.mod" or "docs/" work as-is. Regexes other than git-remote must
+// match the whole value.
+type ProjectRule struct {
+	Project string
+	Pattern string
+	Kind    string
+	value   string
+	envName string
+	regex   *regexp.Regexp
+}
+
+// ProjectMatch describes which project was detected and why
+type ProjectMatch struct {
+	Project string
+	// Rule is the detect pattern that matched, empty when the project was
+	// detected from project files instead of configured rules
+	Rule string
+	// Repo and Package are set when the project is a package inside a
+	// repository or workspace; Project is then "<Repo>/<Package>". Repo alone
+	// is set when the working directory is not inside a package.
+	Repo    string
+	Package string
+	Reason  string
+}
+
+// ParseProjectRule parses a detect pattern for a project
+func ParseProjectRule(project, pattern string) (ProjectRule, error) {
+	rule := ProjectRule{Project: project, Pattern: pattern, Kind: RuleFile, value: pattern}
+
+	if kind, value, ok := strings.Cut(pattern, ":"); ok {
+		for _, known := range ruleKinds {
+			if kind == known {
+				rule.Kind = kind
+				rule.value = value
+				break
+			}
+		}
+	}
+
+	if rule.value == "" {
+		return rule, fmt.Errorf("detect pattern %q is empty", pattern)
+	}
+
+	var err error
+	switch rule.Kind {
+	case RuleEnv:
+		name, value, hasValue := strings.Cut(rule.value, "=")
+		rule.envName = name
+		if hasValue {
+			rule.regex, err = regexp.Compile("^(?:" + value + ")$")
+		}
+	case RuleBranch, RuleGit:
+		rule.regex, err = regexp.Compile("^(?:" + rule.value + ")$")
+	case RuleGitRemote:
+		rule.regex, err = regexp.Compile(rule.value)
+	case RuleDir, RuleFile:
+		_, err = filepath.Match(strings.TrimSuffix(rule.value, "/"), "")
+	}
+	if err != nil {
+		return rule, fmt.Errorf("invalid detect pattern %q: %w", pattern, err)
+	}
+
+	return rule, nil
+}
+
+// detectionContext gathers facts about the working directory on demand, so
+// rules that don't need git never run it
+type detectionContext struct {
+	cwd string
+
+	gitLoaded bool
+	gitRoot   string
+	repoNames []string
+	remote    string
+	branch    string
+}
+
+func (dc *detectionContext) loadGit(pd *ProjectDetector) {
+	if dc.gitLoaded {
+		return
+	}
+	dc.gitLoaded = true
+
+	dc.gitRoot = pd.findGitRoot(dc.cwd)
+	if dc.gitRoot == "" {
+		return
+	}
+
+	dc.remote = gitOutput(dc.gitRoot, "remote", "get-url", "origin")
+	dc.branch = gitOutput(dc.gitRoot, "symbolic-ref", "--short", "HEAD")
+
+	// The repository is known by its remote name and its checkout directory
+	dc.repoNames = []string{filepath.Base(dc.gitRoot)}
+	if dc.remote != "" {
+		name := strings.TrimSuffix(dc.remote, ".git")
+		name = name[strings.LastIndexAny(name, "/:")+1:]
+		if name != "" && name != dc.repoNames[0] {
+			dc.repoNames = append([]string{name}, dc.repoNames...)
+		}
+	}
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// match checks a rule against the working directory and explains a match
+func (rule ProjectRule) match(pd *ProjectDetector, dc *detectionContext) (string, bool) {
+	switch rule.Kind {
+	case RuleEnv:
+		value, ok := os.LookupEnv(rule.envName)
+		if !ok || (rule.regex == nil && value == "") {
+			return "", false
+		}
+		if rule.regex != nil && !rule.regex.MatchString(value) {
+			return "", false
+		}
+		return fmt.Sprintf("environment variable %s=%q", rule.envName, value), true
+
+	case RuleBranch:
+		dc.loadGit(pd)
+		if dc.branch == "" || !rule.regex.MatchString(dc.branch) {
+			return "", false
+		}
+		return fmt.Sprintf("git branch %q", dc.branch), true
+
+	case RuleGitRemote:
+		dc.loadGit(pd)
+		if dc.remote == "" || !rule.regex.MatchString(dc.remote) {
+			return "", false
+		}
+		return fmt.Sprintf("git remote %q", dc.remote), true
+
+	case RuleGit:
+		dc.loadGit(pd)
+		for _, name := range dc.repoNames {
+			if rule.regex.MatchString(name) {
+				return fmt.Sprintf("git repository %q", name), true
+			}
+		}
+		return "", false
+
+	case RuleDir:
+		pattern := filepath.Clean(expandHome(strings.TrimSuffix(rule.value, "/")))
+		for dir := dc.cwd; ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(pattern, dir); ok {
+				return fmt.Sprintf("directory %q", dir), true
+			}
+			if filepath.Dir(dir) == dir {
+				return "", false
+			}
+		}
+
+	case RuleFile:
+		pattern := strings.TrimSuffix(rule.value, "/")
+		matches, _ := filepath.Glob(filepath.Join(dc.cwd, pattern))
+		if len(matches) == 0 {
+			return "", false
+		}
+		return fmt.Sprintf("file %q", filepath.Base(matches[0])), true
+	}
+
+	return "", false
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// matchRules returns the first rule matching the working directory, trying
+// rule kinds in priority order and rules of the same kind in config order
+func (pd *ProjectDetector) matchRules(cwd string) (ProjectMatch, bool) {
+	dc := &detectionContext{cwd: cwd}
+
+	for _, kind := range ruleKinds {
+		for _, rule := range pd.rules {
+			if rule.Kind != kind {
+				continue
+			}
+			if reason, ok := rule.match(pd, dc); ok {
+				return ProjectMatch{
+					Project: rule.Project,
+					Rule:    rule.Pattern,
+					Reason:  fmt.Sprintf("%s matches %q", reason, rule.Pattern),
+				}, true
+			}
+		}
+	}
+
+	return ProjectMatch{}, false
+}
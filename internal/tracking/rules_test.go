@@ -0,0 +1,143 @@
+package tracking
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProjectRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		kind    string
+		wantErr bool
+	}{
+		{"git:main-app", RuleGit, false},
+		{"git-remote:github.com/me/.*", RuleGitRemote, false},
+		{"branch:feature/.*", RuleBranch, false},
+		{"env:ONCALL", RuleEnv, false},
+		{"env:CLIENT=acme|globex", RuleEnv, false},
+		{"dir:~/projects/*", RuleDir, false},
+		{"go.mod", RuleFile, false},
+		{"docs/", RuleFile, false},
+		{"git:(", RuleGit, true},
+		{"dir:[", RuleDir, true},
+		{"branch:", RuleBranch, true},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseProjectRule("project", test.pattern)
+		if test.wantErr {
+			assert.Error(t, err, test.pattern)
+			continue
+		}
+		require.NoError(t, err, test.pattern)
+		assert.Equal(t, test.kind, rule.Kind, test.pattern)
+	}
+}
+
+// detectIn runs detection with the given rules from dir
+func detectIn(t *testing.T, dir string, patterns map[string][]string) ProjectMatch {
+	t.Helper()
+
+	var rules []ProjectRule
+	// Add projects in a fixed order, as they would appear in a config file
+	for _, project := range []string{"alpha", "beta", "gamma"} {
+		for _, pattern := range patterns[project] {
+			rule, err := ParseProjectRule(project, pattern)
+			require.NoError(t, err)
+			rules = append(rules, rule)
+		}
+	}
+
+	originalCwd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalCwd) })
+	require.NoError(t, os.Chdir(dir))
+
+	return NewProjectDetectorWithRules(rules).Detect()
+}
+
+func TestProjectDetector_Rules(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectDir := filepath.Join(home, "work", "client-a", "site")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "package.json"), []byte(`{"name": "site"}`), 0644))
+
+	t.Run("dir glob matches parent directories", func(t *testing.T) {
+		match := detectIn(t, projectDir, map[string][]string{"alpha": {"dir:~/work/client-*"}})
+		assert.Equal(t, "alpha", match.Project)
+		assert.Equal(t, "dir:~/work/client-*", match.Rule)
+		assert.Contains(t, match.Reason, "client-a")
+	})
+
+	t.Run("file patterns match the working directory", func(t *testing.T) {
+		match := detectIn(t, projectDir, map[string][]string{"alpha": {"go.mod"}, "beta": {"package.json"}})
+		assert.Equal(t, "beta", match.Project)
+	})
+
+	t.Run("env rules take priority over directories", func(t *testing.T) {
+		t.Setenv("RUNE_CLIENT", "globex")
+		match := detectIn(t, projectDir, map[string][]string{
+			"alpha": {"dir:~/work/client-a"},
+			"beta":  {"env:RUNE_CLIENT=acme"},
+			"gamma": {"env:RUNE_CLIENT=globex"},
+		})
+		assert.Equal(t, "gamma", match.Project)
+		assert.Equal(t, "env:RUNE_CLIENT=globex", match.Rule)
+	})
+
+	t.Run("falls back to project files", func(t *testing.T) {
+		match := detectIn(t, projectDir, map[string][]string{"alpha": {"dir:~/elsewhere"}})
+		assert.Equal(t, "site", match.Project)
+		assert.Empty(t, match.Rule)
+		assert.Equal(t, "name in package.json", match.Reason)
+	})
+}
+
+func TestProjectDetector_GitRules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := filepath.Join(t.TempDir(), "checkout")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "services", "api"), 0755))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "feature/billing"},
+		{"remote", "add", "origin", "git@github.com:acme/platform.git"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		require.NoError(t, cmd.Run(), "git %v", args)
+	}
+	apiDir := filepath.Join(repo, "services", "api")
+
+	t.Run("repository name from the remote", func(t *testing.T) {
+		match := detectIn(t, apiDir, map[string][]string{"alpha": {"git:platform"}})
+		assert.Equal(t, "alpha", match.Project)
+	})
+
+	t.Run("repository name from the checkout directory", func(t *testing.T) {
+		match := detectIn(t, apiDir, map[string][]string{"alpha": {"git:check.*"}})
+		assert.Equal(t, "alpha", match.Project)
+	})
+
+	t.Run("git names must match fully", func(t *testing.T) {
+		match := detectIn(t, apiDir, map[string][]string{"alpha": {"git:plat"}})
+		assert.NotEqual(t, "alpha", match.Project)
+	})
+
+	t.Run("branch beats remote", func(t *testing.T) {
+		match := detectIn(t, apiDir, map[string][]string{
+			"alpha": {"git-remote:acme/"},
+			"beta":  {"branch:feature/.*"},
+		})
+		assert.Equal(t, "beta", match.Project)
+		assert.Contains(t, match.Reason, "feature/billing")
+	})
+}
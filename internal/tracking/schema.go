@@ -0,0 +1,87 @@
+package tracking
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// schemaVersionKey stores the database schema version in the meta bucket
+var schemaVersionKey = []byte("schema_version")
+
+// schemaMigrations upgrade the database one version at a time. The schema
+// version stored in the meta bucket is the number of migrations applied.
+var schemaMigrations = []func(tx *bbolt.Tx) error{
+	migrateSessionIntervals,
+	migrateSessionIndexes,
+}
+
+// migrateSchema applies any pending database migrations
+func (t *Tracker) migrateSchema() error {
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+
+		version := 0
+		if data := meta.Get(schemaVersionKey); len(data) == 8 {
+			version = int(binary.BigEndian.Uint64(data))
+		}
+
+		for i := version; i < len(schemaMigrations); i++ {
+			if err := schemaMigrations[i](tx); err != nil {
+				return fmt.Errorf("schema migration %d failed: %w", i+1, err)
+			}
+		}
+
+		if version >= len(schemaMigrations) {
+			return nil
+		}
+
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(len(schemaMigrations)))
+		return meta.Put(schemaVersionKey, data)
+	})
+}
+
+// migrateSessionIntervals adds work intervals to sessions saved before
+// sessions recorded their pause/resume history
+func migrateSessionIntervals(tx *bbolt.Tx) error {
+	upgrade := func(bucket *bbolt.Bucket, key, value []byte) error {
+		var session Session
+		if err := json.Unmarshal(value, &session); err != nil {
+			// Leave unreadable records untouched
+			return nil
+		}
+		if !session.ensureIntervals() {
+			return nil
+		}
+		data, err := json.Marshal(&session)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	}
+
+	for _, name := range [][]byte{sessionsBucket, currentBucket} {
+		bucket := tx.Bucket(name)
+
+		// Collect first: bbolt does not allow modifying a bucket while iterating it
+		var keys, values [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			values = append(values, append([]byte(nil), v...))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for i := range keys {
+			if err := upgrade(bucket, keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"go.etcd.io/bbolt"
@@ -32,6 +34,13 @@ func (s SessionState) String() string {
 	}
 }
 
+// Interval represents a contiguous stretch of work within a session.
+// An interval with a nil End is still open (the session is running).
+type Interval struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
 // Session represents a work session
 type Session struct {
 	ID        string        `json:"id"`
@@ -41,18 +50,142 @@ type Session struct {
 	PausedAt  *time.Time    `json:"paused_at,omitempty"`
 	Duration  time.Duration `json:"duration"`
 	State     SessionState  `json:"state"`
+	Intervals []Interval    `json:"intervals,omitempty"`
+	Tags      []string      `json:"tags,omitempty"`
+	Note      string        `json:"note,omitempty"`
+
+	// BreakReminders are the times a break reminder was sent
+	BreakReminders []time.Time `json:"break_reminders,omitempty"`
+}
+
+// HasTag reports whether the session is tagged with the given tag
+func (s *Session) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTag trims whitespace and the "+" prefix used by Watson and Timewarrior
+func normalizeTag(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "+")
+}
+
+// NormalizeTags cleans up a list of tags, dropping empty and duplicate entries
+// while preserving order
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// WorkedDuration returns the time spent working in the session's intervals,
+// counting any open interval up to now.
+func (s *Session) WorkedDuration(now time.Time) time.Duration {
+	var total time.Duration
+	for _, interval := range s.Intervals {
+		end := now
+		if interval.End != nil {
+			end = *interval.End
+		}
+		if end.After(interval.Start) {
+			total += end.Sub(interval.Start)
+		}
+	}
+	return total
+}
+
+// Breaks returns the number of pauses taken during the session. A session
+// that is currently paused counts its ongoing pause as a break.
+func (s *Session) Breaks() int {
+	if len(s.Intervals) == 0 {
+		return 0
+	}
+	breaks := len(s.Intervals) - 1
+	if s.State == StatePaused {
+		breaks++
+	}
+	return breaks
+}
+
+// BreakDuration returns the total time spent paused between intervals,
+// including an ongoing pause up to now.
+func (s *Session) BreakDuration(now time.Time) time.Duration {
+	var total time.Duration
+	for i := 1; i < len(s.Intervals); i++ {
+		prev := s.Intervals[i-1]
+		if prev.End != nil && s.Intervals[i].Start.After(*prev.End) {
+			total += s.Intervals[i].Start.Sub(*prev.End)
+		}
+	}
+	if s.State == StatePaused && s.PausedAt != nil && now.After(*s.PausedAt) {
+		total += now.Sub(*s.PausedAt)
+	}
+	return total
+}
+
+// openInterval returns the session's open interval, if any
+func (s *Session) openInterval() *Interval {
+	if len(s.Intervals) == 0 {
+		return nil
+	}
+	last := &s.Intervals[len(s.Intervals)-1]
+	if last.End != nil {
+		return nil
+	}
+	return last
+}
+
+// ensureIntervals synthesizes intervals for sessions recorded before
+// intervals existed. Older versions shifted StartTime forward on resume,
+// so the recovered interval covers only the worked time, not the breaks.
+func (s *Session) ensureIntervals() bool {
+	if len(s.Intervals) > 0 || s.StartTime.IsZero() {
+		return false
+	}
+
+	interval := Interval{Start: s.StartTime}
+	switch s.State {
+	case StateStopped:
+		end := s.StartTime.Add(s.Duration)
+		interval.End = &end
+	case StatePaused:
+		if s.PausedAt != nil {
+			end := *s.PausedAt
+			interval.End = &end
+		}
+	}
+	s.Intervals = []Interval{interval}
+	return true
 }
 
 // Tracker manages time tracking sessions
 type Tracker struct {
-	db           *bbolt.DB
-	idleDetector *IdleDetector
-	idleStop     chan struct{}
+	db             *bbolt.DB
+	idleDetector   *IdleDetector
+	idleStop       chan struct{}
+	idleEndHandler func(*IdleStretch)
+	idleLock       sync.Locker // held by the idle monitor while it changes the session
+	idleMu         sync.Mutex  // the idle lock unless SetIdleLock sets another
+	idlePaused     bool        // guarded by the idle lock
+	calendar       Calendar
 }
 
 var (
 	sessionsBucket = []byte("sessions")
 	currentBucket  = []byte("current")
+	metaBucket     = []byte("meta")
 )
 
 // NewTracker creates a new time tracker
@@ -83,11 +216,16 @@ func NewTrackerWithIdleThreshold(idleThreshold time.Duration) (*Tracker, error)
 	tracker := &Tracker{
 		db:           db,
 		idleDetector: idleDetector,
+		calendar:     DefaultCalendar(),
 	}
 	if err := tracker.initBuckets(); err != nil {
 		db.Close()
 		return nil, err
 	}
+	if err := tracker.migrateSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade database: %w", err)
+	}
 
 	return tracker, nil
 }
@@ -109,12 +247,26 @@ func (t *Tracker) initBuckets() error {
 		if _, err := tx.CreateBucketIfNotExists(currentBucket); err != nil {
 			return err
 		}
+		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(startIndexBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(projectIndexBucket); err != nil {
+			return err
+		}
 		return nil
 	})
 }
 
 // Start starts a new work session
 func (t *Tracker) Start(project string) (*Session, error) {
+	return t.StartWithDetails(project, nil, "")
+}
+
+// StartWithDetails starts a new work session with tags and a note
+func (t *Tracker) StartWithDetails(project string, tags []string, note string) (*Session, error) {
 	// Check if there's already an active session
 	current, err := t.GetCurrentSession()
 	if err != nil {
@@ -124,11 +276,15 @@ func (t *Tracker) Start(project string) (*Session, error) {
 		return nil, fmt.Errorf("session already active (state: %s)", current.State)
 	}
 
+	now := time.Now()
 	session := &Session{
 		ID:        generateSessionID(),
 		Project:   project,
-		StartTime: time.Now(),
+		StartTime: now,
 		State:     StateRunning,
+		Intervals: []Interval{{Start: now}},
+		Tags:      NormalizeTags(tags),
+		Note:      strings.TrimSpace(note),
 	}
 
 	if err := t.saveSession(session); err != nil {
@@ -159,16 +315,16 @@ func (t *Tracker) Stop() (*Session, error) {
 	}
 
 	now := time.Now()
+	session.ensureIntervals()
+	if open := session.openInterval(); open != nil {
+		open.End = &now
+	}
 	session.EndTime = &now
+	session.PausedAt = nil
 	session.State = StateStopped
 
-	// Calculate total duration
-	if session.PausedAt != nil {
-		// If paused, don't include time since pause
-		session.Duration = session.PausedAt.Sub(session.StartTime)
-	} else {
-		session.Duration = now.Sub(session.StartTime)
-	}
+	// Total duration only counts the worked intervals, not the breaks
+	session.Duration = session.WorkedDuration(now)
 
 	if err := t.saveSession(session); err != nil {
 		return nil, err
@@ -198,6 +354,10 @@ func (t *Tracker) Pause() (*Session, error) {
 	}
 
 	now := time.Now()
+	session.ensureIntervals()
+	if open := session.openInterval(); open != nil {
+		open.End = &now
+	}
 	session.PausedAt = &now
 	session.State = StatePaused
 
@@ -225,9 +385,9 @@ func (t *Tracker) Resume() (*Session, error) {
 		return nil, fmt.Errorf("session is not paused (state: %s)", session.State)
 	}
 
-	// Calculate duration while paused and adjust start time
-	pauseDuration := time.Since(*session.PausedAt)
-	session.StartTime = session.StartTime.Add(pauseDuration)
+	// Open a new work interval; the pause stays recorded as the gap between intervals
+	session.ensureIntervals()
+	session.Intervals = append(session.Intervals, Interval{Start: time.Now()})
 	session.PausedAt = nil
 	session.State = StateRunning
 
@@ -242,6 +402,44 @@ func (t *Tracker) Resume() (*Session, error) {
 	return session, nil
 }
 
+// Annotate updates the tags and note of the current session. Tags in remove
+// are dropped before tags in add are appended; a nil note leaves it unchanged.
+func (t *Tracker) Annotate(add, remove []string, note *string) (*Session, error) {
+	session, err := t.GetCurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no active session to annotate")
+	}
+
+	removed := make(map[string]bool)
+	for _, tag := range NormalizeTags(remove) {
+		removed[tag] = true
+	}
+	var tags []string
+	for _, tag := range session.Tags {
+		if !removed[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	session.Tags = NormalizeTags(append(tags, add...))
+
+	if note != nil {
+		session.Note = strings.TrimSpace(*note)
+	}
+
+	if err := t.saveSession(session); err != nil {
+		return nil, err
+	}
+
+	if err := t.setCurrentSession(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
 // GetCurrentSession returns the current active session
 func (t *Tracker) GetCurrentSession() (*Session, error) {
 	var session *Session
@@ -254,7 +452,11 @@ func (t *Tracker) GetCurrentSession() (*Session, error) {
 		}
 
 		session = &Session{}
-		return json.Unmarshal(data, session)
+		if err := json.Unmarshal(data, session); err != nil {
+			return err
+		}
+		session.ensureIntervals()
+		return nil
 	})
 
 	return session, err
@@ -271,13 +473,8 @@ func (t *Tracker) GetSessionDuration() (time.Duration, error) {
 	}
 
 	switch session.State {
-	case StateRunning:
-		return time.Since(session.StartTime), nil
-	case StatePaused:
-		if session.PausedAt != nil {
-			return session.PausedAt.Sub(session.StartTime), nil
-		}
-		return time.Since(session.StartTime), nil
+	case StateRunning, StatePaused:
+		return session.WorkedDuration(time.Now()), nil
 	case StateStopped:
 		return session.Duration, nil
 	default:
@@ -288,12 +485,7 @@ func (t *Tracker) GetSessionDuration() (time.Duration, error) {
 // saveSession saves a session to the database
 func (t *Tracker) saveSession(session *Session) error {
 	return t.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		data, err := json.Marshal(session)
-		if err != nil {
-			return err
-		}
-		return bucket.Put([]byte(session.ID), data)
+		return putSession(tx, session)
 	})
 }
 
@@ -317,101 +509,33 @@ func (t *Tracker) clearCurrentSession() error {
 	})
 }
 
-// GetDailyTotal returns the total time worked today
-func (t *Tracker) GetDailyTotal() (time.Duration, error) {
-	var total time.Duration
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+// SetCalendar sets the timezone and week start used for daily and weekly totals
+func (t *Tracker) SetCalendar(calendar Calendar) {
+	t.calendar = calendar
+}
 
-	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-
-			// Only count completed sessions from today
-			if session.State == StateStopped &&
-				session.StartTime.After(today) &&
-				session.StartTime.Before(tomorrow) {
-				total += session.Duration
-			}
-		}
-		return nil
-	})
+// Calendar returns the calendar used for daily and weekly totals
+func (t *Tracker) Calendar() Calendar {
+	return t.calendar
+}
 
-	return total, err
+// GetDailyTotal returns the total time worked today in completed sessions.
+// Sessions that cross midnight only count the part worked today.
+func (t *Tracker) GetDailyTotal() (time.Duration, error) {
+	return t.workedBetween(t.calendar.Day(time.Now()))
 }
 
-// GetWeeklyTotal returns the total time worked this week
+// GetWeeklyTotal returns the total time worked this week in completed sessions
 func (t *Tracker) GetWeeklyTotal() (time.Duration, error) {
-	var total time.Duration
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekStart = weekStart.Truncate(24 * time.Hour)
-	weekEnd := weekStart.Add(7 * 24 * time.Hour)
-
-	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-
-			if session.State == StateStopped &&
-				session.StartTime.After(weekStart) &&
-				session.StartTime.Before(weekEnd) {
-				total += session.Duration
-			}
-		}
-		return nil
-	})
-
-	return total, err
+	return t.workedBetween(t.calendar.Week(time.Now()))
 }
 
-// GetSessionHistory returns recent sessions
+// GetSessionHistory returns recent sessions, most recent first
 func (t *Tracker) GetSessionHistory(limit int) ([]*Session, error) {
 	var sessions []*Session
 
 	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		// Collect all sessions first
-		var allSessions []*Session
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-			if session.State == StateStopped {
-				allSessions = append(allSessions, &session)
-			}
-		}
-
-		// Sort by start time (most recent first)
-		for i := 0; i < len(allSessions)-1; i++ {
-			for j := i + 1; j < len(allSessions); j++ {
-				if allSessions[i].StartTime.Before(allSessions[j].StartTime) {
-					allSessions[i], allSessions[j] = allSessions[j], allSessions[i]
-				}
-			}
-		}
-
-		// Take only the requested number
-		if limit > 0 && len(allSessions) > limit {
-			sessions = allSessions[:limit]
-		} else {
-			sessions = allSessions
-		}
-
+		sessions = recentSessions(tx, limit)
 		return nil
 	})
 
@@ -420,22 +544,10 @@ func (t *Tracker) GetSessionHistory(limit int) ([]*Session, error) {
 
 // GetProjectStats returns time statistics by project
 func (t *Tracker) GetProjectStats() (map[string]time.Duration, error) {
-	stats := make(map[string]time.Duration)
+	var stats map[string]time.Duration
 
 	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-
-			if session.State == StateStopped {
-				stats[session.Project] += session.Duration
-			}
-		}
+		stats = projectStats(tx)
 		return nil
 	})
 
@@ -444,7 +556,13 @@ func (t *Tracker) GetProjectStats() (map[string]time.Duration, error) {
 
 // SetIdleThreshold sets the idle detection threshold
 func (t *Tracker) SetIdleThreshold(threshold time.Duration) {
-	t.idleDetector = NewIdleDetector(threshold)
+	t.idleDetector = NewIdleDetectorWithSources(threshold, t.idleDetector.sources)
+}
+
+// SetIdleSources sets the sources used to detect idle time on Linux. Nil
+// picks sources for the current session type.
+func (t *Tracker) SetIdleSources(sources []IdleSource) {
+	t.idleDetector = NewIdleDetectorWithSources(t.idleDetector.threshold, sources)
 }
 
 // StartIdleMonitoring starts monitoring for idle state changes
@@ -454,22 +572,7 @@ func (t *Tracker) StartIdleMonitoring() error {
 		return nil
 	}
 
-	t.idleStop = t.idleDetector.StartIdleMonitoring(
-		func() {
-			// On idle start - pause current session if running
-			session, err := t.GetCurrentSession()
-			if err != nil || session == nil || session.State != StateRunning {
-				return
-			}
-
-			// Auto-pause due to idle
-			_, _ = t.Pause()
-		},
-		func() {
-			// On idle end - could potentially resume, but we'll leave that manual
-			// to avoid accidentally resuming the wrong session
-		},
-	)
+	t.idleStop = t.idleDetector.StartIdleMonitoring(t.handleIdleStart, t.handleIdleEnd)
 
 	return nil
 }
@@ -494,6 +597,8 @@ func (t *Tracker) GetIdleTime() (time.Duration, error) {
 
 // SaveImportedSession saves an imported session to the database
 func (t *Tracker) SaveImportedSession(session *Session) error {
+	session.ensureIntervals()
+	session.Tags = NormalizeTags(session.Tags)
 	return t.saveSession(session)
 }
 
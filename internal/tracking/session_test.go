@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
 )
 
 func TestSessionState_String(t *testing.T) {
@@ -106,6 +107,129 @@ func TestTracker_PauseResumeSession(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTracker_PauseResumeRecordsIntervals(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	session, err := tracker.Start("test-project")
+	require.NoError(t, err)
+	startTime := session.StartTime
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = tracker.Pause()
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	resumed, err := tracker.Resume()
+	require.NoError(t, err)
+
+	// Resuming must not rewrite when the session really started
+	assert.True(t, resumed.StartTime.Equal(startTime))
+	require.Len(t, resumed.Intervals, 2)
+	assert.Nil(t, resumed.Intervals[1].End)
+
+	time.Sleep(5 * time.Millisecond)
+	stopped, err := tracker.Stop()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, stopped.Breaks())
+	assert.True(t, stopped.BreakDuration(time.Now()) >= 20*time.Millisecond)
+	assert.Equal(t, stopped.WorkedDuration(time.Now()), stopped.Duration)
+	assert.True(t, stopped.Duration < stopped.EndTime.Sub(stopped.StartTime))
+	for _, interval := range stopped.Intervals {
+		assert.NotNil(t, interval.End)
+	}
+}
+
+func TestSession_BreakAccounting(t *testing.T) {
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		ts := base.Add(time.Duration(minutes) * time.Minute)
+		return &ts
+	}
+
+	session := &Session{
+		StartTime: base,
+		State:     StateStopped,
+		Intervals: []Interval{
+			{Start: base, End: at(60)},
+			{Start: *at(75), End: at(120)},
+			{Start: *at(150), End: at(180)},
+		},
+	}
+
+	assert.Equal(t, 2*time.Hour+15*time.Minute, session.WorkedDuration(*at(200)))
+	assert.Equal(t, 2, session.Breaks())
+	assert.Equal(t, 45*time.Minute, session.BreakDuration(*at(200)))
+
+	// An ongoing pause counts as a break up to now
+	session.State = StatePaused
+	session.PausedAt = at(180)
+	assert.Equal(t, 3, session.Breaks())
+	assert.Equal(t, 65*time.Minute, session.BreakDuration(*at(200)))
+}
+
+func TestTracker_MigratesLegacySessions(t *testing.T) {
+	tracker := setupTestTracker(t)
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+	legacy := &Session{
+		ID:        "session_legacy",
+		Project:   "legacy",
+		StartTime: start,
+		EndTime:   &end,
+		Duration:  2 * time.Hour,
+		State:     StateStopped,
+	}
+	require.NoError(t, tracker.saveSession(legacy))
+
+	// Pretend the database predates the interval schema
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Delete(schemaVersionKey)
+	}))
+	require.NoError(t, tracker.Close())
+
+	tracker, err := NewTracker()
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	sessions, err := tracker.GetSessionHistory(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Len(t, sessions[0].Intervals, 1)
+	assert.True(t, sessions[0].Intervals[0].Start.Equal(start))
+	assert.True(t, sessions[0].Intervals[0].End.Equal(start.Add(2*time.Hour)))
+	assert.Equal(t, 2*time.Hour, sessions[0].WorkedDuration(time.Now()))
+}
+
+func TestTracker_TagsAndNote(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	session, err := tracker.StartWithDetails("test-project", []string{"review", " +review", "", "bugfix"}, " PR #42 ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review", "bugfix"}, session.Tags)
+	assert.Equal(t, "PR #42", session.Note)
+	assert.True(t, session.HasTag("+review"))
+
+	note := "Pairing"
+	annotated, err := tracker.Annotate([]string{"pairing"}, []string{"bugfix"}, &note)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review", "pairing"}, annotated.Tags)
+	assert.Equal(t, "Pairing", annotated.Note)
+
+	// Annotations survive stopping the session
+	stopped, err := tracker.Stop()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"review", "pairing"}, stopped.Tags)
+	assert.Equal(t, "Pairing", stopped.Note)
+
+	_, err = tracker.Annotate([]string{"late"}, nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no active session to annotate")
+}
+
 func TestTracker_GetSessionDuration(t *testing.T) {
 	tracker := setupTestTracker(t)
 	defer tracker.Close()
@@ -259,7 +383,7 @@ func TestTracker_GetProjectStats(t *testing.T) {
 }
 
 // setupTestTracker creates a tracker with a temporary database for testing
-func setupTestTracker(t *testing.T) *Tracker {
+func setupTestTracker(t testing.TB) *Tracker {
 	// Create a temporary directory for the test database
 	tempDir := t.TempDir()
 
@@ -0,0 +1,166 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectManifests are the files that mark a package directory, in the order
+// they are checked
+var projectManifests = []string{"package.json", "go.mod", "Cargo.toml", "pyproject.toml", "setup.py"}
+
+// detectInRepository names the project for a directory inside a git
+// repository. It walks up from dir to the repository root looking for the
+// nearest package manifest and the nearest workspace root (go.work,
+// pnpm-workspace.yaml, package.json workspaces or a Cargo workspace). A
+// package below the repository or workspace root is reported as
+// "<repo>/<package>".
+func (pd *ProjectDetector) detectInRepository(dir, gitRoot string) ProjectMatch {
+	var pkgDir, pkgManifest, workspaceDir, workspaceMarker string
+
+	for current := dir; ; current = filepath.Dir(current) {
+		if pkgDir == "" {
+			if manifest := pd.findManifest(current); manifest != "" {
+				pkgDir, pkgManifest = current, manifest
+			}
+		}
+		if workspaceDir == "" {
+			if marker := pd.workspaceMarker(current); marker != "" {
+				workspaceDir, workspaceMarker = current, marker
+			}
+		}
+
+		if current == gitRoot || filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	// The repository level is the workspace root if there is one, else the git root
+	repoDir := gitRoot
+	repoName := pd.getProjectNameFromGit(gitRoot)
+	repoReason := fmt.Sprintf("git repository %q", repoName)
+	if workspaceDir != "" && workspaceDir != gitRoot {
+		repoDir = workspaceDir
+		repoName = pd.packageName(workspaceDir, pd.findManifest(workspaceDir))
+		repoReason = fmt.Sprintf("workspace %q (%s)", repoName, workspaceMarker)
+	} else if workspaceDir != "" {
+		repoReason = fmt.Sprintf("%s (%s)", repoReason, workspaceMarker)
+	}
+
+	// A manifest above a nested workspace belongs to an outer package
+	if pkgDir != "" && !isWithin(pkgDir, repoDir) {
+		pkgDir = ""
+	}
+
+	switch {
+	case pkgDir == "" || (pkgDir == repoDir && workspaceDir == repoDir):
+		// Nothing below the root, or the root is only a workspace definition
+		return ProjectMatch{Project: repoName, Repo: repoName, Reason: repoReason}
+
+	case pkgDir == repoDir:
+		// A single-package repository is named after its package
+		name := pd.packageName(pkgDir, pkgManifest)
+		return ProjectMatch{Project: name, Reason: fmt.Sprintf("name in %s", pkgManifest)}
+
+	default:
+		name := pd.packageName(pkgDir, pkgManifest)
+		rel, _ := filepath.Rel(repoDir, pkgDir)
+		return ProjectMatch{
+			Project: repoName + "/" + name,
+			Repo:    repoName,
+			Package: name,
+			Reason:  fmt.Sprintf("%s in %s of %s", pkgManifest, filepath.ToSlash(rel), repoReason),
+		}
+	}
+}
+
+// findManifest returns the first package manifest found in dir
+func (pd *ProjectDetector) findManifest(dir string) string {
+	for _, manifest := range projectManifests {
+		if pd.hasFile(dir, manifest) {
+			return manifest
+		}
+	}
+	return ""
+}
+
+// packageName reads a package's name from its manifest. npm scopes are
+// dropped, so "@acme/api" becomes "api".
+func (pd *ProjectDetector) packageName(dir, manifest string) string {
+	switch manifest {
+	case "package.json":
+		name := pd.getProjectNameFromPackageJSON(dir)
+		if strings.HasPrefix(name, "@") {
+			name = name[strings.Index(name, "/")+1:]
+		}
+		return name
+	case "go.mod":
+		return pd.getProjectNameFromGoMod(dir)
+	case "Cargo.toml":
+		return pd.getProjectNameFromCargoToml(dir)
+	case "pyproject.toml", "setup.py":
+		return pd.getProjectNameFromPython(dir)
+	default:
+		return filepath.Base(dir)
+	}
+}
+
+// workspaceMarker returns the file that makes dir a workspace root, or ""
+func (pd *ProjectDetector) workspaceMarker(dir string) string {
+	if pd.hasFile(dir, "go.work") {
+		return "go.work"
+	}
+	if pd.hasFile(dir, "pnpm-workspace.yaml") {
+		return "pnpm-workspace.yaml"
+	}
+	if pd.hasNPMWorkspaces(dir) {
+		return "package.json workspaces"
+	}
+	if pd.hasCargoWorkspace(dir) {
+		return "Cargo workspace"
+	}
+	return ""
+}
+
+// hasNPMWorkspaces reports whether dir's package.json declares workspaces
+func (pd *ProjectDetector) hasNPMWorkspaces(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	return len(pkg.Workspaces) > 0 && string(pkg.Workspaces) != "null"
+}
+
+// hasCargoWorkspace reports whether dir's Cargo.toml has a [workspace] section
+func (pd *ProjectDetector) hasCargoWorkspace(dir string) bool {
+	file, err := os.Open(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "[workspace]" {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,129 @@
+package tracking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates files (and their directories) under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestProjectDetector_Monorepos(t *testing.T) {
+	detector := NewProjectDetector()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		dir     string
+		project string
+		repo    string
+		pkg     string
+	}{
+		{
+			name:    "package below the repository root",
+			files:   map[string]string{"services/api/go.mod": "module github.com/acme/platform/services/api"},
+			dir:     "services/api/internal/handlers",
+			project: "platform/api",
+			repo:    "platform",
+			pkg:     "api",
+		},
+		{
+			name:    "no manifest uses the repository name",
+			files:   map[string]string{"docs/README.md": "# docs"},
+			dir:     "docs",
+			project: "platform",
+			repo:    "platform",
+		},
+		{
+			name:    "single-package repository",
+			files:   map[string]string{"package.json": `{"name": "web-app"}`},
+			dir:     "src/components",
+			project: "web-app",
+		},
+		{
+			name: "go workspace",
+			files: map[string]string{
+				"go.work":         "go 1.22\nuse ./cli\n",
+				"cli/go.mod":      "module example.com/cli",
+				"cli/cmd/main.go": "package main",
+			},
+			dir:     "cli/cmd",
+			project: "platform/cli",
+			repo:    "platform",
+			pkg:     "cli",
+		},
+		{
+			name: "npm workspaces with scoped packages",
+			files: map[string]string{
+				"package.json":             `{"name": "root", "workspaces": ["packages/*"]}`,
+				"packages/ui/package.json": `{"name": "@acme/ui"}`,
+			},
+			dir:     "packages/ui",
+			project: "platform/ui",
+			repo:    "platform",
+			pkg:     "ui",
+		},
+		{
+			name:    "workspace root itself",
+			files:   map[string]string{"package.json": `{"name": "root", "workspaces": ["packages/*"]}`},
+			dir:     ".",
+			project: "platform",
+			repo:    "platform",
+		},
+		{
+			name: "nested pnpm workspace",
+			files: map[string]string{
+				"frontend/pnpm-workspace.yaml":     "packages:\n  - apps/*\n",
+				"frontend/package.json":            `{"name": "frontend"}`,
+				"frontend/apps/admin/package.json": `{"name": "admin"}`,
+			},
+			dir:     "frontend/apps/admin",
+			project: "frontend/admin",
+			repo:    "frontend",
+			pkg:     "admin",
+		},
+		{
+			name: "cargo workspace",
+			files: map[string]string{
+				"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n",
+				"crates/core/Cargo.toml": "[package]\nname = \"engine-core\"\n",
+			},
+			dir:     "crates/core/src",
+			project: "platform/engine-core",
+			repo:    "platform",
+			pkg:     "engine-core",
+		},
+	}
+
+	originalCwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalCwd) }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "platform")
+			require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+			writeFiles(t, root, test.files)
+
+			dir := filepath.Join(root, test.dir)
+			require.NoError(t, os.MkdirAll(dir, 0755))
+			require.NoError(t, os.Chdir(dir))
+
+			match := detector.Detect()
+			assert.Equal(t, test.project, match.Project)
+			assert.Equal(t, test.repo, match.Repo)
+			assert.Equal(t, test.pkg, match.Package)
+			assert.NotEmpty(t, match.Reason)
+		})
+	}
+}